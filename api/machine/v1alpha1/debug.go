@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"net"
+	"strconv"
+)
+
+const (
+	// DefaultGDBPort is the port on which the GDB server of a machine listens by
+	// default, which follows that of QEMU.
+	DefaultGDBPort = 1234
+
+	// DefaultGDBHost is the address on the host on which the GDB server of a
+	// machine listens by default, such that it is only reachable locally.
+	DefaultGDBHost = "127.0.0.1"
+
+	// CrashDumpDir is the name of the directory within the state directory of a
+	// machine which contains the ELF cores of its guest which are dumped when it
+	// panics.
+	CrashDumpDir = "crash"
+
+	// CrashDumpExt is the extension of the ELF cores within CrashDumpDir.
+	CrashDumpExt = ".core"
+)
+
+// MachineDebug describes the GDB server through which the kernel of the
+// machine is debugged.
+type MachineDebug struct {
+	// GDBPort is the port on the host on which the GDB server listens.
+	GDBPort int32 `json:"gdbPort"`
+
+	// GDBHost is the address on the host on which the GDB server listens, which
+	// defaults to DefaultGDBHost.  Listening on a public address allows for the
+	// machine to be debugged remotely.
+	GDBHost string `json:"gdbHost,omitempty"`
+
+	// Wait indicates whether the machine remains paused once started until it
+	// is continued by the debugger.
+	Wait bool `json:"wait,omitempty"`
+}
+
+// Address returns the address on the host on which the GDB server listens.
+func (debug MachineDebug) Address() string {
+	host := debug.GDBHost
+	if host == "" {
+		host = DefaultGDBHost
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(debug.GDBPort)))
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// MachineFilter selects machines by their labels, state, platform and
+// architecture.  A machine matches the filter if it matches every label and,
+// for each other attribute, at least one of the values filtered by.
+type MachineFilter struct {
+	// Labels the machine must have.
+	Labels labels.Selector
+
+	// States of which the machine must be in one.
+	States []MachineState
+
+	// Platforms of which the machine must run on one.
+	Platforms []string
+
+	// Architectures of which the machine must run on one.
+	Architectures []string
+}
+
+// ParseMachineFilter parses "docker-like" filters, i.e. comma-separated lists
+// of label=KEY[=VALUE], state=STATE, plat=PLAT or arch=ARCH, into a
+// MachineFilter.
+func ParseMachineFilter(filters ...string) (*MachineFilter, error) {
+	filter := MachineFilter{
+		Labels: labels.Everything(),
+	}
+
+	for _, f := range filters {
+		for _, pair := range strings.Split(f, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("malformed filter '%s': expected KEY=VALUE", pair)
+			}
+
+			switch key {
+			case "label":
+				labelKey, labelValue, hasValue := strings.Cut(value, "=")
+
+				op := selection.Exists
+				values := []string{}
+				if hasValue {
+					op = selection.Equals
+					values = []string{labelValue}
+				}
+
+				req, err := labels.NewRequirement(labelKey, op, values)
+				if err != nil {
+					return nil, fmt.Errorf("invalid label filter '%s': %w", value, err)
+				}
+
+				filter.Labels = filter.Labels.Add(*req)
+
+			case "state":
+				state := MachineState(value)
+				known := false
+				for _, s := range MachineStates() {
+					if s == state {
+						known = true
+						break
+					}
+				}
+
+				if !known {
+					return nil, fmt.Errorf("unknown machine state '%s'", value)
+				}
+
+				filter.States = append(filter.States, state)
+
+			case "plat":
+				filter.Platforms = append(filter.Platforms, value)
+
+			case "arch":
+				filter.Architectures = append(filter.Architectures, value)
+
+			default:
+				return nil, fmt.Errorf("unknown filter '%s': expected one of label, state, plat or arch", key)
+			}
+		}
+	}
+
+	return &filter, nil
+}
+
+// Matches returns whether the provided machine is selected by the filter.  A
+// nil filter matches every machine.
+func (filter *MachineFilter) Matches(machine *Machine) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.Labels != nil && !filter.Labels.Matches(labels.Set(machine.Labels)) {
+		return false
+	}
+
+	if len(filter.States) > 0 && !contains(filter.States, machine.Status.State) {
+		return false
+	}
+
+	if len(filter.Platforms) > 0 && !contains(filter.Platforms, machine.Spec.Platform) {
+		return false
+	}
+
+	if len(filter.Architectures) > 0 && !contains(filter.Architectures, machine.Spec.Architecture) {
+		return false
+	}
+
+	return true
+}
+
+// contains returns whether the provided value is one of the provided values.
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
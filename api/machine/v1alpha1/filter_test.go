@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineFilter(t *testing.T) {
+	machine := Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app":  "nginx",
+				"tier": "frontend",
+			},
+		},
+		Spec: MachineSpec{
+			Platform:     "qemu",
+			Architecture: "x86_64",
+		},
+		Status: MachineStatus{
+			State: MachineStateRunning,
+		},
+	}
+
+	tests := []struct {
+		filters []string
+		want    bool
+		wantErr bool
+	}{
+		{filters: nil, want: true},
+		{filters: []string{"label=app"}, want: true},
+		{filters: []string{"label=app=nginx"}, want: true},
+		{filters: []string{"label=app=redis"}, want: false},
+		{filters: []string{"label=app=nginx,label=tier=backend"}, want: false},
+		{filters: []string{"label=app=nginx", "label=tier=frontend"}, want: true},
+		{filters: []string{"label=env"}, want: false},
+		{filters: []string{"state=running"}, want: true},
+		{filters: []string{"state=exited,state=running"}, want: true},
+		{filters: []string{"state=exited"}, want: false},
+		{filters: []string{"plat=qemu,arch=x86_64"}, want: true},
+		{filters: []string{"plat=fc"}, want: false},
+		{filters: []string{"arch=arm64,arch=x86_64"}, want: true},
+		{filters: []string{"state=sleeping"}, wantErr: true},
+		{filters: []string{"name=nginx"}, wantErr: true},
+		{filters: []string{"label"}, wantErr: true},
+		{filters: []string{"label=in valid"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.filters, ";"), func(t *testing.T) {
+			filter, err := ParseMachineFilter(tt.filters...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMachineFilter(%q) error = %v, wantErr %v", tt.filters, err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if got := filter.Matches(&machine); got != tt.want {
+				t.Errorf("ParseMachineFilter(%q).Matches() = %v, want %v", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Defaults of health checks which follow those of Docker.
+	DefaultHealthCheckInterval = 30 * time.Second
+	DefaultHealthCheckTimeout  = 30 * time.Second
+	DefaultHealthCheckRetries  = 3
+)
+
+// MachineHealthCheck describes a check which is periodically performed from
+// the host to determine whether the machine is healthy.  Exactly one of TCP,
+// HTTP or Log must be set.
+type MachineHealthCheck struct {
+	// TCP checks whether a connection can be established to a port of the
+	// machine.
+	TCP *MachineHealthCheckTCP `json:"tcp,omitempty"`
+
+	// HTTP checks whether a GET request to the machine succeeds.
+	HTTP *MachineHealthCheckHTTP `json:"http,omitempty"`
+
+	// Log checks whether the serial console output of the machine matches a
+	// regular expression.
+	Log *MachineHealthCheckLog `json:"log,omitempty"`
+
+	// Interval between two consecutive checks.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Timeout after which a single check is considered to have failed.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// Retries is the number of consecutive failures after which the machine is
+	// considered unhealthy.
+	Retries int `json:"retries,omitempty"`
+
+	// StartPeriod is the duration after the machine has started during which
+	// failures do not count towards the number of retries.
+	StartPeriod metav1.Duration `json:"startPeriod,omitempty"`
+}
+
+// MachineHealthCheckTCP checks whether a connection can be established.
+type MachineHealthCheckTCP struct {
+	// Host to connect to.  Defaults to the IP address of the machine.
+	Host string `json:"host,omitempty"`
+
+	// Port of the machine to connect to.
+	Port int32 `json:"port"`
+}
+
+// MachineHealthCheckHTTP checks whether a GET request succeeds, i.e. whether
+// its response has a status code less than 400.
+type MachineHealthCheckHTTP struct {
+	// Scheme of the request, either "http" or "https".  Defaults to "http".
+	Scheme string `json:"scheme,omitempty"`
+
+	// Host to send the request to.  Defaults to the IP address of the machine.
+	Host string `json:"host,omitempty"`
+
+	// Port of the machine to send the request to.
+	Port int32 `json:"port"`
+
+	// Path of the request.
+	Path string `json:"path,omitempty"`
+}
+
+// MachineHealthCheckLog checks whether the serial console output of the
+// machine contains a line which matches a regular expression.
+type MachineHealthCheckLog struct {
+	// Pattern is the regular expression which is matched against each line.
+	Pattern string `json:"pattern"`
+}
+
+// ParseHealthCheck parses a string representation of the test of a
+// MachineHealthCheck and returns the instantiated structure with default
+// timings.  The test is one of:
+//
+//	tcp://[HOST]:PORT
+//	http[s]://[HOST]:PORT[/PATH]
+//	log:REGEX
+//
+// where an empty host refers to the IP address of the machine.
+func ParseHealthCheck(s string) (*MachineHealthCheck, error) {
+	check := MachineHealthCheck{
+		Interval: metav1.Duration{Duration: DefaultHealthCheckInterval},
+		Timeout:  metav1.Duration{Duration: DefaultHealthCheckTimeout},
+		Retries:  DefaultHealthCheckRetries,
+	}
+
+	if pattern, ok := strings.CutPrefix(s, "log:"); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid health check pattern: %w", err)
+		}
+
+		check.Log = &MachineHealthCheckLog{
+			Pattern: pattern,
+		}
+
+		return &check, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid health check: %w", err)
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		host = ""
+	}
+
+	port, err := strconv.ParseInt(u.Port(), 10, 32)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid health check: missing or invalid port: %s", s)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		check.TCP = &MachineHealthCheckTCP{
+			Host: host,
+			Port: int32(port),
+		}
+
+	case "http", "https":
+		check.HTTP = &MachineHealthCheckHTTP{
+			Scheme: u.Scheme,
+			Host:   host,
+			Port:   int32(port),
+			Path:   u.RequestURI(),
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid health check: expected tcp://, http(s):// or log: but got: %s", s)
+	}
+
+	return &check, nil
+}
+
+// String implements fmt.Stringer and outputs the test of the
+// MachineHealthCheck in the same format which is accepted by
+// ParseHealthCheck.
+func (check *MachineHealthCheck) String() string {
+	switch {
+	case check.TCP != nil:
+		return fmt.Sprintf("tcp://%s:%d", check.TCP.Host, check.TCP.Port)
+	case check.HTTP != nil:
+		scheme := check.HTTP.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+
+		return fmt.Sprintf("%s://%s:%d%s", scheme, check.HTTP.Host, check.HTTP.Port, check.HTTP.Path)
+	case check.Log != nil:
+		return "log:" + check.Log.Pattern
+	}
+
+	return ""
+}
+
+// MachineHealthStatus indicates the health of the machine.
+type MachineHealthStatus string
+
+const (
+	MachineHealthStarting  = MachineHealthStatus("starting")
+	MachineHealthHealthy   = MachineHealthStatus("healthy")
+	MachineHealthUnhealthy = MachineHealthStatus("unhealthy")
+)
+
+// String implements fmt.Stringer
+func (mhs MachineHealthStatus) String() string {
+	return string(mhs)
+}
+
+// MachineHealth contains the result of the health check of the machine.
+type MachineHealth struct {
+	// Status is the health of the machine.
+	Status MachineHealthStatus `json:"status"`
+
+	// FailingStreak is the number of consecutive failed checks.
+	FailingStreak int `json:"failingStreak,omitempty"`
+
+	// LastError is the reason the most recent check failed, if it did.
+	LastError string `json:"lastError,omitempty"`
+
+	// CheckedAt represents when the most recent check was performed.
+	CheckedAt time.Time `json:"checkedAt,omitempty"`
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHealthCheck(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantTCP  *MachineHealthCheckTCP
+		wantHTTP *MachineHealthCheckHTTP
+		wantLog  *MachineHealthCheckLog
+		wantErr  bool
+	}{
+		{
+			in:      "tcp://:5432",
+			wantTCP: &MachineHealthCheckTCP{Port: 5432},
+		},
+		{
+			in:      "tcp://localhost:5432",
+			wantTCP: &MachineHealthCheckTCP{Port: 5432},
+		},
+		{
+			in:      "tcp://10.0.0.1:5432",
+			wantTCP: &MachineHealthCheckTCP{Host: "10.0.0.1", Port: 5432},
+		},
+		{
+			in:       "http://:8080/health?ready=1",
+			wantHTTP: &MachineHealthCheckHTTP{Scheme: "http", Port: 8080, Path: "/health?ready=1"},
+		},
+		{
+			in:       "https://:8443",
+			wantHTTP: &MachineHealthCheckHTTP{Scheme: "https", Port: 8443, Path: "/"},
+		},
+		{
+			in:      "log:^Listening on port [0-9]+$",
+			wantLog: &MachineHealthCheckLog{Pattern: "^Listening on port [0-9]+$"},
+		},
+		{in: "log:(", wantErr: true},
+		{in: "tcp://:0", wantErr: true},
+		{in: "http://localhost/", wantErr: true},
+		{in: "udp://:53", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseHealthCheck(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHealthCheck(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got.TCP, tt.wantTCP) {
+				t.Errorf("ParseHealthCheck(%q).TCP = %+v, want %+v", tt.in, got.TCP, tt.wantTCP)
+			}
+
+			if !reflect.DeepEqual(got.HTTP, tt.wantHTTP) {
+				t.Errorf("ParseHealthCheck(%q).HTTP = %+v, want %+v", tt.in, got.HTTP, tt.wantHTTP)
+			}
+
+			if !reflect.DeepEqual(got.Log, tt.wantLog) {
+				t.Errorf("ParseHealthCheck(%q).Log = %+v, want %+v", tt.in, got.Log, tt.wantLog)
+			}
+
+			if got.Retries != DefaultHealthCheckRetries {
+				t.Errorf("ParseHealthCheck(%q).Retries = %d, want %d", tt.in, got.Retries, DefaultHealthCheckRetries)
+			}
+		})
+	}
+}
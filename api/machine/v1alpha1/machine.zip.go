@@ -90,6 +90,21 @@ type MachineSpec struct {
 
 	// Emulation indicates whether to use VMM emulation.
 	Emulation bool `json:"emulation,omitempty"`
+
+	// RestartPolicy determines whether the machine is restarted when it exits.
+	RestartPolicy MachineRestartPolicy `json:"restartPolicy,omitempty"`
+
+	// HealthCheck is periodically performed to determine whether the machine is
+	// healthy.
+	HealthCheck *MachineHealthCheck `json:"healthCheck,omitempty"`
+
+	// Debug exposes a GDB server through which the kernel of the machine can be
+	// debugged.
+	Debug *MachineDebug `json:"debug,omitempty"`
+
+	// CrashDump indicates whether the memory of the guest is dumped into the
+	// state directory of the machine when it panics.
+	CrashDump bool `json:"crashDump,omitempty"`
 }
 
 // MachineState indicates the state of the machine.
@@ -107,11 +122,51 @@ const (
 	MachineStateErrored    = MachineState("errored")
 )
 
+// MachineStates returns all the known states of a machine.
+func MachineStates() []MachineState {
+	return []MachineState{
+		MachineStateUnknown,
+		MachineStateCreated,
+		MachineStateFailed,
+		MachineStateRestarting,
+		MachineStateRunning,
+		MachineStatePaused,
+		MachineStateSuspended,
+		MachineStateExited,
+		MachineStateErrored,
+	}
+}
+
 // String implements fmt.Stringer
 func (ms MachineState) String() string {
 	return string(ms)
 }
 
+// MachineExitReason indicates why the machine has exited.
+type MachineExitReason string
+
+const (
+	// The guest has shut itself down.
+	MachineExitReasonShutdown = MachineExitReason("shutdown")
+	// The guest has requested to be reset, which machines cannot be.
+	MachineExitReasonReset = MachineExitReason("reset")
+	// The guest has panicked.
+	MachineExitReasonPanic = MachineExitReason("panic")
+	// The watchdog of the guest has expired.
+	MachineExitReasonWatchdog = MachineExitReason("watchdog")
+	// The machine has been stopped on request.
+	MachineExitReasonStopped = MachineExitReason("stopped")
+	// The VMM has been killed, e.g. by a signal.
+	MachineExitReasonKilled = MachineExitReason("killed")
+	// The VMM has encountered an error.
+	MachineExitReasonError = MachineExitReason("error")
+)
+
+// String implements fmt.Stringer
+func (mer MachineExitReason) String() string {
+	return string(mer)
+}
+
 // MachineStatus contains the complete status of the machine instance.
 type MachineStatus struct {
 	// State is the current state of the machine instance.
@@ -135,12 +190,35 @@ type MachineStatus struct {
 	// ExitedAt represents when the machine fully shutdown
 	ExitedAt time.Time `json:"exitedAt,omitempty"`
 
+	// ExitReason indicates why the machine has exited, if it has and this is
+	// known.
+	ExitReason MachineExitReason `json:"exitReason,omitempty"`
+
 	// StateDir contains the path of the state of the machine.
 	StateDir string `json:"stateDir,omitempty"`
 
 	// LogFile is the in-host path to the log file of the machine.
 	LogFile string `json:"logFile,omitempty"`
 
+	// ConsoleSocket is the in-host path to the UNIX socket through which the
+	// serial console of the machine can be attached to, if it has one.
+	ConsoleSocket string `json:"consoleSocket,omitempty"`
+
+	// Health is the result of the health check of the machine, if it has one.
+	Health *MachineHealth `json:"health,omitempty"`
+
+	// RestartCount is the number of times the machine has been restarted
+	// according to its restart policy.
+	RestartCount int `json:"restartCount,omitempty"`
+
+	// StoppedExplicitly indicates whether the machine was stopped on request, as
+	// opposed to having exited on its own, such that it is not restarted.
+	StoppedExplicitly bool `json:"stoppedExplicitly,omitempty"`
+
+	// SnapshotDir is the in-host path to the snapshot from which the state of the
+	// machine is restored when it is created, if any.
+	SnapshotDir string `json:"snapshotDir,omitempty"`
+
 	// PlatformConfig is platform-specific attributes which are populated by the
 	// underlying machine service implementation.
 	PlatformConfig interface{} `json:"platformConfig,omitempty"`
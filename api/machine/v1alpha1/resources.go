@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Resources which, in addition to corev1.ResourceCPU and
+// corev1.ResourceMemory, limit the host resources consumed by the VMM of a
+// machine when set in the limits of its resource requirements.
+const (
+	// ResourceCPUWeight is the relative share of CPU time of the VMM, in the
+	// range of 1 to 10000 with a default of 100.
+	ResourceCPUWeight = corev1.ResourceName("kraftkit.sh/cpu-weight")
+
+	// ResourceIOWeight is the relative share of block IO of the VMM, in the
+	// range of 1 to 10000 with a default of 100.
+	ResourceIOWeight = corev1.ResourceName("kraftkit.sh/io-weight")
+
+	// ResourceIOReadBPS is the maximum number of bytes per second the VMM reads
+	// from each block device of the host.
+	ResourceIOReadBPS = corev1.ResourceName("kraftkit.sh/io-read-bps")
+
+	// ResourceIOWriteBPS is the maximum number of bytes per second the VMM
+	// writes to each block device of the host.
+	ResourceIOWriteBPS = corev1.ResourceName("kraftkit.sh/io-write-bps")
+)
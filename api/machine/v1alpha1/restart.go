@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RestartPolicyName is the name of a policy which determines whether a machine
+// is restarted when it exits.
+type RestartPolicyName string
+
+const (
+	RestartPolicyNo            = RestartPolicyName("no")
+	RestartPolicyOnFailure     = RestartPolicyName("on-failure")
+	RestartPolicyAlways        = RestartPolicyName("always")
+	RestartPolicyUnlessStopped = RestartPolicyName("unless-stopped")
+)
+
+// RestartPolicyNames returns all the supported restart policies.
+func RestartPolicyNames() []RestartPolicyName {
+	return []RestartPolicyName{
+		RestartPolicyNo,
+		RestartPolicyOnFailure,
+		RestartPolicyAlways,
+		RestartPolicyUnlessStopped,
+	}
+}
+
+// MachineRestartPolicy determines whether a machine is restarted when it exits.
+type MachineRestartPolicy struct {
+	// Name of the policy.  An empty name is equivalent to "no".
+	Name RestartPolicyName `json:"name,omitempty"`
+
+	// MaximumRetryCount is the number of times a machine with the "on-failure"
+	// policy is restarted before giving up.  Zero means no limit.
+	MaximumRetryCount int `json:"maximumRetryCount,omitempty"`
+}
+
+// ParseRestartPolicy parses a string representation of a MachineRestartPolicy
+// which follows the "docker-like" syntax of the `--restart` flag, i.e.
+// no|on-failure[:max-retries]|always|unless-stopped.
+func ParseRestartPolicy(s string) (MachineRestartPolicy, error) {
+	name, retries, hasRetries := strings.Cut(s, ":")
+
+	policy := MachineRestartPolicy{
+		Name: RestartPolicyName(name),
+	}
+
+	switch policy.Name {
+	case "", RestartPolicyNo, RestartPolicyAlways, RestartPolicyUnlessStopped:
+		if hasRetries {
+			return policy, fmt.Errorf("maximum retry count cannot be used with restart policy '%s'", name)
+		}
+
+	case RestartPolicyOnFailure:
+		if !hasRetries {
+			break
+		}
+
+		count, err := strconv.Atoi(retries)
+		if err != nil || count < 0 {
+			return policy, fmt.Errorf("invalid maximum retry count: %s", retries)
+		}
+
+		policy.MaximumRetryCount = count
+
+	default:
+		return policy, fmt.Errorf("unknown restart policy: %s (choice of %v)", name, RestartPolicyNames())
+	}
+
+	if policy.Name == "" {
+		policy.Name = RestartPolicyNo
+	}
+
+	return policy, nil
+}
+
+// String implements fmt.Stringer and outputs the MachineRestartPolicy in the
+// same format which is accepted by ParseRestartPolicy.
+func (policy MachineRestartPolicy) String() string {
+	if policy.Name == "" {
+		return string(RestartPolicyNo)
+	}
+
+	if policy.Name == RestartPolicyOnFailure && policy.MaximumRetryCount > 0 {
+		return fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+	}
+
+	return string(policy.Name)
+}
+
+// ShouldRestart returns whether a machine with the provided status is to be
+// restarted according to the policy.  Machines which have been stopped
+// explicitly are not restarted, unless the policy is "always" and the machine
+// is encountered for the first time since its monitor started, similar to the
+// behavior of Docker when its daemon is restarted.
+func (policy MachineRestartPolicy) ShouldRestart(status MachineStatus, monitorStarted bool) bool {
+	switch status.State {
+	case MachineStateExited, MachineStateFailed, MachineStateErrored:
+	default:
+		return false
+	}
+
+	if status.StoppedExplicitly && !(monitorStarted && policy.Name == RestartPolicyAlways) {
+		return false
+	}
+
+	switch policy.Name {
+	case RestartPolicyAlways, RestartPolicyUnlessStopped:
+		return true
+
+	case RestartPolicyOnFailure:
+		failed := status.State != MachineStateExited || status.ExitCode > 0
+		withinLimit := policy.MaximumRetryCount == 0 || status.RestartCount < policy.MaximumRetryCount
+
+		return failed && withinLimit
+	}
+
+	return false
+}
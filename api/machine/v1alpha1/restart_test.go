@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import "testing"
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    MachineRestartPolicy
+		wantErr bool
+	}{
+		{in: "", want: MachineRestartPolicy{Name: RestartPolicyNo}},
+		{in: "no", want: MachineRestartPolicy{Name: RestartPolicyNo}},
+		{in: "always", want: MachineRestartPolicy{Name: RestartPolicyAlways}},
+		{in: "unless-stopped", want: MachineRestartPolicy{Name: RestartPolicyUnlessStopped}},
+		{in: "on-failure", want: MachineRestartPolicy{Name: RestartPolicyOnFailure}},
+		{in: "on-failure:3", want: MachineRestartPolicy{Name: RestartPolicyOnFailure, MaximumRetryCount: 3}},
+		{in: "on-failure:-1", wantErr: true},
+		{in: "on-failure:x", wantErr: true},
+		{in: "always:3", wantErr: true},
+		{in: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRestartPolicy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRestartPolicy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseRestartPolicy(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		status         MachineStatus
+		monitorStarted bool
+		want           bool
+	}{
+		{
+			name:   "no policy",
+			policy: "no",
+			status: MachineStatus{State: MachineStateErrored, ExitCode: 1},
+			want:   false,
+		},
+		{
+			name:   "running machine",
+			policy: "always",
+			status: MachineStatus{State: MachineStateRunning},
+			want:   false,
+		},
+		{
+			name:   "always after clean exit",
+			policy: "always",
+			status: MachineStatus{State: MachineStateExited},
+			want:   true,
+		},
+		{
+			name:   "always after explicit stop",
+			policy: "always",
+			status: MachineStatus{State: MachineStateExited, StoppedExplicitly: true},
+			want:   false,
+		},
+		{
+			name:           "always after explicit stop when monitor started",
+			policy:         "always",
+			status:         MachineStatus{State: MachineStateExited, StoppedExplicitly: true},
+			monitorStarted: true,
+			want:           true,
+		},
+		{
+			name:           "unless-stopped after explicit stop when monitor started",
+			policy:         "unless-stopped",
+			status:         MachineStatus{State: MachineStateExited, StoppedExplicitly: true},
+			monitorStarted: true,
+			want:           false,
+		},
+		{
+			name:   "on-failure after clean exit",
+			policy: "on-failure",
+			status: MachineStatus{State: MachineStateExited},
+			want:   false,
+		},
+		{
+			name:   "on-failure after non-zero exit",
+			policy: "on-failure",
+			status: MachineStatus{State: MachineStateExited, ExitCode: 1},
+			want:   true,
+		},
+		{
+			name:   "on-failure after crash",
+			policy: "on-failure",
+			status: MachineStatus{State: MachineStateErrored},
+			want:   true,
+		},
+		{
+			name:   "on-failure within limit",
+			policy: "on-failure:3",
+			status: MachineStatus{State: MachineStateFailed, RestartCount: 2},
+			want:   true,
+		},
+		{
+			name:   "on-failure exceeding limit",
+			policy: "on-failure:3",
+			status: MachineStatus{State: MachineStateFailed, RestartCount: 3},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseRestartPolicy(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := policy.ShouldRestart(tt.status, tt.monitorStarted); got != tt.want {
+				t.Errorf("ShouldRestart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// NetworkAttachment describes the interface through which a machine is
+// attached to a network.
+type NetworkAttachment struct {
+	// Driver is the name of the strategy which implements the network.
+	Driver string
+
+	// Network is the name of the network.
+	Network string
+
+	// Interface is the requested interface of the machine, whose unset
+	// attributes are populated by the network driver.
+	Interface NetworkInterfaceSpec
+}
+
+// ParseNetworkAttachment parses a string representation of a
+// NetworkAttachment which follows the syntax of the `--network` flag, i.e.
+// DRIVER:NETWORK[,ip=IP][,mac=MAC][,gateway=GATEWAY][,ifname=IFNAME].  The
+// ip and gateway parameters may be provided once for each address family.
+func ParseNetworkAttachment(s string) (NetworkAttachment, error) {
+	var attachment NetworkAttachment
+
+	params := strings.Split(s, ",")
+
+	driver, network, ok := strings.Cut(params[0], ":")
+	if !ok || driver == "" || network == "" {
+		return attachment, fmt.Errorf("specifying a network must be in the format <driver>:<network> e.g. --network=bridge:kraft0")
+	}
+
+	attachment.Driver = driver
+	attachment.Network = network
+
+	for _, param := range params[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || value == "" {
+			return attachment, fmt.Errorf("expected network parameter in the format KEY=VALUE but got: '%s'", param)
+		}
+
+		switch key {
+		case "ip":
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return attachment, fmt.Errorf("invalid IP address: %s", value)
+			} else if ip.To4() != nil {
+				attachment.Interface.IP = value
+			} else {
+				attachment.Interface.IP6 = value
+			}
+
+		case "mac":
+			if _, err := net.ParseMAC(value); err != nil {
+				return attachment, fmt.Errorf("invalid MAC address: %s", value)
+			}
+
+			attachment.Interface.MacAddress = value
+
+		case "gateway":
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return attachment, fmt.Errorf("invalid gateway address: %s", value)
+			} else if ip.To4() != nil {
+				attachment.Interface.Gateway = value
+			} else {
+				attachment.Interface.Gateway6 = value
+			}
+
+		case "ifname":
+			attachment.Interface.IfName = value
+
+		default:
+			return attachment, fmt.Errorf("unknown network parameter: %s (choice of [ip mac gateway ifname])", key)
+		}
+	}
+
+	return attachment, nil
+}
+
+// String implements fmt.Stringer and outputs the NetworkAttachment in the same
+// format which is accepted by ParseNetworkAttachment.
+func (attachment NetworkAttachment) String() string {
+	var ret strings.Builder
+
+	ret.WriteString(attachment.Driver)
+	ret.WriteString(":")
+	ret.WriteString(attachment.Network)
+
+	for _, param := range []struct{ key, value string }{
+		{"ip", attachment.Interface.IP},
+		{"ip", attachment.Interface.IP6},
+		{"mac", attachment.Interface.MacAddress},
+		{"gateway", attachment.Interface.Gateway},
+		{"gateway", attachment.Interface.Gateway6},
+		{"ifname", attachment.Interface.IfName},
+	} {
+		if param.value != "" {
+			ret.WriteString(",")
+			ret.WriteString(param.key)
+			ret.WriteString("=")
+			ret.WriteString(param.value)
+		}
+	}
+
+	return ret.String()
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func TestParseNetworkAttachment(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    NetworkAttachment
+		wantErr bool
+	}{
+		{
+			in:   "bridge:kraft0",
+			want: NetworkAttachment{Driver: "bridge", Network: "kraft0"},
+		},
+		{
+			in: "bridge:public,ip=10.0.0.2,gateway=10.0.0.1",
+			want: NetworkAttachment{
+				Driver:    "bridge",
+				Network:   "public",
+				Interface: NetworkInterfaceSpec{IP: "10.0.0.2", Gateway: "10.0.0.1"},
+			},
+		},
+		{
+			in: "bridge:private,ip=172.16.0.2,mac=02:b0:b0:00:00:01,ifname=private0",
+			want: NetworkAttachment{
+				Driver:    "bridge",
+				Network:   "private",
+				Interface: NetworkInterfaceSpec{IP: "172.16.0.2", MacAddress: "02:b0:b0:00:00:01", IfName: "private0"},
+			},
+		},
+		{
+			in: "bridge:dual,ip=172.18.0.2,ip=fd00::2,gateway=fd00::1",
+			want: NetworkAttachment{
+				Driver:    "bridge",
+				Network:   "dual",
+				Interface: NetworkInterfaceSpec{IP: "172.18.0.2", IP6: "fd00::2", Gateway6: "fd00::1"},
+			},
+		},
+		{in: "kraft0", wantErr: true},
+		{in: "bridge:", wantErr: true},
+		{in: "bridge:kraft0,ip", wantErr: true},
+		{in: "bridge:kraft0,ip=10.0.0", wantErr: true},
+		{in: "bridge:kraft0,mac=02:b0", wantErr: true},
+		{in: "bridge:kraft0,gateway=gw", wantErr: true},
+		{in: "bridge:kraft0,mtu=1500", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseNetworkAttachment(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNetworkAttachment(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseNetworkAttachment(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+
+			if got.String() != tt.in {
+				t.Errorf("ParseNetworkAttachment(%q).String() = %q", tt.in, got.String())
+			}
+		})
+	}
+}
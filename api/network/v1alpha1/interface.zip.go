@@ -30,6 +30,17 @@ type NetworkInterfaceSpec struct {
 
 	// Hardware address of a machine interface.
 	MacAddress string `json:"mac,omitempty"`
+
+	// Gateway of the machine interface, which overrides the gateway of the
+	// network.
+	Gateway string `json:"gateway,omitempty"`
+
+	// IPv6 address of a machine interface.
+	IP6 string `json:"ip6,omitempty"`
+
+	// IPv6 gateway of the machine interface, which overrides the IPv6 gateway of
+	// the network.
+	Gateway6 string `json:"gateway6,omitempty"`
 }
 
 // NetworkInterfaceTemplateSpec describes the data a network interface should
@@ -40,6 +51,10 @@ type NetworkInterfaceTemplateSpec struct {
 
 	// Spec defines the behavior of the network interface.
 	Spec NetworkInterfaceSpec `json:"spec,omitempty"`
+
+	// Status of the link of the network interface.  Machine drivers which
+	// support it connect or disconnect the link of the interface accordingly.
+	Status NetworkInterfaceStatus `json:"status,omitempty"`
 }
 
 // NetworkInterfaceState indicates the state of the network.
@@ -27,13 +27,25 @@ type NetworkSpec struct {
 	// Interface name of this network.
 	IfName string `json:"ifName,omitempty"`
 
-	// The gateway IP address of the network.
+	// The gateway IPv4 address of the network.
 	Gateway string `json:"gateway,omitempty"`
 
 	// The network mask to apply over the gateway IP address to gather the subnet
 	// range.
 	Netmask string `json:"netmask,omitempty"`
 
+	// The gateway IPv6 address of the network, which is set for IPv6-only and
+	// dual-stack networks.
+	Gateway6 string `json:"gateway6,omitempty"`
+
+	// The network mask to apply over the gateway IPv6 address to gather the
+	// subnet range.
+	Netmask6 string `json:"netmask6,omitempty"`
+
+	// The addresses of the DNS servers which machines on this network use, e.g.
+	// those of the embedded DNS responder of the network.
+	Nameservers []string `json:"nameservers,omitempty"`
+
 	// Network interfaces associated with this network.
 	Interfaces []NetworkInterfaceTemplateSpec `json:"interfaces,omitempty"`
 }
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+)
+
+// SetSubnet sets the gateway and the netmask of the address family of the
+// provided subnet in CIDR notation, e.g. 172.18.0.1/16 or fd00::1/64, where the
+// address is the gateway of the network.  If the address is the one of the
+// subnet itself, e.g. 172.18.0.0/16, the first address of the subnet is used as
+// the gateway.
+func (spec *NetworkSpec) SetSubnet(cidr string) error {
+	ip, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	if ip.Equal(subnet.IP) {
+		ip = make(net.IP, len(subnet.IP))
+		copy(ip, subnet.IP)
+		ip[len(ip)-1]++
+
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("subnet %s is too small", cidr)
+		}
+	}
+
+	if ip.To4() != nil {
+		spec.Gateway = ip.String()
+		spec.Netmask = net.IP(subnet.Mask).String()
+	} else {
+		spec.Gateway6 = ip.String()
+		spec.Netmask6 = net.IP(subnet.Mask).String()
+	}
+
+	return nil
+}
+
+// IPNet returns the gateway IPv4 address of the network together with the
+// mask of its subnet, or nil if the network has no IPv4 subnet.
+func (spec NetworkSpec) IPNet() *net.IPNet {
+	return ipNet(spec.Gateway, spec.Netmask, net.IPv4len)
+}
+
+// IPNet6 returns the gateway IPv6 address of the network together with the
+// mask of its subnet, or nil if the network has no IPv6 subnet.
+func (spec NetworkSpec) IPNet6() *net.IPNet {
+	return ipNet(spec.Gateway6, spec.Netmask6, net.IPv6len)
+}
+
+// Subnets returns the subnets of the network in CIDR notation, where the
+// address is the gateway of the network.
+func (spec NetworkSpec) Subnets() []string {
+	var ret []string
+
+	for _, ipnet := range []*net.IPNet{spec.IPNet(), spec.IPNet6()} {
+		if ipnet != nil {
+			ret = append(ret, ipnet.String())
+		}
+	}
+
+	return ret
+}
+
+// ipNet returns the provided gateway and netmask of the address family of the
+// provided length as an IPNet.
+func ipNet(gateway, netmask string, length int) *net.IPNet {
+	ip := net.ParseIP(gateway)
+	mask := net.ParseIP(netmask)
+	if ip == nil || mask == nil {
+		return nil
+	}
+
+	if length == net.IPv4len {
+		ip, mask = ip.To4(), mask.To4()
+		if ip == nil || mask == nil {
+			return nil
+		}
+	} else if ip.To4() != nil {
+		return nil
+	}
+
+	return &net.IPNet{
+		IP:   ip,
+		Mask: net.IPMask(mask),
+	}
+}
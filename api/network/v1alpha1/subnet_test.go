@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetSubnet(t *testing.T) {
+	tests := []struct {
+		in          []string
+		want        NetworkSpec
+		wantSubnets []string
+		wantErr     bool
+	}{
+		{
+			in:          []string{"172.18.0.1/16"},
+			want:        NetworkSpec{Gateway: "172.18.0.1", Netmask: "255.255.0.0"},
+			wantSubnets: []string{"172.18.0.1/16"},
+		},
+		{
+			in:          []string{"10.0.0.0/24"},
+			want:        NetworkSpec{Gateway: "10.0.0.1", Netmask: "255.255.255.0"},
+			wantSubnets: []string{"10.0.0.1/24"},
+		},
+		{
+			in:          []string{"fd00::1/64"},
+			want:        NetworkSpec{Gateway6: "fd00::1", Netmask6: "ffff:ffff:ffff:ffff::"},
+			wantSubnets: []string{"fd00::1/64"},
+		},
+		{
+			in: []string{"172.18.0.1/16", "fd00:1::/64"},
+			want: NetworkSpec{
+				Gateway:  "172.18.0.1",
+				Netmask:  "255.255.0.0",
+				Gateway6: "fd00:1::1",
+				Netmask6: "ffff:ffff:ffff:ffff::",
+			},
+			wantSubnets: []string{"172.18.0.1/16", "fd00:1::1/64"},
+		},
+		{in: []string{"172.18.0.1"}, wantErr: true},
+		{in: []string{"10.0.0.0/32"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in[0], func(t *testing.T) {
+			var got NetworkSpec
+
+			var err error
+			for _, cidr := range tt.in {
+				if err = got.SetSubnet(cidr); err != nil {
+					break
+				}
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetSubnet(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetSubnet(%v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+
+			if subnets := got.Subnets(); !reflect.DeepEqual(subnets, tt.wantSubnets) {
+				t.Errorf("SetSubnet(%v).Subnets() = %v, want %v", tt.in, subnets, tt.wantSubnets)
+			}
+		})
+	}
+}
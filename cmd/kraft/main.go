@@ -9,10 +9,18 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/docker/docker/pkg/reexec"
+
 	"kraftkit.sh/internal/cli/kraft"
 )
 
 func main() {
+	// Helper processes, e.g. the port forwarder of a machine, are spawned by
+	// re-executing this binary.
+	if reexec.Init() {
+		return
+	}
+
 	// Make args[0] just the name of the executable since it is used in logs.
 	os.Args[0] = filepath.Base(os.Args[0])
 
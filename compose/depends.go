@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// DependencyName returns the name of the service which is referenced by the
+// provided `depends_on` key.  Dependencies are declared through the name of the
+// service within the compose file whilst the services of a validated project
+// are prefixed with the name of the project.
+func (project *Project) DependencyName(key string) string {
+	return fmt.Sprint(project.Name, "-", key)
+}
+
+// OrderedServices returns the services of the project ordered such that each
+// service appears after all of the services it depends on.  The project must
+// have been validated beforehand.
+func (project *Project) OrderedServices() (types.Services, error) {
+	services := make(map[string]types.ServiceConfig, len(project.Services))
+	for _, service := range project.Services {
+		services[service.Name] = service
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(project.Services))
+	ordered := make(types.Services, 0, len(project.Services))
+
+	var visit func(service types.ServiceConfig, path []string) error
+	visit = func(service types.ServiceConfig, path []string) error {
+		switch state[service.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, service.Name), " -> "))
+		}
+
+		state[service.Name] = visiting
+
+		for _, key := range sortedKeys(service.DependsOn) {
+			dependency, ok := services[project.DependencyName(key)]
+			if !ok {
+				if !service.DependsOn[key].Required {
+					continue
+				}
+
+				return fmt.Errorf("service %s depends on undefined service %s", service.Name, key)
+			}
+
+			if err := visit(dependency, append(path, service.Name)); err != nil {
+				return err
+			}
+		}
+
+		state[service.Name] = visited
+		ordered = append(ordered, service)
+
+		return nil
+	}
+
+	for _, service := range project.Services {
+		if err := visit(service, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// WaitForDependencies blocks until each of the dependencies of the provided
+// service satisfies the condition under which it was declared:
+//
+//   - service_started: the machine of the dependency is running;
+//   - service_healthy: the machine of the dependency is running and its
+//     health check passes against its IP address on its network;
+//   - service_completed_successfully: the machine of the dependency has exited
+//     with a zero exit code.
+func (project *Project) WaitForDependencies(ctx context.Context, controller machineapi.MachineService, service types.ServiceConfig) error {
+	for _, key := range sortedKeys(service.DependsOn) {
+		dependsOn := service.DependsOn[key]
+
+		dependency, err := project.GetService(project.DependencyName(key))
+		if err != nil {
+			if !dependsOn.Required {
+				continue
+			}
+
+			return err
+		}
+
+		switch dependsOn.Condition {
+		case types.ServiceConditionHealthy:
+			probe, err := NewHealthProbe(dependency.HealthCheck)
+			if err != nil {
+				return fmt.Errorf("could not parse health check of %s: %w", dependency.Name, err)
+			} else if probe == nil {
+				return fmt.Errorf("service %s depends on %s being healthy but it has no health check", service.Name, dependency.Name)
+			}
+
+			machine, err := waitForMachine(ctx, controller, dependency.Name, machineRunning)
+			if err != nil {
+				return err
+			}
+
+			ip := machineIP(machine)
+			if ip == "" {
+				return fmt.Errorf("cannot check health of %s: machine has no network", dependency.Name)
+			}
+
+			log.G(ctx).
+				WithField("service", dependency.Name).
+				WithField("probe", probe.URL.String()).
+				Info("waiting until healthy")
+
+			if err := probe.Wait(ctx, ip); err != nil {
+				return fmt.Errorf("service %s is unhealthy: %w", dependency.Name, err)
+			}
+
+		case types.ServiceConditionCompletedSuccessfully:
+			log.G(ctx).
+				WithField("service", dependency.Name).
+				Info("waiting until completed")
+
+			machine, err := waitForMachine(ctx, controller, dependency.Name, machineExited)
+			if err != nil {
+				return err
+			} else if machine.Status.ExitCode != 0 {
+				return fmt.Errorf("service %s exited with code %d", dependency.Name, machine.Status.ExitCode)
+			}
+
+		default:
+			if _, err := waitForMachine(ctx, controller, dependency.Name, machineRunning); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// machineRunning is a condition which is satisfied once the machine is
+// running and fails if the machine has stopped.
+func machineRunning(machine *machineapi.Machine) (bool, error) {
+	switch machine.Status.State {
+	case machineapi.MachineStateRunning:
+		return true, nil
+	case machineapi.MachineStateExited,
+		machineapi.MachineStateFailed,
+		machineapi.MachineStateErrored:
+		return false, fmt.Errorf("machine %s is %s", machine.Name, machine.Status.State)
+	}
+
+	return false, nil
+}
+
+// machineExited is a condition which is satisfied once the machine has exited.
+func machineExited(machine *machineapi.Machine) (bool, error) {
+	switch machine.Status.State {
+	case machineapi.MachineStateExited:
+		return true, nil
+	case machineapi.MachineStateFailed,
+		machineapi.MachineStateErrored:
+		return false, fmt.Errorf("machine %s is %s", machine.Name, machine.Status.State)
+	}
+
+	return false, nil
+}
+
+// waitForMachine polls the machine with the provided name until the provided
+// condition is satisfied or returns an error.
+func waitForMachine(ctx context.Context, controller machineapi.MachineService, name string, condition func(*machineapi.Machine) (bool, error)) (*machineapi.Machine, error) {
+	for {
+		machines, err := controller.List(ctx, &machineapi.MachineList{})
+		if err != nil {
+			return nil, err
+		}
+
+		var machine *machineapi.Machine
+		for i := range machines.Items {
+			if machines.Items[i].Name == name {
+				machine = &machines.Items[i]
+				break
+			}
+		}
+
+		if machine == nil {
+			return nil, fmt.Errorf("could not find machine %s", name)
+		}
+
+		if ok, err := condition(machine); err != nil {
+			return nil, err
+		} else if ok {
+			return machine, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// machineIP returns the IP address of the first network interface of the
+// provided machine, if any.
+func machineIP(machine *machineapi.Machine) string {
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			if iface.Spec.IP != "" {
+				return iface.Spec.IP
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/compose-spec/compose-go/types"
+)
+
+func TestOrderedServices(t *testing.T) {
+	service := func(name string, deps ...string) types.ServiceConfig {
+		dependsOn := types.DependsOnConfig{}
+		for _, dep := range deps {
+			dependsOn[dep] = types.ServiceDependency{
+				Condition: types.ServiceConditionStarted,
+				Required:  true,
+			}
+		}
+
+		return types.ServiceConfig{
+			Name:      "test-" + name,
+			DependsOn: dependsOn,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		services types.Services
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "no dependencies",
+			services: types.Services{
+				service("app"),
+				service("db"),
+			},
+			want: []string{"test-app", "test-db"},
+		},
+		{
+			name: "chain",
+			services: types.Services{
+				service("proxy", "app"),
+				service("app", "db", "cache"),
+				service("cache"),
+				service("db"),
+			},
+			want: []string{"test-cache", "test-db", "test-app", "test-proxy"},
+		},
+		{
+			name: "undefined dependency",
+			services: types.Services{
+				service("app", "db"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "cycle",
+			services: types.Services{
+				service("app", "db"),
+				service("db", "app"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &Project{&types.Project{
+				Name:     "test",
+				Services: tt.services,
+			}}
+
+			ordered, err := project.OrderedServices()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OrderedServices() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			var got []string
+			for _, service := range ordered {
+				got = append(got, service.Name)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OrderedServices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
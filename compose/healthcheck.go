@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/types"
+)
+
+const (
+	// Defaults as defined by the compose specification.
+	DefaultHealthCheckInterval      = 30 * time.Second
+	DefaultHealthCheckTimeout       = 30 * time.Second
+	DefaultHealthCheckRetries       = 3
+	DefaultHealthCheckStartInterval = 5 * time.Second
+)
+
+// HealthProbe is a health check of a service which is performed from the host
+// against the machine of the service.  Since unikernels cannot execute
+// arbitrary commands, the test of the compose health check must contain a URL
+// which describes either a TCP or HTTP probe, e.g.:
+//
+//	healthcheck:
+//	  test: ["CMD", "curl", "-f", "http://localhost:8080/health"]
+//
+//	healthcheck:
+//	  test: tcp://localhost:5432
+//
+// The host "localhost" (or an empty host) refers to the IP address of the
+// machine on its network.
+type HealthProbe struct {
+	URL           *url.URL
+	Interval      time.Duration
+	Timeout       time.Duration
+	Retries       int
+	StartPeriod   time.Duration
+	StartInterval time.Duration
+}
+
+// NewHealthProbe parses the provided compose health check and returns the
+// equivalent probe.  A nil probe is returned if the health check is unset or
+// disabled.
+func NewHealthProbe(healthcheck *types.HealthCheckConfig) (*HealthProbe, error) {
+	if healthcheck == nil || healthcheck.Disable || len(healthcheck.Test) == 0 {
+		return nil, nil
+	}
+
+	var tokens []string
+
+	switch healthcheck.Test[0] {
+	case "NONE":
+		return nil, nil
+	case "CMD":
+		tokens = healthcheck.Test[1:]
+	case "CMD-SHELL":
+		tokens = strings.Fields(strings.Join(healthcheck.Test[1:], " "))
+	default:
+		tokens = healthcheck.Test
+	}
+
+	probe := HealthProbe{
+		Interval:      DefaultHealthCheckInterval,
+		Timeout:       DefaultHealthCheckTimeout,
+		Retries:       DefaultHealthCheckRetries,
+		StartInterval: DefaultHealthCheckStartInterval,
+	}
+
+	for _, token := range tokens {
+		u, err := url.Parse(strings.Trim(token, `"'`))
+		if err != nil {
+			continue
+		}
+
+		switch u.Scheme {
+		case "tcp", "http", "https":
+			probe.URL = u
+		}
+
+		if probe.URL != nil {
+			break
+		}
+	}
+
+	if probe.URL == nil {
+		return nil, fmt.Errorf("health check test does not contain a tcp:// or http(s):// URL: %s", strings.Join(healthcheck.Test, " "))
+	}
+
+	if healthcheck.Interval != nil {
+		probe.Interval = time.Duration(*healthcheck.Interval)
+	}
+	if healthcheck.Timeout != nil {
+		probe.Timeout = time.Duration(*healthcheck.Timeout)
+	}
+	if healthcheck.Retries != nil {
+		probe.Retries = int(*healthcheck.Retries)
+	}
+	if healthcheck.StartPeriod != nil {
+		probe.StartPeriod = time.Duration(*healthcheck.StartPeriod)
+	}
+	if healthcheck.StartInterval != nil {
+		probe.StartInterval = time.Duration(*healthcheck.StartInterval)
+	}
+
+	if probe.Retries < 1 {
+		probe.Retries = 1
+	}
+
+	return &probe, nil
+}
+
+// address returns the host and port of the probe where local hosts are
+// substituted with the provided IP address of the machine.
+func (probe *HealthProbe) address(ip string) string {
+	host := probe.URL.Hostname()
+	switch host {
+	case "", "localhost", "127.0.0.1", "0.0.0.0", "::1":
+		host = ip
+	}
+
+	port := probe.URL.Port()
+	if port == "" {
+		switch probe.URL.Scheme {
+		case "https":
+			port = "443"
+		default:
+			port = "80"
+		}
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// Test returns the URL of the probe where local hosts are omitted, such that
+// they refer to the machine, which is the format of the test of a health check
+// of a machine.
+func (probe *HealthProbe) Test() string {
+	u := *probe.URL
+	u.Host = probe.address("")
+
+	return u.String()
+}
+
+// Check performs a single probe against the machine with the provided IP
+// address.
+func (probe *HealthProbe) Check(ctx context.Context, ip string) error {
+	ctx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	switch probe.URL.Scheme {
+	case "tcp":
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", probe.address(ip))
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+
+	case "http", "https":
+		u := *probe.URL
+		u.Host = probe.address(ip)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unsupported health check scheme: %s", probe.URL.Scheme)
+}
+
+// Wait blocks until the probe succeeds against the machine with the provided
+// IP address.  Failures during the start period do not count towards the
+// number of retries, after which the machine is considered unhealthy.
+func (probe *HealthProbe) Wait(ctx context.Context, ip string) error {
+	start := time.Now()
+	failures := 0
+
+	for {
+		err := probe.Check(ctx, ip)
+		if err == nil {
+			return nil
+		}
+
+		interval := probe.Interval
+		if time.Since(start) < probe.StartPeriod {
+			interval = probe.StartInterval
+		} else {
+			failures++
+			if failures >= probe.Retries {
+				return fmt.Errorf("unhealthy after %d attempts: %w", failures, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
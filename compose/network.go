@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/compose-spec/compose-go/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/dns"
+)
+
+const (
+	// NetworkDriver is the machine network driver which is used to back compose
+	// networks.
+	NetworkDriver = "bridge"
+
+	// maxNetworkNameLen is the maximum length of the name of a bridge such that
+	// the names of the tap interfaces attached to it, i.e. <bridge>@if<N>, do
+	// not exceed the kernel's limit of 15 characters.
+	maxNetworkNameLen = 10
+)
+
+// NetworkName returns the name of the bridge network which backs the compose
+// network with the provided key.  Names which are too long to be used as a
+// network interface are replaced by a short name derived from their hash such
+// that they are stable across invocations.
+func (project *Project) NetworkName(key string) string {
+	name := key
+	netcfg, ok := project.Networks[key]
+	if ok && netcfg.Name != "" {
+		name = netcfg.Name
+	}
+
+	// External networks are referenced by their actual name.
+	if len(name) <= maxNetworkNameLen || (ok && netcfg.External.External) {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+
+	return fmt.Sprintf("kc%x", sum[:4])
+}
+
+// ServiceNetworks returns the keys of the compose networks the service is
+// attached to, ordered by descending priority.
+func ServiceNetworks(service types.ServiceConfig) []string {
+	keys := make([]string, 0, len(service.Networks))
+	for key := range service.Networks {
+		keys = append(keys, key)
+	}
+
+	priority := func(key string) int {
+		if netcfg := service.Networks[key]; netcfg != nil {
+			return netcfg.Priority
+		}
+
+		return 0
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if priority(keys[i]) != priority(keys[j]) {
+			return priority(keys[i]) > priority(keys[j])
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// networkController instantiates the controller of the driver which backs
+// compose networks.
+func networkController(ctx context.Context) (networkapi.NetworkService, error) {
+	strategy, ok := network.Strategies()[NetworkDriver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", NetworkDriver)
+	}
+
+	return strategy.NewNetworkV1alpha1(ctx)
+}
+
+// CreateNetworks creates the bridge networks of the project which do not yet
+// exist and starts the embedded DNS responder of each network, such that
+// services resolve each other by name.  External networks must already exist.
+func (project *Project) CreateNetworks(ctx context.Context) error {
+	if len(project.Networks) == 0 {
+		return nil
+	}
+
+	controller, err := networkController(ctx)
+	if err != nil {
+		return err
+	}
+
+	networks, err := controller.List(ctx, &networkapi.NetworkList{})
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*networkapi.Network, len(networks.Items))
+	for i := range networks.Items {
+		existing[networks.Items[i].Name] = &networks.Items[i]
+	}
+
+	for _, key := range sortedKeys(project.Networks) {
+		netcfg := project.Networks[key]
+		name := project.NetworkName(key)
+
+		if netcfg.Driver != "" && netcfg.Driver != NetworkDriver {
+			return fmt.Errorf("unsupported driver for network %s: %s", key, netcfg.Driver)
+		}
+
+		if network, ok := existing[name]; ok {
+			startDNS(ctx, network)
+			continue
+		} else if netcfg.External.External {
+			return fmt.Errorf("external network %s not found", name)
+		}
+
+		spec, err := subnetFromIPAM(netcfg.Ipam)
+		if err != nil {
+			return fmt.Errorf("could not parse IPAM configuration of network %s: %w", key, err)
+		} else if spec.Gateway == "" && spec.Gateway6 == "" {
+			spec.Gateway, spec.Netmask, err = allocateSubnet(networks.Items)
+			if err != nil {
+				return fmt.Errorf("could not allocate subnet for network %s: %w", key, err)
+			}
+		}
+
+		network, err := controller.Create(ctx, &networkapi.Network{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: spec,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create network %s: %w", key, err)
+		}
+
+		networks.Items = append(networks.Items, *network)
+
+		log.G(ctx).
+			WithField("network", name).
+			WithField("subnets", spec.Subnets()).
+			Debug("created")
+
+		startDNS(ctx, network)
+	}
+
+	return nil
+}
+
+// startDNS starts the embedded DNS responder of the provided network unless it
+// is already running.  Services can still reach each other by address without
+// it, e.g. whilst the daemon is not running, such that failing to start it is
+// not fatal.
+func startDNS(ctx context.Context, network *networkapi.Network) {
+	if err := dns.Start(ctx, network); err != nil {
+		log.G(ctx).
+			WithField("network", network.Name).
+			Warnf("services will not resolve each other by name: %v", err)
+	}
+}
+
+// RemoveNetworks removes the bridge networks of the project which are no longer
+// in use.  Networks are shared between projects which refer to the same
+// network, as such a network is only removed once no interfaces remain
+// attached to it, i.e. the last machine using it has been removed.  External
+// networks are never removed.
+func (project *Project) RemoveNetworks(ctx context.Context) error {
+	if len(project.Networks) == 0 {
+		return nil
+	}
+
+	controller, err := networkController(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(project.Networks) {
+		if project.Networks[key].External.External {
+			continue
+		}
+
+		name := project.NetworkName(key)
+
+		network, err := controller.Get(ctx, &networkapi.Network{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		})
+		if err != nil {
+			log.G(ctx).
+				WithField("network", name).
+				Debugf("could not get network: %v", err)
+			continue
+		}
+
+		if len(network.Spec.Interfaces) > 0 {
+			log.G(ctx).
+				WithField("network", name).
+				Info("still in use")
+			continue
+		}
+
+		if _, err := controller.Delete(ctx, network); err != nil {
+			return fmt.Errorf("could not remove network %s: %w", name, err)
+		}
+
+		if err := dns.Stop(ctx, name); err != nil {
+			return fmt.Errorf("could not stop DNS responder of network %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// subnetFromIPAM returns the specification of a network with the subnets of
+// the pools of the provided IPAM configuration, at most one per address family.
+// If no gateway is provided for a pool, the first address of its subnet is
+// used.
+func subnetFromIPAM(ipam types.IPAMConfig) (networkapi.NetworkSpec, error) {
+	var spec networkapi.NetworkSpec
+
+	for _, pool := range ipam.Config {
+		if pool == nil || pool.Subnet == "" {
+			continue
+		}
+
+		_, subnet, err := net.ParseCIDR(pool.Subnet)
+		if err != nil {
+			return spec, err
+		}
+
+		cidr := subnet.String()
+		if pool.Gateway != "" {
+			gateway := net.ParseIP(pool.Gateway)
+			if !subnet.Contains(gateway) {
+				return spec, fmt.Errorf("gateway %s is not within subnet %s", pool.Gateway, subnet.String())
+			}
+
+			ones, _ := subnet.Mask.Size()
+			cidr = fmt.Sprintf("%s/%d", gateway.String(), ones)
+		}
+
+		if (subnet.IP.To4() != nil && spec.Gateway != "") || (subnet.IP.To4() == nil && spec.Gateway6 != "") {
+			return spec, fmt.Errorf("more than one subnet of the address family of %s", subnet.String())
+		}
+
+		if err := spec.SetSubnet(cidr); err != nil {
+			return spec, err
+		}
+	}
+
+	return spec, nil
+}
+
+// allocateSubnet returns the gateway and netmask of the first /16 subnet
+// within the private 172.16.0.0/12 range which does not overlap with any of
+// the provided networks.
+func allocateSubnet(networks []networkapi.Network) (string, string, error) {
+	var used []*net.IPNet
+
+	for _, network := range networks {
+		gateway := net.ParseIP(network.Spec.Gateway)
+		netmask := net.ParseIP(network.Spec.Netmask)
+		if gateway == nil || netmask == nil || gateway.To4() == nil || netmask.To4() == nil {
+			continue
+		}
+
+		mask := net.IPMask(netmask.To4())
+		used = append(used, &net.IPNet{
+			IP:   gateway.To4().Mask(mask),
+			Mask: mask,
+		})
+	}
+
+	for i := 18; i < 32; i++ {
+		candidate := &net.IPNet{
+			IP:   net.IPv4(172, byte(i), 0, 0).To4(),
+			Mask: net.CIDRMask(16, 32),
+		}
+
+		overlaps := false
+		for _, subnet := range used {
+			if subnet.Contains(candidate.IP) || candidate.Contains(subnet.IP) {
+				overlaps = true
+				break
+			}
+		}
+
+		if !overlaps {
+			return net.IPv4(172, byte(i), 0, 1).String(), net.IP(candidate.Mask).String(), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("no free subnet available")
+}
+
+// sortedKeys returns the keys of the provided map in lexicographical order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -11,11 +11,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/compose-spec/compose-go/loader"
 	"github.com/compose-spec/compose-go/types"
 
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
 	ukarch "kraftkit.sh/unikraft/arch"
@@ -51,15 +53,42 @@ func NewProjectFromComposeFile(ctx context.Context, composeFile string) (*Projec
 		return nil, fmt.Errorf("no compose file found")
 	}
 
+	composeFile, err := filepath.Abs(composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	workdir := filepath.Dir(composeFile)
+
 	configfile := types.ConfigFile{
 		Filename: composeFile,
+		Content:  content,
+	}
+
+	// Make the host environment available for interpolation.
+	environment := make(map[string]string)
+	for _, env := range os.Environ() {
+		if k, v, ok := strings.Cut(env, "="); ok {
+			environment[k] = v
+		}
 	}
 
 	config := types.ConfigDetails{
+		WorkingDir:  workdir,
 		ConfigFiles: []types.ConfigFile{configfile},
+		Environment: environment,
 	}
 
-	project, err := loader.Load(config)
+	// Use the name of the directory containing the compose file as the project
+	// name, unless one is explicitly set within the compose file itself.
+	project, err := loader.Load(config, func(opts *loader.Options) {
+		opts.SetProjectName(loader.NormalizeProjectName(filepath.Base(workdir)), false)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -113,3 +142,34 @@ func (project *Project) Validate(ctx context.Context) error {
 
 	return nil
 }
+
+// Machines returns the list of machines which have been instantiated from the
+// services of the project.  The project must have been validated beforehand
+// such that the service names are prefixed with the name of the project.
+func (project *Project) Machines(ctx context.Context, controller machineapi.MachineService) ([]machineapi.Machine, error) {
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]struct{}, len(project.Services))
+	for _, service := range project.Services {
+		services[service.Name] = struct{}{}
+	}
+
+	var ret []machineapi.Machine
+	for _, machine := range machines.Items {
+		if _, ok := services[machine.Name]; ok {
+			ret = append(ret, machine)
+		}
+	}
+
+	return ret, nil
+}
+
+// PlatArch returns the platform and architecture which has been set for the
+// provided service in the format "<plat>/<arch>".
+func PlatArch(service types.ServiceConfig) (string, string) {
+	plat, arch, _ := strings.Cut(service.Platform, "/")
+	return plat, arch
+}
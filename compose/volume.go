@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/volume"
+)
+
+// VolumeDriver is the machine volume driver which is used to back compose
+// volumes.
+const VolumeDriver = "9pfs"
+
+// VolumeName returns the name of the volume which backs the compose volume
+// with the provided key.
+func (project *Project) VolumeName(key string) string {
+	if volcfg, ok := project.Volumes[key]; ok && volcfg.Name != "" {
+		return volcfg.Name
+	}
+
+	return key
+}
+
+// VolumePath returns the path on the host which backs the compose volume with
+// the provided key.
+func (project *Project) VolumePath(ctx context.Context, key string) string {
+	return filepath.Join(
+		config.G[config.KraftKit](ctx).RuntimeDir,
+		"volumes",
+		project.VolumeName(key),
+	)
+}
+
+// volumeController instantiates the controller of the driver which backs
+// compose volumes.
+func volumeController(ctx context.Context) (volumeapi.VolumeService, error) {
+	strategy, ok := volume.Strategies()[VolumeDriver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", VolumeDriver)
+	}
+
+	return strategy.NewVolumeV1alpha1(ctx)
+}
+
+// CreateVolumes creates the volumes of the project which do not yet exist,
+// including their backing directory on the host.  External volumes must
+// already exist.
+func (project *Project) CreateVolumes(ctx context.Context) error {
+	if len(project.Volumes) == 0 {
+		return nil
+	}
+
+	controller, err := volumeController(ctx)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := controller.List(ctx, &volumeapi.VolumeList{})
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{}, len(volumes.Items))
+	for _, vol := range volumes.Items {
+		existing[vol.Name] = struct{}{}
+	}
+
+	for _, key := range sortedKeys(project.Volumes) {
+		volcfg := project.Volumes[key]
+		path := project.VolumePath(ctx, key)
+
+		if volcfg.Driver != "" && volcfg.Driver != "local" && volcfg.Driver != VolumeDriver {
+			return fmt.Errorf("unsupported driver for volume %s: %s", key, volcfg.Driver)
+		}
+
+		if volcfg.External.External {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("external volume %s not found", project.VolumeName(key))
+			}
+
+			continue
+		}
+
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return fmt.Errorf("could not create volume %s: %w", key, err)
+		}
+
+		if _, ok := existing[project.VolumeName(key)]; ok {
+			continue
+		}
+
+		if _, err := controller.Create(ctx, &volumeapi.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: project.VolumeName(key),
+			},
+			Spec: volumeapi.VolumeSpec{
+				Driver: VolumeDriver,
+				Source: path,
+			},
+		}); err != nil {
+			return fmt.Errorf("could not create volume %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveVolumes removes the volumes of the project which are no longer in use
+// by any of the provided machines.  The data stored on the host is only
+// removed if purge is set.  External volumes are never removed.
+func (project *Project) RemoveVolumes(ctx context.Context, machines []machineapi.Machine, purge bool) error {
+	if len(project.Volumes) == 0 {
+		return nil
+	}
+
+	controller, err := volumeController(ctx)
+	if err != nil {
+		return err
+	}
+
+	inuse := make(map[string]struct{})
+	for _, machine := range machines {
+		for _, vol := range machine.Spec.Volumes {
+			inuse[vol.Spec.Source] = struct{}{}
+		}
+	}
+
+	for _, key := range sortedKeys(project.Volumes) {
+		if project.Volumes[key].External.External {
+			continue
+		}
+
+		name := project.VolumeName(key)
+		path := project.VolumePath(ctx, key)
+
+		if _, ok := inuse[path]; ok {
+			log.G(ctx).
+				WithField("volume", name).
+				Info("still in use")
+			continue
+		}
+
+		if _, err := controller.Delete(ctx, &volumeapi.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		}); err != nil {
+			return fmt.Errorf("could not remove volume %s: %w", name, err)
+		}
+
+		if purge {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("could not remove data of volume %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
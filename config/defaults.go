@@ -47,6 +47,11 @@ func NewDefaultKraftKitConfig() (*KraftKit, error) {
 		c.EventsPidFile = filepath.Join(c.RuntimeDir, "events.pid")
 	}
 
+	// ..for the socket of the daemon..
+	if len(c.DaemonSocket) == 0 {
+		c.DaemonSocket = filepath.Join(c.RuntimeDir, "kraftd.sock")
+	}
+
 	// ..and for cached source files
 	if len(c.Paths.Sources) == 0 {
 		c.Paths.Sources = filepath.Join(DataDir(), "sources")
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package exec
+
+import (
+	"syscall"
+)
+
+// cgroupAttributes extends the provided attributes such that the process is
+// created within the cgroup at the provided path.  The returned function
+// releases the cgroup once the process has been started.
+func cgroupAttributes(attr *syscall.SysProcAttr, cgroup string) (*syscall.SysProcAttr, func() error, error) {
+	fd, err := syscall.Open(cgroup, syscall.O_DIRECTORY|syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if attr == nil {
+		attr = &syscall.SysProcAttr{}
+	}
+
+	attr.UseCgroupFD = true
+	attr.CgroupFD = fd
+
+	return attr, func() error { return syscall.Close(fd) }, nil
+}
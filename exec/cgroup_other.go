@@ -0,0 +1,16 @@
+//go:build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package exec
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func cgroupAttributes(_ *syscall.SysProcAttr, _ string) (*syscall.SysProcAttr, func() error, error) {
+	return nil, nil, fmt.Errorf("cgroups are not supported on this host")
+}
@@ -18,6 +18,7 @@ type ExecOptions struct {
 	env       []string
 	callbacks []func(int)
 	detach    bool
+	cgroup    string
 }
 
 type ExecOption func(eo *ExecOptions) error
@@ -118,3 +119,13 @@ func WithDetach(detach bool) ExecOption {
 		return nil
 	}
 }
+
+// WithCgroup creates the process within the cgroup v2 at the provided in-host
+// path, such that any process which it forks, e.g. when it daemonizes, remains
+// within the cgroup.  This is only supported on Linux 5.7 or later.
+func WithCgroup(cgroup string) ExecOption {
+	return func(eo *ExecOptions) error {
+		eo.cgroup = cgroup
+		return nil
+	}
+}
@@ -114,6 +114,17 @@ func (e *Process) Start(ctx context.Context) error {
 		e.cmd.Stdin = nil
 	}
 
+	if e.opts.cgroup != "" {
+		attr, release, err := cgroupAttributes(e.cmd.SysProcAttr, e.opts.cgroup)
+		if err != nil {
+			return fmt.Errorf("could not prepare cgroup %s: %v", e.opts.cgroup, err)
+		}
+
+		defer release()
+
+		e.cmd.SysProcAttr = attr
+	}
+
 	if err := e.cmd.Start(); err != nil {
 		return fmt.Errorf("could not start process: %v", err)
 	}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/objects"
+	"kraftkit.sh/packmanager"
+)
+
+type ApplyOptions struct {
+	Files []string `long:"file" short:"f" usage:"Path to a YAML or JSON file of objects, or - to read from stdin"`
+}
+
+// Apply creates or reconciles the machines, networks and volumes of the
+// provided declarative objects.
+func Apply(ctx context.Context, opts *ApplyOptions, args ...string) error {
+	if opts == nil {
+		opts = &ApplyOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ApplyOptions{}, cobra.Command{
+		Short: "Create or update unikernels, networks and volumes from files",
+		Use:   "apply [FLAGS] -f FILE [-f FILE [...]]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Create or update the unikernels, networks and volumes which are declared
+			in one or more YAML or JSON files, each of which may contain multiple
+			documents.  Volumes and networks are created before the unikernels which
+			may use them.
+
+			A unikernel is created through the same means as 'kraft run', where its
+			spec.kernel is the package, path or kernel to run.  An existing unikernel
+			whose declaration has changed since it was last applied is replaced.
+			An existing unikernel which was not created by 'kraft apply', e.g. with
+			'kraft run', is adopted: its current declaration is taken as the one
+			which was last applied, such that it is only replaced if it differs.
+			Existing networks and volumes must match their declaration.
+
+			The declaration of an existing unikernel, network or volume can be
+			exported with 'kraft get'.`),
+		Example: heredoc.Doc(`
+			Create the objects declared in machine.yaml:
+			$ kraft apply -f machine.yaml
+
+			Where machine.yaml contains, for example:
+
+			  apiVersion: network.unikraft.io/v1alpha1
+			  kind: Network
+			  metadata:
+			    name: kraft0
+			  spec:
+			    driver: bridge
+			    gateway: 172.18.0.1
+			    netmask: 255.255.0.0
+			  ---
+			  apiVersion: machine.unikraft.io/v1alpha1
+			  kind: Machine
+			  metadata:
+			    name: nginx
+			    labels:
+			      app: nginx
+			  spec:
+			    kernel: unikraft.org/nginx:latest
+			    plat: qemu
+			    arch: x86_64
+			    ports:
+			    - hostPort: 8080
+			      machinePort: 80
+			    networks:
+			    - driver: bridge
+			      ifName: kraft0
+
+			Re-create a unikernel from its exported declaration:
+			$ kraft get machine nginx -o yaml | kraft apply -f -`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ApplyOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if len(opts.Files) == 0 {
+		return fmt.Errorf("please supply at least one file with the -f flag")
+	}
+
+	// Set use of the global package manager.
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *ApplyOptions) Run(ctx context.Context, _ []string) error {
+	objs, err := objects.ReadFiles(ctx, opts.Files...)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects.SortByDependency(objs) {
+		switch obj := obj.(type) {
+		case *volumeapi.Volume:
+			err = applyVolume(ctx, obj)
+		case *networkapi.Network:
+			err = applyNetwork(ctx, obj)
+		case *machineapi.Machine:
+			err = applyMachine(ctx, obj)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+// LastAppliedAnnotation is the annotation of a machine which contains its
+// declaration when it was last applied.  It is used to determine whether the
+// declaration has changed since.
+const LastAppliedAnnotation = "kraftkit.sh/last-applied-configuration"
+
+// applyMachine creates the provided machine if it does not exist or replaces
+// it if its declaration has changed since it was last applied.  An unchanged
+// machine is started if it is not running.  A machine which was not created by
+// applying it, e.g. via `kraft run`, is adopted by treating its current
+// declaration as the one which was last applied.
+func applyMachine(ctx context.Context, desired *machineapi.Machine) error {
+	if desired.Name == "" {
+		return fmt.Errorf("cannot apply machine without a name")
+	}
+
+	config, err := lastAppliedConfiguration(desired)
+	if err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	action := "created"
+
+	for _, machine := range machines.Items {
+		if machine.Name != desired.Name {
+			continue
+		}
+
+		applied, ok := machine.Annotations[LastAppliedAnnotation]
+		if !ok {
+			applied, err = lastAppliedConfiguration(&machine)
+			if err != nil {
+				return err
+			}
+		}
+
+		if applied == config {
+			if machine.Status.State == machineapi.MachineStateRunning {
+				log.G(ctx).WithField("machine", machine.Name).Info("unchanged")
+				return nil
+			}
+
+			if _, err := controller.Start(ctx, &machine); err != nil {
+				return fmt.Errorf("could not start machine %s: %w", machine.Name, err)
+			}
+
+			log.G(ctx).WithField("machine", machine.Name).Info("started")
+
+			return nil
+		}
+
+		if err := remove.Remove(ctx, &remove.RemoveOptions{Platform: "auto"}, machine.Name); err != nil {
+			return fmt.Errorf("could not replace machine %s: %w", machine.Name, err)
+		}
+
+		action = "configured"
+
+		break
+	}
+
+	opts, args, err := runOptions(desired)
+	if err != nil {
+		return fmt.Errorf("cannot apply machine %s: %w", desired.Name, err)
+	}
+
+	opts.Annotations = append(opts.Annotations, LastAppliedAnnotation+"="+config)
+
+	if err := run.Run(ctx, opts, args...); err != nil {
+		return fmt.Errorf("could not run machine %s: %w", desired.Name, err)
+	}
+
+	log.G(ctx).WithField("machine", desired.Name).Info(action)
+
+	return nil
+}
+
+// lastAppliedConfiguration returns the serialized declaration of the provided
+// machine, i.e. its name, labels, annotations and specification.
+func lastAppliedConfiguration(machine *machineapi.Machine) (string, error) {
+	applied := machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   machine.Name,
+			Labels: machine.Labels,
+		},
+		Spec: machine.Spec,
+	}
+
+	for key, value := range machine.Annotations {
+		if key == LastAppliedAnnotation {
+			continue
+		}
+
+		if applied.Annotations == nil {
+			applied.Annotations = map[string]string{}
+		}
+
+		applied.Annotations[key] = value
+	}
+
+	data, err := json.Marshal(&applied)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize machine %s: %w", machine.Name, err)
+	}
+
+	return string(data), nil
+}
+
+// runOptions converts the declaration of the provided machine into the
+// options and arguments of `kraft run` which instantiate it.
+func runOptions(machine *machineapi.Machine) (*run.RunOptions, []string, error) {
+	spec := machine.Spec
+
+	kernel, err := kernelReference(machine)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &run.RunOptions{
+		Architecture: spec.Architecture,
+		CrashDump:    spec.CrashDump,
+		Detach:       true,
+		DisableAccel: spec.Emulation,
+		KernelArgs:   spec.KernelArgs,
+		Name:         machine.Name,
+		Platform:     spec.Platform,
+		Rootfs:       spec.Rootfs,
+	}
+
+	for _, key := range sortedKeys(machine.Labels) {
+		opts.Labels = append(opts.Labels, key+"="+machine.Labels[key])
+	}
+
+	for _, key := range sortedKeys(machine.Annotations) {
+		if key != LastAppliedAnnotation {
+			opts.Annotations = append(opts.Annotations, key+"="+machine.Annotations[key])
+		}
+	}
+
+	if memory, ok := spec.Resources.Requests[corev1.ResourceMemory]; ok {
+		opts.Memory = memory.String()
+	}
+
+	for name, limit := range spec.Resources.Limits {
+		switch name {
+		case corev1.ResourceCPU:
+			opts.CPULimit = limit.String()
+		case corev1.ResourceMemory:
+			opts.MemoryLimit = limit.String()
+		case machineapi.ResourceIOReadBPS:
+			opts.IOReadBPS = limit.String()
+		case machineapi.ResourceIOWriteBPS:
+			opts.IOWriteBPS = limit.String()
+		case machineapi.ResourceCPUWeight:
+			opts.CPUWeight = int(limit.Value())
+		case machineapi.ResourceIOWeight:
+			opts.IOWeight = int(limit.Value())
+		default:
+			return nil, nil, fmt.Errorf("unsupported resource limit: %s", name)
+		}
+	}
+
+	for _, port := range spec.Ports {
+		opts.Ports = append(opts.Ports, portFlag(port))
+	}
+
+	for _, network := range spec.Networks {
+		attachment := networkapi.NetworkAttachment{
+			Driver:  network.Driver,
+			Network: network.IfName,
+		}
+
+		if len(network.Interfaces) > 0 {
+			attachment.Interface = network.Interfaces[0].Spec
+		}
+
+		opts.Networks = append(opts.Networks, attachment.String())
+	}
+
+	for _, volume := range spec.Volumes {
+		opts.Volumes = append(opts.Volumes, fmt.Sprintf("%s:%s", volume.Spec.Source, volume.Spec.Destination))
+	}
+
+	if spec.RestartPolicy.Name != "" {
+		opts.Restart = string(spec.RestartPolicy.Name)
+		if spec.RestartPolicy.MaximumRetryCount > 0 {
+			opts.Restart = fmt.Sprintf("%s:%d", opts.Restart, spec.RestartPolicy.MaximumRetryCount)
+		}
+	}
+
+	if check := spec.HealthCheck; check != nil {
+		opts.HealthCheck = check.String()
+		opts.HealthInterval = check.Interval.Duration
+		opts.HealthTimeout = check.Timeout.Duration
+		opts.HealthRetries = check.Retries
+		opts.HealthStartPeriod = check.StartPeriod.Duration
+	}
+
+	if spec.Debug != nil {
+		opts.GDB = int(spec.Debug.GDBPort)
+		opts.GDBHost = spec.Debug.GDBHost
+		opts.GDBWait = spec.Debug.Wait
+	}
+
+	return opts, append([]string{kernel}, spec.ApplicationArgs...), nil
+}
+
+// kernelReference returns the argument of `kraft run` from which the kernel of
+// the provided machine is instantiated.  This is either the package or path of
+// spec.kernel, which is optionally prefixed by the format of the package as
+// recorded by `kraft run`, e.g. oci://unikraft.org/nginx:latest.
+func kernelReference(machine *machineapi.Machine) (string, error) {
+	if machine.Spec.Kernel == "" {
+		return "", fmt.Errorf("no kernel specified")
+	}
+
+	format, ref, ok := strings.Cut(machine.Spec.Kernel, "://")
+	if !ok {
+		return machine.Spec.Kernel, nil
+	}
+
+	switch format {
+	case "kernel":
+		// Only the name of a kernel is recorded, whilst its path is part of the
+		// status of the machine.
+		if machine.Status.KernelPath != "" {
+			return machine.Status.KernelPath, nil
+		}
+
+		return ref, nil
+
+	case "project", "elfloader":
+		return "", fmt.Errorf("kernel %s cannot be instantiated: expected a package or the path to a kernel", machine.Spec.Kernel)
+	}
+
+	return ref, nil
+}
+
+// portFlag returns the provided port in the "docker-like" syntax which is
+// accepted by `kraft run -p`, i.e. [hostip:]hostport:machineport[/protocol].
+func portFlag(port machineapi.MachinePort) string {
+	hostPort := port.HostPort
+	if hostPort == 0 {
+		hostPort = port.MachinePort
+	}
+
+	ret := fmt.Sprintf("%d:%d", hostPort, port.MachinePort)
+	if port.HostIP != "" {
+		ret = fmt.Sprintf("%s:%s", port.HostIP, ret)
+	}
+
+	if port.Protocol != "" {
+		ret = fmt.Sprintf("%s/%s", ret, strings.ToLower(string(port.Protocol)))
+	}
+
+	return ret
+}
+
+// sortedKeys returns the keys of the provided map in ascending order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+)
+
+// defaultNetworkDriver is the driver of networks which do not declare one.
+const defaultNetworkDriver = "bridge"
+
+// applyNetwork creates the provided network if it does not exist and brings it
+// up otherwise.  An existing network must have the declared subnet as it
+// cannot be changed whilst machines may be attached to it.
+func applyNetwork(ctx context.Context, desired *networkapi.Network) error {
+	if desired.Name == "" {
+		return fmt.Errorf("cannot apply network without a name")
+	}
+
+	driver := desired.Spec.Driver
+	if driver == "" {
+		driver = defaultNetworkDriver
+	}
+
+	strategy, ok := network.Strategies()[driver]
+	if !ok {
+		return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", driver)
+	}
+
+	controller, err := strategy.NewNetworkV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	networks, err := controller.List(ctx, &networkapi.NetworkList{})
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range networks.Items {
+		if existing.Name != desired.Name {
+			continue
+		}
+
+		if (desired.Spec.Gateway != "" && desired.Spec.Gateway != existing.Spec.Gateway) ||
+			(desired.Spec.Netmask != "" && desired.Spec.Netmask != existing.Spec.Netmask) ||
+			(desired.Spec.Gateway6 != "" && desired.Spec.Gateway6 != existing.Spec.Gateway6) ||
+			(desired.Spec.Netmask6 != "" && desired.Spec.Netmask6 != existing.Spec.Netmask6) {
+			return fmt.Errorf("network %s already exists with subnets %s: remove it first to change its subnets", existing.Name, strings.Join(existing.Spec.Subnets(), ", "))
+		}
+
+		if existing.Status.State == networkapi.NetworkStateUp {
+			log.G(ctx).WithField("network", existing.Name).Info("unchanged")
+			return nil
+		}
+
+		if _, err := controller.Start(ctx, &existing); err != nil {
+			return fmt.Errorf("could not start network %s: %w", existing.Name, err)
+		}
+
+		log.G(ctx).WithField("network", existing.Name).Info("started")
+
+		return nil
+	}
+
+	if desired.Spec.IPNet() == nil && desired.Spec.IPNet6() == nil {
+		return fmt.Errorf("cannot create network %s: gateway and netmask of an IPv4 or IPv6 subnet are required", desired.Name)
+	}
+
+	if _, err := controller.Create(ctx, &networkapi.Network{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        desired.Name,
+			Labels:      desired.Labels,
+			Annotations: desired.Annotations,
+		},
+		Spec: networkapi.NetworkSpec{
+			Driver:   driver,
+			Gateway:  desired.Spec.Gateway,
+			Netmask:  desired.Spec.Netmask,
+			Gateway6: desired.Spec.Gateway6,
+			Netmask6: desired.Spec.Netmask6,
+		},
+	}); err != nil {
+		return fmt.Errorf("could not create network %s: %w", desired.Name, err)
+	}
+
+	log.G(ctx).WithField("network", desired.Name).Info("created")
+
+	return nil
+}
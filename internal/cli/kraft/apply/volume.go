@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package apply
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/volume"
+)
+
+// defaultVolumeDriver is the driver of volumes which do not declare one.
+const defaultVolumeDriver = "9pfs"
+
+// applyVolume creates the provided volume, including the directory on the host
+// which backs it, if it does not exist.  An existing volume must have the
+// declared source.
+func applyVolume(ctx context.Context, desired *volumeapi.Volume) error {
+	if desired.Name == "" {
+		return fmt.Errorf("cannot apply volume without a name")
+	}
+
+	if desired.Spec.Source == "" {
+		return fmt.Errorf("cannot apply volume %s without a source", desired.Name)
+	}
+
+	driver := desired.Spec.Driver
+	if driver == "" {
+		driver = defaultVolumeDriver
+	}
+
+	strategy, ok := volume.Strategies()[driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := controller.List(ctx, &volumeapi.VolumeList{})
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range volumes.Items {
+		if existing.Name != desired.Name {
+			continue
+		}
+
+		if existing.Spec.Source != desired.Spec.Source {
+			return fmt.Errorf("volume %s already exists with source %s: remove it first to change its source", existing.Name, existing.Spec.Source)
+		}
+
+		log.G(ctx).WithField("volume", existing.Name).Info("unchanged")
+
+		return nil
+	}
+
+	if err := os.MkdirAll(desired.Spec.Source, 0o755); err != nil {
+		return fmt.Errorf("could not create volume %s: %w", desired.Name, err)
+	}
+
+	if _, err := controller.Create(ctx, &volumeapi.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        desired.Name,
+			Labels:      desired.Labels,
+			Annotations: desired.Annotations,
+		},
+		Spec: volumeapi.VolumeSpec{
+			Driver:      driver,
+			Source:      desired.Spec.Source,
+			Destination: desired.Spec.Destination,
+			Mode:        desired.Spec.Mode,
+			ReadOnly:    desired.Spec.ReadOnly,
+		},
+	}); err != nil {
+		return fmt.Errorf("could not create volume %s: %w", desired.Name, err)
+	}
+
+	log.G(ctx).WithField("volume", desired.Name).Info("created")
+
+	return nil
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type AttachOptions struct {
+	DetachKeys string `long:"detach-keys" usage:"Override the key sequence for detaching from the unikernel" default:"ctrl-p,ctrl-q"`
+	platform   string
+}
+
+// Attach the terminal to the console of a local Unikraft virtual machine.
+func Attach(ctx context.Context, opts *AttachOptions, args ...string) error {
+	if opts == nil {
+		opts = &AttachOptions{
+			DetachKeys: DefaultDetachKeys,
+		}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&AttachOptions{}, cobra.Command{
+		Short: "Attach to the console of a running unikernel",
+		Use:   "attach [FLAGS] MACHINE",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Attach the input and output of the terminal to the serial console of a
+			running unikernel.  Detach from the unikernel without stopping it by
+			pressing the detach key sequence, by default CTRL-p CTRL-q.`),
+		Example: heredoc.Doc(`
+			Attach to the console of the unikernel named my-machine:
+			$ kraft attach my-machine
+
+			Attach to the console and detach with CTRL-x:
+			$ kraft attach --detach-keys ctrl-x my-machine`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.  Set to 'auto' to detect the guest's platform and 'host' to use the host platform.",
+	)
+
+	return cmd
+}
+
+func (opts *AttachOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.platform = cmd.Flag("plat").Value.String()
+	return nil
+}
+
+func (opts *AttachOptions) Run(ctx context.Context, args []string) error {
+	keys, err := parseDetachKeys(opts.DetachKeys)
+	if err != nil {
+		return err
+	}
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.platform == "" || opts.platform == "auto" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.platform == "host" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var machine *machineapi.Machine
+
+	for _, candidate := range machines.Items {
+		if args[0] == candidate.Name || string(candidate.UID) == args[0] {
+			machine = &candidate
+			break
+		}
+	}
+
+	if machine == nil {
+		return fmt.Errorf("could not find instance %s", args[0])
+	}
+
+	switch machine.Status.State {
+	case machineapi.MachineStateRunning, machineapi.MachineStatePaused:
+	default:
+		return fmt.Errorf("cannot attach to %s: instance is %s", machine.Name, machine.Status.State)
+	}
+
+	if len(machine.Status.ConsoleSocket) == 0 {
+		return fmt.Errorf("cannot attach to %s: instance does not have a console", machine.Name)
+	}
+
+	conn, err := net.Dial("unix", machine.Status.ConsoleSocket)
+	if err != nil {
+		return fmt.Errorf("could not connect to console of %s: %w", machine.Name, err)
+	}
+
+	defer conn.Close()
+
+	streams := iostreams.G(ctx)
+
+	fmt.Fprintf(streams.ErrOut, "attached to %s, press %s to detach\n", machine.Name, opts.DetachKeys)
+
+	// Forward each key press, including control characters, to the console
+	// rather than interpreting it locally.
+	if streams.IsStdinTTY() {
+		state, err := term.MakeRaw(int(streams.In.Fd()))
+		if err != nil {
+			return fmt.Errorf("could not set terminal to raw mode: %w", err)
+		}
+
+		defer func() {
+			_ = term.Restore(int(streams.In.Fd()), state)
+		}()
+	}
+
+	output := make(chan error, 1)
+	input := make(chan error, 1)
+
+	go func() {
+		_, err := io.Copy(streams.Out, conn)
+		output <- err
+	}()
+
+	go func() {
+		_, err := io.Copy(conn, &detachReader{
+			r:    streams.In,
+			keys: keys,
+		})
+		input <- err
+	}()
+
+	for {
+		select {
+		case err := <-output:
+			// The console is closed once the instance exits.
+			if err != nil && !errors.Is(err, net.ErrClosed) {
+				return err
+			}
+
+			return nil
+
+		case err := <-input:
+			if errors.Is(err, errDetached) {
+				fmt.Fprintf(streams.ErrOut, "\r\ndetached from %s\r\n", machine.Name)
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			// The end of the input has been reached, continue to show the output of
+			// the instance.
+			input = nil
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
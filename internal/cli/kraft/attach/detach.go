@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package attach
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultDetachKeys is the key sequence which detaches from the console of a
+// machine by default.
+const DefaultDetachKeys = "ctrl-p,ctrl-q"
+
+// errDetached is returned once the detach key sequence has been read.
+var errDetached = errors.New("detached")
+
+// parseDetachKeys parses a comma-separated sequence of keys, each of which is
+// either a single character or a control character in the form of ctrl-VALUE,
+// where VALUE is a letter or one of @, [, \, ], ^ and _.
+func parseDetachKeys(s string) ([]byte, error) {
+	var keys []byte
+
+	for _, key := range strings.Split(s, ",") {
+		if len(key) == 1 {
+			keys = append(keys, key[0])
+			continue
+		}
+
+		value, ok := strings.CutPrefix(strings.ToLower(key), "ctrl-")
+		if !ok || len(value) != 1 {
+			return nil, fmt.Errorf("invalid detach key: %s", key)
+		}
+
+		c := strings.ToUpper(value)[0]
+		if c < '@' || c > '_' {
+			return nil, fmt.Errorf("invalid detach key: %s", key)
+		}
+
+		keys = append(keys, c-'@')
+	}
+
+	return keys, nil
+}
+
+// detachReader forwards input until the detach key sequence has been read.
+// Input which matches the beginning of the sequence is held back until it
+// either completes the sequence or diverges from it.
+type detachReader struct {
+	r       io.Reader
+	keys    []byte
+	matched int
+	pending []byte
+	err     error
+}
+
+// Read implements io.Reader
+func (d *detachReader) Read(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+
+	for len(d.pending) == 0 && d.err == nil {
+		n, err := d.r.Read(buf)
+
+		for _, b := range buf[:n] {
+			if b != d.keys[d.matched] {
+				d.pending = append(d.pending, d.keys[:d.matched]...)
+				d.matched = 0
+			}
+
+			if b != d.keys[d.matched] {
+				d.pending = append(d.pending, b)
+				continue
+			}
+
+			d.matched++
+			if d.matched == len(d.keys) {
+				d.err = errDetached
+				break
+			}
+		}
+
+		if d.err == nil && err != nil {
+			d.pending = append(d.pending, d.keys[:d.matched]...)
+			d.matched = 0
+			d.err = err
+		}
+	}
+
+	if len(d.pending) == 0 {
+		return 0, d.err
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+
+	return n, nil
+}
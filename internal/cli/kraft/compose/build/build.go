@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package build
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/build"
+	"kraftkit.sh/internal/cli/kraft/pkg"
+	"kraftkit.sh/log"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
+)
+
+type BuildOptions struct {
+	Composefile string `noattribute:"true"`
+}
+
+// Build the services of a compose project which provide a build context.
+func Build(ctx context.Context, opts *BuildOptions, args ...string) error {
+	if opts == nil {
+		opts = &BuildOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&BuildOptions{}, cobra.Command{
+		Short: "Build the services of a compose project",
+		Use:   "build [FLAGS] [SERVICE [SERVICE [...]]]",
+		Long: heredoc.Doc(`
+			Build and package the services of a compose project which provide a
+			build context containing a Kraftfile.  The resulting OCI package is
+			tagged with the image name of the service such that it can be run
+			locally without pushing it to a registry.`),
+		Example: heredoc.Doc(`
+			# Build all services of the compose project in the current working directory
+			$ kraft compose build
+
+			# Build a specific service
+			$ kraft compose build app`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *BuildOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+
+	// Set use of the global package manager.
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *BuildOptions) Run(ctx context.Context, args []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	for _, service := range project.Services {
+		if service.Build == nil {
+			continue
+		}
+
+		if len(args) > 0 {
+			selected := false
+			for _, arg := range args {
+				if service.Name == arg || service.Name == fmt.Sprint(project.Name, "-", arg) {
+					selected = true
+					break
+				}
+			}
+
+			if !selected {
+				continue
+			}
+		}
+
+		if err := BuildService(ctx, service); err != nil {
+			return fmt.Errorf("could not build service %s: %w", service.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// BuildService builds the unikernel from the build context of the provided
+// service through the same pipeline as `kraft build` and subsequently packages
+// it as an OCI image through the same pipeline as `kraft pkg`.  The resulting
+// package is tagged with the image name of the service.
+func BuildService(ctx context.Context, service types.ServiceConfig) error {
+	if service.Build == nil {
+		return fmt.Errorf("service %s has no build context", service.Name)
+	}
+
+	plat, arch := compose.PlatArch(service)
+
+	log.G(ctx).
+		WithField("service", service.Name).
+		WithField("context", service.Build.Context).
+		Info("building")
+
+	if err := build.Build(ctx, &build.BuildOptions{
+		Architecture: arch,
+		Platform:     plat,
+		Workdir:      service.Build.Context,
+		NoCache:      service.Build.NoCache,
+		ForcePull:    service.Build.Pull,
+		Target:       service.Build.Target,
+	}, service.Build.Context); err != nil {
+		return err
+	}
+
+	popts := &pkg.PkgOptions{
+		Format:   "oci",
+		Name:     service.Image,
+		Strategy: packmanager.StrategyOverwrite,
+		Workdir:  service.Build.Context,
+	}
+
+	// Targets and the architecture and platform filters are mutually exclusive.
+	if service.Build.Target != "" {
+		popts.Target = service.Build.Target
+	} else {
+		popts.Architecture = arch
+		popts.Platform = plat
+	}
+
+	if _, err := pkg.Pkg(ctx, popts, service.Build.Context); err != nil {
+		return fmt.Errorf("could not package: %w", err)
+	}
+
+	return nil
+}
+
+// ImageExists checks whether the image of the provided service is available in
+// the local catalog of packages.
+func ImageExists(ctx context.Context, service types.ServiceConfig) (bool, error) {
+	plat, arch := compose.PlatArch(service)
+
+	packs, err := packmanager.G(ctx).Catalog(ctx,
+		packmanager.WithTypes(unikraft.ComponentTypeApp),
+		packmanager.WithName(service.Image),
+		packmanager.WithArchitecture(arch),
+		packmanager.WithPlatform(plat),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return len(packs) > 0, nil
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package compose
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/compose/build"
+	"kraftkit.sh/internal/cli/kraft/compose/down"
+	"kraftkit.sh/internal/cli/kraft/compose/logs"
+	"kraftkit.sh/internal/cli/kraft/compose/ps"
+	"kraftkit.sh/internal/cli/kraft/compose/start"
+	"kraftkit.sh/internal/cli/kraft/compose/stop"
+	"kraftkit.sh/internal/cli/kraft/compose/up"
+)
+
+type ComposeOptions struct {
+	Composefile string `local:"false" long:"file" short:"f" usage:"Set the Compose file."`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ComposeOptions{}, cobra.Command{
+		Short: "Build and run compose projects with Unikraft",
+		Use:   "compose SUBCOMMAND",
+		Long: heredoc.Doc(`
+			Build and run compose projects with Unikraft.
+
+			Each service of the compose project is instantiated as a unikernel
+			virtual machine named after the project and the service, i.e.
+			<project>-<service>.
+		`),
+		Example: heredoc.Doc(`
+			# Start the project in the current working directory
+			$ kraft compose up
+
+			# Build the services of the project which provide a build context
+			$ kraft compose build
+
+			# Start the project defined in a specific compose file
+			$ kraft compose -f path/to/compose.yaml up
+
+			# List the machines of the project
+			$ kraft compose ps
+
+			# Stop and remove the machines of the project
+			$ kraft compose down
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(build.NewCmd())
+	cmd.AddCommand(down.NewCmd())
+	cmd.AddCommand(logs.NewCmd())
+	cmd.AddCommand(ps.NewCmd())
+	cmd.AddCommand(start.NewCmd())
+	cmd.AddCommand(stop.NewCmd())
+	cmd.AddCommand(up.NewCmd())
+
+	return cmd
+}
+
+func (opts *ComposeOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package down
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type DownOptions struct {
+	Composefile string `noattribute:"true"`
+	Volumes     bool   `long:"volumes" short:"v" usage:"Remove the data of the project's volumes"`
+}
+
+// Down stops and removes the machines of a compose project.
+func Down(ctx context.Context, opts *DownOptions, args ...string) error {
+	if opts == nil {
+		opts = &DownOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DownOptions{}, cobra.Command{
+		Short: "Stop and remove a compose project",
+		Use:   "down [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Stop and remove the machines of each service of a compose project.
+
+			Networks and volumes of the project are removed once they are no longer
+			in use by any machine, such that those shared with other projects
+			survive until the last project using them is removed.  The data of
+			volumes is only removed when the --volumes flag is set.`),
+		Example: heredoc.Doc(`
+			# Stop and remove the compose project in the current working directory
+			$ kraft compose down
+
+			# Additionally remove the data of the project's volumes
+			$ kraft compose down --volumes`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DownOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+	return nil
+}
+
+func (opts *DownOptions) Run(ctx context.Context, _ []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := project.Machines(ctx, controller)
+	if err != nil {
+		return err
+	}
+
+	for _, machine := range machines {
+		if err := remove.Remove(ctx, &remove.RemoveOptions{Platform: "auto"}, machine.Name); err != nil {
+			log.G(ctx).Errorf("could not remove machine %s: %v", machine.Name, err)
+		}
+	}
+
+	if err := project.RemoveNetworks(ctx); err != nil {
+		return err
+	}
+
+	// Volumes may still be used by machines of other projects.
+	remaining, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	return project.RemoveVolumes(ctx, remaining.Items, opts.Volumes)
+}
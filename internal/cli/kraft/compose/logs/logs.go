@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type LogsOptions struct {
+	Composefile string `noattribute:"true"`
+	Follow      bool   `long:"follow" usage:"Follow log output"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&LogsOptions{}, cobra.Command{
+		Short: "Fetch the logs of a compose project",
+		Use:   "logs [FLAGS] [SERVICE [SERVICE [...]]]",
+		Long: heredoc.Doc(`
+			Fetch the logs of the machines of a compose project.  Each line is
+			prefixed with the name of the machine it originates from.`),
+		Example: heredoc.Doc(`
+			# Fetch the logs of all services of the compose project
+			$ kraft compose logs
+
+			# Follow the logs of a specific service
+			$ kraft compose logs --follow app`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *LogsOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+	return nil
+}
+
+func (opts *LogsOptions) Run(ctx context.Context, args []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := project.Machines(ctx, controller)
+	if err != nil {
+		return err
+	}
+
+	// Only select the requested services, if any.
+	if len(args) > 0 {
+		var selected []machineapi.Machine
+		for _, machine := range machines {
+			for _, arg := range args {
+				if machine.Name == arg || machine.Name == fmt.Sprint(project.Name, "-", arg) {
+					selected = append(selected, machine)
+					break
+				}
+			}
+		}
+
+		machines = selected
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for _, machine := range machines {
+		machine := machine
+		prefix := fmt.Sprintf("%s | ", machine.Name)
+
+		if !opts.Follow || machine.Status.State != machineapi.MachineStateRunning {
+			fd, err := os.Open(machine.Status.LogFile)
+			if err != nil {
+				log.G(ctx).Errorf("could not open logs of %s: %v", machine.Name, err)
+				continue
+			}
+
+			scanner := bufio.NewScanner(fd)
+			for scanner.Scan() {
+				fmt.Fprintf(iostreams.G(ctx).Out, "%s%s\n", prefix, scanner.Text())
+			}
+
+			fd.Close()
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			logs, errs, err := controller.Logs(ctx, &machine)
+			if err != nil {
+				log.G(ctx).Errorf("could not listen for logs of %s: %v", machine.Name, err)
+				return
+			}
+
+			for {
+				// Wait on either channel
+				select {
+				case line := <-logs:
+					mu.Lock()
+					fmt.Fprintf(iostreams.G(ctx).Out, "%s%s\n", prefix, strings.TrimRight(line, "\n"))
+					mu.Unlock()
+
+				case err := <-errs:
+					log.G(ctx).Errorf("received log error from %s: %v", machine.Name, err)
+					return
+
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package ps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type PsOptions struct {
+	Composefile string `noattribute:"true"`
+	Output      string `long:"output" short:"o" usage:"Set output format" default:"table"`
+}
+
+const (
+	MemoryMiB = 1024 * 1024
+)
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&PsOptions{}, cobra.Command{
+		Short: "List the machines of a compose project",
+		Use:   "ps [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			List the machines of each service of a compose project.`),
+		Example: heredoc.Doc(`
+			# List the machines of the compose project in the current working directory
+			$ kraft compose ps`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *PsOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+	return nil
+}
+
+func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := project.Machines(ctx, controller)
+	if err != nil {
+		return err
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("NAME", cs.Bold)
+	table.AddField("KERNEL", cs.Bold)
+	table.AddField("ARGS", cs.Bold)
+	table.AddField("CREATED", cs.Bold)
+	table.AddField("STATUS", cs.Bold)
+	table.AddField("MEM", cs.Bold)
+	table.AddField("PORTS", cs.Bold)
+	table.AddField("PLAT", cs.Bold)
+	table.EndRow()
+
+	for _, machine := range machines {
+		table.AddField(machine.Name, nil)
+		table.AddField(machine.Spec.Kernel, nil)
+		table.AddField(strings.Join(machine.Spec.ApplicationArgs, " "), nil)
+		table.AddField(humanize.Time(machine.ObjectMeta.CreationTimestamp.Time), nil)
+		table.AddField(machine.Status.State.String(), nil)
+		table.AddField(fmt.Sprintf("%dMiB", machine.Spec.Resources.Requests.Memory().Value()/MemoryMiB), nil)
+		table.AddField(machine.Spec.Ports.String(), nil)
+		table.AddField(fmt.Sprintf("%s/%s", machine.Spec.Platform, machine.Spec.Architecture), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package start
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type StartOptions struct {
+	Composefile string `noattribute:"true"`
+}
+
+// Start the machines of a compose project.
+func Start(ctx context.Context, opts *StartOptions, args ...string) error {
+	if opts == nil {
+		opts = &StartOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&StartOptions{}, cobra.Command{
+		Short: "Start a compose project",
+		Use:   "start [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Start the existing machines of each service of a compose project in
+			dependency order.`),
+		Example: heredoc.Doc(`
+			# Start the compose project in the current working directory
+			$ kraft compose start`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *StartOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+	return nil
+}
+
+func (opts *StartOptions) Run(ctx context.Context, _ []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := project.Machines(ctx, controller)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]machineapi.Machine, len(machines))
+	for _, machine := range machines {
+		existing[machine.Name] = machine
+	}
+
+	services, err := project.OrderedServices()
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		machine, ok := existing[service.Name]
+		if !ok || machine.Status.State == machineapi.MachineStateRunning {
+			continue
+		}
+
+		if err := project.WaitForDependencies(ctx, controller, service); err != nil {
+			return fmt.Errorf("could not start service %s: %w", service.Name, err)
+		}
+
+		if _, err := controller.Start(ctx, &machine); err != nil {
+			log.G(ctx).Errorf("could not start machine %s: %v", machine.Name, err)
+		} else {
+			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package stop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type StopOptions struct {
+	Composefile string `noattribute:"true"`
+}
+
+// Stop the machines of a compose project.
+func Stop(ctx context.Context, opts *StopOptions, args ...string) error {
+	if opts == nil {
+		opts = &StopOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&StopOptions{}, cobra.Command{
+		Short: "Stop a compose project",
+		Use:   "stop [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Stop the running machines of each service of a compose project.`),
+		Example: heredoc.Doc(`
+			# Stop the compose project in the current working directory
+			$ kraft compose stop`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *StopOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+	return nil
+}
+
+func (opts *StopOptions) Run(ctx context.Context, _ []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := project.Machines(ctx, controller)
+	if err != nil {
+		return err
+	}
+
+	for _, machine := range machines {
+		if machine.Status.State != machineapi.MachineStateRunning &&
+			machine.Status.State != machineapi.MachineStatePaused {
+			continue
+		}
+
+		if _, err := controller.Stop(ctx, &machine); err != nil {
+			log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
+		} else {
+			fmt.Fprintln(iostreams.G(ctx).Out, machine.Name)
+		}
+	}
+
+	return nil
+}
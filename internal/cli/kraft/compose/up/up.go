@@ -0,0 +1,289 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package up
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/compose"
+	"kraftkit.sh/internal/cli/kraft/compose/build"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network/dns"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/packmanager"
+)
+
+type UpOptions struct {
+	Build       bool   `long:"build" usage:"Build the services before starting them"`
+	Composefile string `noattribute:"true"`
+}
+
+// Up instantiates and starts the services of a compose project.
+func Up(ctx context.Context, opts *UpOptions, args ...string) error {
+	if opts == nil {
+		opts = &UpOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&UpOptions{}, cobra.Command{
+		Short: "Run a compose project",
+		Use:   "up [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Create and start the machines of each service of a compose project.
+			Machines which already exist are started if they are not running.
+
+			Services are started in dependency order.  A service which depends on
+			another service with the condition "service_healthy" is only started
+			once the health check of the dependency passes against the IP address of
+			its machine.  Health checks must contain a tcp:// or http(s):// URL,
+			where "localhost" refers to the machine itself, e.g.:
+
+			  healthcheck:
+			    test: ["CMD", "curl", "-f", "http://localhost:8080/health"]
+
+			Services which provide a build context are built and packaged if their
+			image is not available locally or if the --build flag is set.`),
+		Example: heredoc.Doc(`
+			# Run the compose project in the current working directory
+			$ kraft compose up
+
+			# Rebuild the services before running the compose project
+			$ kraft compose up --build`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "compose",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *UpOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Composefile = cmd.Flag("file").Value.String()
+
+	// Set use of the global package manager.
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *UpOptions) Run(ctx context.Context, _ []string) error {
+	project, err := compose.NewProjectFromComposeFile(ctx, opts.Composefile)
+	if err != nil {
+		return err
+	}
+
+	if err := project.Validate(ctx); err != nil {
+		return err
+	}
+
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := project.Machines(ctx, controller)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]machineapi.Machine, len(machines))
+	for _, machine := range machines {
+		existing[machine.Name] = machine
+	}
+
+	for _, service := range project.Services {
+		if service.Build == nil {
+			continue
+		}
+
+		if _, ok := existing[service.Name]; ok && !opts.Build {
+			continue
+		}
+
+		if !opts.Build {
+			exists, err := build.ImageExists(ctx, service)
+			if err != nil {
+				return err
+			} else if exists {
+				continue
+			}
+		}
+
+		if err := build.BuildService(ctx, service); err != nil {
+			return fmt.Errorf("could not build service %s: %w", service.Name, err)
+		}
+	}
+
+	if err := project.CreateNetworks(ctx); err != nil {
+		return err
+	}
+
+	if err := project.CreateVolumes(ctx); err != nil {
+		return err
+	}
+
+	services, err := project.OrderedServices()
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		if err := project.WaitForDependencies(ctx, controller, service); err != nil {
+			return fmt.Errorf("could not start service %s: %w", service.Name, err)
+		}
+
+		if machine, ok := existing[service.Name]; ok {
+			if machine.Status.State == machineapi.MachineStateRunning {
+				log.G(ctx).
+					WithField("service", service.Name).
+					Info("already running")
+				continue
+			}
+
+			if _, err := controller.Start(ctx, &machine); err != nil {
+				return fmt.Errorf("could not start service %s: %w", service.Name, err)
+			}
+
+			continue
+		}
+
+		if err := runService(ctx, project, service); err != nil {
+			return fmt.Errorf("could not run service %s: %w", service.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// runService instantiates a new machine for the provided service through the
+// same machinery which is used by `kraft run`.
+func runService(ctx context.Context, project *compose.Project, service types.ServiceConfig) error {
+	plat, arch := compose.PlatArch(service)
+
+	opts := &run.RunOptions{
+		Architecture: arch,
+		Detach:       true,
+		Name:         service.Name,
+		Platform:     plat,
+		Restart:      service.Restart,
+	}
+
+	probe, err := compose.NewHealthProbe(service.HealthCheck)
+	if err != nil {
+		return fmt.Errorf("could not parse health check: %w", err)
+	} else if probe != nil {
+		opts.HealthCheck = probe.Test()
+		opts.HealthInterval = probe.Interval
+		opts.HealthTimeout = probe.Timeout
+		opts.HealthRetries = probe.Retries
+		opts.HealthStartPeriod = probe.StartPeriod
+	}
+
+	if service.MemLimit > 0 {
+		opts.Memory = fmt.Sprintf("%d", service.MemLimit)
+	}
+
+	if service.CPUS > 0 {
+		opts.CPULimit = fmt.Sprintf("%dm", int64(service.CPUS*1000))
+	}
+
+	if service.BlkioConfig != nil && service.BlkioConfig.Weight > 0 {
+		opts.IOWeight = int(service.BlkioConfig.Weight)
+	}
+
+	for _, port := range service.Ports {
+		opts.Ports = append(opts.Ports, servicePort(port))
+	}
+
+	// The machine is resolved by the name of its service and its aliases through
+	// the embedded DNS responders of its networks.
+	aliases := []string{strings.TrimPrefix(service.Name, project.Name+"-")}
+
+	// The network with the highest priority is attached first, such that its
+	// gateway is the default gateway of the unikernel.
+	for _, name := range compose.ServiceNetworks(service) {
+		attachment := networkapi.NetworkAttachment{
+			Driver:  compose.NetworkDriver,
+			Network: project.NetworkName(name),
+		}
+
+		if netcfg := service.Networks[name]; netcfg != nil {
+			attachment.Interface.IP = netcfg.Ipv4Address
+			aliases = append(aliases, netcfg.Aliases...)
+		}
+
+		opts.Networks = append(opts.Networks, attachment.String())
+	}
+
+	opts.Annotations = append(opts.Annotations, dns.AliasesAnnotation+"="+strings.Join(aliases, ","))
+
+	for _, volume := range service.Volumes {
+		switch volume.Type {
+		case types.VolumeTypeBind:
+			opts.Volumes = append(opts.Volumes, fmt.Sprintf("%s:%s", volume.Source, volume.Target))
+
+		case types.VolumeTypeVolume:
+			if volume.Source == "" {
+				log.G(ctx).
+					WithField("service", service.Name).
+					WithField("target", volume.Target).
+					Warn("skipping unsupported anonymous volume")
+				continue
+			}
+
+			opts.Volumes = append(opts.Volumes, fmt.Sprintf("%s:%s", project.VolumePath(ctx, volume.Source), volume.Target))
+
+		default:
+			log.G(ctx).
+				WithField("service", service.Name).
+				WithField("type", volume.Type).
+				Warn("skipping unsupported volume")
+		}
+	}
+
+	return run.Run(ctx, opts, append([]string{service.Image}, service.Command...)...)
+}
+
+// servicePort converts a compose port into the docker-like syntax which is
+// accepted by `kraft run -p`, i.e. [hostip:]hostport:machineport[/protocol].
+func servicePort(port types.ServicePortConfig) string {
+	published := port.Published
+	if published == "" {
+		published = fmt.Sprintf("%d", port.Target)
+	}
+
+	ret := fmt.Sprintf("%s:%d", published, port.Target)
+	if port.HostIP != "" {
+		ret = fmt.Sprintf("%s:%s", port.HostIP, ret)
+	}
+
+	if port.Protocol != "" {
+		ret = fmt.Sprintf("%s/%s", ret, port.Protocol)
+	}
+
+	return ret
+}
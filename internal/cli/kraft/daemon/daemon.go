@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/dockerapi"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/daemon"
+	"kraftkit.sh/machine/daemon/server"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/packmanager"
+)
+
+type DaemonOptions struct {
+	DockerSocket string `long:"docker-socket" usage:"Also serve a subset of the Docker Engine API on the provided unix socket"`
+}
+
+// Daemon serves the machine, network and volume services of the host over the
+// configured unix socket until the context is cancelled.
+func Daemon(ctx context.Context, opts *DaemonOptions, args ...string) error {
+	if opts == nil {
+		opts = &DaemonOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DaemonOptions{}, cobra.Command{
+		Short:   "Serve unikernels, networks and volumes from a long-running daemon",
+		Use:     "daemon [FLAGS]",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"kraftd"},
+		Long: heredoc.Doc(`
+			Serve the machine, network and volume services of the host over a unix
+			socket.  Whilst the daemon is running, all other kraft commands access
+			unikernels, networks and volumes through it instead of driving the
+			store and the virtual machine monitors directly.
+
+			The daemon monitors every unikernel: transitions of their health are
+			logged and unikernels which exit are restarted as their restart
+			policy demands.
+
+			The path to the socket is set with --daemon-socket or the
+			KRAFTKIT_DAEMON_SOCKET environment variable and defaults to kraftd.sock
+			within the runtime directory.
+
+			With --docker-socket, the daemon additionally serves a subset of the
+			Docker Engine API, such that the docker CLI can create, start, stop,
+			list, inspect, wait for and remove unikernels, read their logs, and
+			list and pull their images.`),
+		Example: heredoc.Doc(`
+			Start the daemon in the foreground:
+			$ kraft daemon
+
+			Start the daemon and manage unikernels with the docker CLI:
+			$ kraft daemon --docker-socket /tmp/kraft-docker.sock
+			$ DOCKER_HOST=unix:///tmp/kraft-docker.sock docker run -d -p 8080:80 unikraft.org/nginx:latest`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DaemonOptions) Pre(cmd *cobra.Command, _ []string) error {
+	// Images are pulled through the global package manager.
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *DaemonOptions) Run(ctx context.Context, _ []string) error {
+	socket := daemon.Socket(ctx)
+	if socket == "" {
+		return fmt.Errorf("no daemon socket configured")
+	}
+
+	if daemon.Running(ctx) {
+		return fmt.Errorf("daemon is already running on %s", socket)
+	}
+
+	srv, err := server.New(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := mplatform.NewMachineV1alpha1Store(ctx)
+	if err != nil {
+		return fmt.Errorf("could not access the machine store: %w", err)
+	}
+
+	var dockerSrv *dockerapi.Server
+	if opts.DockerSocket != "" {
+		dockerSrv, err = dockerapi.New(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	listener, err := listen(ctx, socket)
+	if err != nil {
+		return err
+	}
+
+	defer closeListener(ctx, listener, socket)
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Stop serving either API as soon as the other fails.
+	group, ctx := errgroup.WithContext(ctx)
+
+	if dockerSrv != nil {
+		dockerListener, err := listen(ctx, opts.DockerSocket)
+		if err != nil {
+			return err
+		}
+
+		defer closeListener(ctx, dockerListener, opts.DockerSocket)
+
+		log.G(ctx).
+			WithField("socket", opts.DockerSocket).
+			Info("serving docker api")
+
+		group.Go(func() error {
+			return dockerSrv.Serve(ctx, dockerListener)
+		})
+	}
+
+	log.G(ctx).
+		WithField("socket", socket).
+		Info("listening")
+
+	group.Go(func() error {
+		return srv.Serve(ctx, listener)
+	})
+
+	// Each machine of each platform is monitored by the daemon, such that its
+	// health is followed and it is restarted as its restart policy demands.
+	group.Go(func() error {
+		if err := daemon.Monitor(ctx, srv.Machines(), machines, nil, false); err != nil {
+			return fmt.Errorf("could not monitor machines: %w", err)
+		}
+
+		return nil
+	})
+
+	return group.Wait()
+}
+
+// listen listens on the provided unix socket, which is only accessible to the
+// owner and group of the daemon.  The socket of a previous daemon which has
+// not exited cleanly is removed.
+func listen(ctx context.Context, socket string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s: %w", socket, err)
+	}
+
+	if err := os.Chmod(socket, 0o660); err != nil {
+		closeListener(ctx, listener, socket)
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// closeListener closes the provided listener and removes its socket.
+func closeListener(ctx context.Context, listener net.Listener, socket string) {
+	_ = listener.Close()
+
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		log.G(ctx).Errorf("could not remove socket: %v", err)
+	}
+}
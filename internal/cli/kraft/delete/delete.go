@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package delete
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/internal/objects"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/dns"
+	"kraftkit.sh/machine/volume"
+)
+
+type DeleteOptions struct {
+	Files []string `long:"file" short:"f" usage:"Path to a YAML or JSON file of objects, or - to read from stdin"`
+}
+
+// Delete removes the machines, networks and volumes of the provided
+// declarative objects.
+func Delete(ctx context.Context, opts *DeleteOptions, args ...string) error {
+	if opts == nil {
+		opts = &DeleteOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DeleteOptions{}, cobra.Command{
+		Short: "Remove unikernels, networks and volumes declared in files",
+		Use:   "delete [FLAGS] -f FILE [-f FILE [...]]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Remove the unikernels, networks and volumes which are declared in one or
+			more YAML or JSON files, as accepted by 'kraft apply'.  Unikernels are
+			removed before the networks and volumes which they may use.  Objects
+			which do not exist are skipped.`),
+		Example: heredoc.Doc(`
+			Remove the objects declared in machine.yaml:
+			$ kraft delete -f machine.yaml`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DeleteOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if len(opts.Files) == 0 {
+		return fmt.Errorf("please supply at least one file with the -f flag")
+	}
+
+	return nil
+}
+
+func (opts *DeleteOptions) Run(ctx context.Context, _ []string) error {
+	objs, err := objects.ReadFiles(ctx, opts.Files...)
+	if err != nil {
+		return err
+	}
+
+	objs = objects.SortByDependency(objs)
+
+	// Remove dependents before their dependencies.
+	for i := len(objs) - 1; i >= 0; i-- {
+		kind, err := objects.KindOf(objs[i])
+		if err != nil {
+			return err
+		}
+
+		accessor, err := meta.Accessor(objs[i])
+		if err != nil {
+			return err
+		}
+
+		name := accessor.GetName()
+		if name == "" {
+			return fmt.Errorf("cannot delete %s without a name", kind)
+		}
+
+		existing, err := objects.Get(ctx, kind, name)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			log.G(ctx).
+				WithField(string(kind), name).
+				Warn("not found")
+			continue
+		}
+
+		switch existing := existing.(type) {
+		case *machineapi.Machine:
+			err = remove.Remove(ctx, &remove.RemoveOptions{Platform: "auto"}, existing.Name)
+		case *networkapi.Network:
+			err = deleteNetwork(ctx, existing)
+		case *volumeapi.Volume:
+			err = deleteVolume(ctx, existing)
+		}
+		if err != nil {
+			return fmt.Errorf("could not delete %s %s: %w", kind, name, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteNetwork removes the provided network through the driver which
+// manages it.
+func deleteNetwork(ctx context.Context, existing *networkapi.Network) error {
+	strategy, ok := network.Strategies()[existing.Spec.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", existing.Spec.Driver)
+	}
+
+	controller, err := strategy.NewNetworkV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := controller.Delete(ctx, existing); err != nil {
+		return err
+	}
+
+	if err := dns.Stop(ctx, existing.Name); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, existing.Name)
+
+	return nil
+}
+
+// deleteVolume removes the provided volume through the driver which manages
+// it.
+func deleteVolume(ctx context.Context, existing *volumeapi.Volume) error {
+	strategy, ok := volume.Strategies()[existing.Spec.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", existing.Spec.Driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := controller.Delete(ctx, existing); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, existing.Name)
+
+	return nil
+}
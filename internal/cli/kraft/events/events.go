@@ -6,7 +6,6 @@ package events
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -20,15 +19,14 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
-	"kraftkit.sh/internal/waitgroup"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/daemon"
 	mplatform "kraftkit.sh/machine/platform"
-	"kraftkit.sh/machine/qemu/qmp"
 )
 
 type EventOptions struct {
 	platform     string
-	Granularity  time.Duration `long:"poll-granularity" short:"g" usage:"How often the machine store and state should polled"`
+	Granularity  time.Duration `long:"poll-granularity" short:"g" usage:"Deprecated: has no effect"`
 	QuitTogether bool          `long:"quit-together" short:"q" usage:"Exit event loop when machine exits"`
 }
 
@@ -40,7 +38,12 @@ func NewCmd() *cobra.Command {
 		Args:    cobra.MaximumNArgs(1),
 		Aliases: []string{"event", "e"},
 		Long: heredoc.Doc(`
-			Follow the events of a unikernel`),
+			Follow the events of a unikernel
+
+			The machine store is held open whilst the events are followed, such
+			that other kraft commands cannot access it in the meantime.  Use
+			'kraft daemon' instead, which serves them whilst it monitors every
+			unikernel.`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
 		},
@@ -59,11 +62,15 @@ func NewCmd() *cobra.Command {
 		"Set the platform virtual machine monitor driver.",
 	)
 
+	// The state of machines is no longer polled since the events of each
+	// machine are followed as they occur.
+	if err := cmd.PersistentFlags().MarkDeprecated("poll-granularity", "the events of machines are followed as they occur"); err != nil {
+		panic(err)
+	}
+
 	return cmd
 }
 
-var observations = waitgroup.WaitGroup[*machineapi.Machine]{}
-
 func (opts *EventOptions) Pre(cmd *cobra.Command, _ []string) error {
 	opts.platform = cmd.Flag("plat").Value.String()
 	return nil
@@ -74,6 +81,12 @@ func (opts *EventOptions) Run(ctx context.Context, args []string) error {
 
 	log.G(ctx).Warnf("This command is DEPRECATED and should not be used")
 
+	// The daemon monitors all machines itself, which must not be restarted
+	// twice.
+	if daemon.Running(ctx) {
+		return fmt.Errorf("machines are monitored by the daemon on %s", daemon.Socket(ctx))
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	platform := mplatform.PlatformUnknown
 
@@ -104,6 +117,14 @@ func (opts *EventOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	// The store is watched for machines as they are created and changed, which
+	// holds it open for as long as the command runs.
+	machines, err := mplatform.NewMachineV1alpha1Store(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("could not access the machine store: %w", err)
+	}
+
 	var pidfile *os.File
 
 	// Check if a pid has already been enabled
@@ -147,87 +168,18 @@ func (opts *EventOptions) Run(ctx context.Context, args []string) error {
 		cancel()
 	}()
 
-	// TODO: Should we throw an error here if a process file already exists?  We
-	// use a pid file for `kraft run` to continuously monitor running machines.
-
-	// Actively seek for machines whose events we wish to monitor.  The thread
-	// will continuously read from the machine store which can be updated
-	// elsewhere and acts as the source-of-truth for VMs which are being
-	// instantiated by KraftKit.  The thread dies if there is nothing in the store
-	// and the `--quit-together` flag is set.
-seek:
-	for {
-		select {
-		case <-ctx.Done():
-			break seek
-		default:
-		}
-
-		machines, err := controller.List(ctx, &machineapi.MachineList{})
-		if err != nil {
-			return fmt.Errorf("could not list machines: %v", err)
-		}
-
-		for _, machine := range machines.Items {
-			if len(args) == 0 || (args[0] == string(machine.UID) || args[0] == machine.Name) {
-				switch machine.Status.State {
-				case machineapi.MachineStateFailed,
-					machineapi.MachineStateExited,
-					machineapi.MachineStateUnknown:
-					if opts.QuitTogether {
-						continue
-					}
-				default:
-				}
-
-				observations.Add(&machine)
-			}
-		}
-
-		if len(observations.Items()) == 0 && opts.QuitTogether {
-			cancel()
-			break seek
-		}
+	defer cancel()
 
-		for _, machine := range observations.Items() {
-			machine := machine // loop closure
-
-			go func() {
-				events, errs, err := controller.Watch(ctx, machine)
-				if err != nil {
-					log.G(ctx).Debugf("could not listen for status updates for %s: %v", machine.Name, err)
-					return
-				}
-
-				for {
-					// Wait on either channel
-					select {
-					case machine := <-events:
-						log.G(ctx).Infof("%s : %s", machine.Name, machine.Status.State.String())
-						switch machine.Status.State {
-						case machineapi.MachineStateExited, machineapi.MachineStateFailed:
-							observations.Done(machine)
-							return
-						}
-
-					case err := <-errs:
-						if !errors.Is(err, qmp.ErrAcceptedNonEvent) {
-							log.G(ctx).Errorf("%v", err)
-						}
-						observations.Done(machine)
-
-					case <-ctx.Done():
-						observations.Done(machine)
-						return
-					}
-				}
-			}()
+	var filter func(*machineapi.Machine) bool
+	if len(args) > 0 {
+		filter = func(machine *machineapi.Machine) bool {
+			return args[0] == string(machine.UID) || args[0] == machine.Name
 		}
-
-		time.Sleep(time.Second * opts.Granularity)
 	}
 
-	observations.Wait()
+	controllers := map[string]machineapi.MachineService{
+		platform.String(): controller,
+	}
 
-	return nil
+	return daemon.Monitor(ctx, controllers, machines, filter, opts.QuitTogether)
 }
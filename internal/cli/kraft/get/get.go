@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package get
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/objects"
+	"kraftkit.sh/iostreams"
+)
+
+type GetOptions struct {
+	Output string `long:"output" short:"o" usage:"Set output format (yaml|json)" default:"yaml"`
+}
+
+// Get exports the declaration of one or all machines, networks or volumes.
+func Get(ctx context.Context, opts *GetOptions, args ...string) error {
+	if opts == nil {
+		opts = &GetOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&GetOptions{}, cobra.Command{
+		Short: "Export unikernels, networks and volumes in declarative form",
+		Use:   "get [FLAGS] TYPE [NAME]",
+		Args:  cobra.RangeArgs(1, 2),
+		Long: heredoc.Doc(`
+			Export one or all unikernels, networks or volumes in the declarative
+			form which is accepted by 'kraft apply'.  TYPE is one of machine,
+			network or volume.`),
+		Example: heredoc.Doc(`
+			Export the unikernel named nginx:
+			$ kraft get machine nginx -o yaml
+
+			Export all networks as JSON:
+			$ kraft get networks -o json`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *GetOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Output != objects.FormatYAML && opts.Output != objects.FormatJSON {
+		return fmt.Errorf("unsupported output format: %s", opts.Output)
+	}
+
+	return nil
+}
+
+func (opts *GetOptions) Run(ctx context.Context, args []string) error {
+	kind, err := objects.ParseKind(args[0])
+	if err != nil {
+		return err
+	}
+
+	var objs []runtime.Object
+
+	if len(args) > 1 {
+		obj, err := objects.Get(ctx, kind, args[1])
+		if err != nil {
+			return err
+		}
+
+		if obj == nil {
+			return fmt.Errorf("could not find %s: %s", kind, args[1])
+		}
+
+		objs = append(objs, obj)
+	} else {
+		objs, err = objects.List(ctx, kind)
+		if err != nil {
+			return err
+		}
+	}
+
+	return objects.Encode(iostreams.G(ctx).Out, opts.Output, objs...)
+}
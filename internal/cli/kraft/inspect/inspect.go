@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/objects"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type InspectOptions struct {
+	Format string `long:"format" usage:"Format the output using a Go template"`
+}
+
+// Inspect prints the stored representation of one or more machines, networks
+// or volumes.
+func Inspect(ctx context.Context, opts *InspectOptions, args ...string) error {
+	if opts == nil {
+		opts = &InspectOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&InspectOptions{}, cobra.Command{
+		Short: "Show detailed information about unikernels, networks and volumes",
+		Use:   "inspect [FLAGS] [TYPE/]NAME [[TYPE/]NAME [...]]",
+		Args:  cobra.MinimumNArgs(1),
+		Long: heredoc.Doc(`
+			Show the complete stored representation of one or more unikernels,
+			networks or volumes, including their status and, for unikernels, the
+			configuration of their platform.  TYPE is one of machine, network or
+			volume and may be omitted if NAME is unambiguous.
+
+			By default, the objects are printed as a JSON array.  With --format,
+			the provided Go template is executed for each object instead, where the
+			fields of the object are accessed by their Go names, e.g. .Status.Pid.
+			The functions json, join, lower, upper and split are available.`),
+		Example: heredoc.Doc(`
+			Show all details of the unikernel named nginx:
+			$ kraft inspect nginx
+
+			Show the PID and path to the kernel of a unikernel:
+			$ kraft inspect --format '{{.Status.Pid}} {{.Status.KernelPath}}' machine/nginx
+
+			Show the IP address of a unikernel on its first network:
+			$ kraft inspect --format '{{(index (index .Spec.Networks 0).Interfaces 0).Spec.IP}}' nginx
+
+			Show the gateway of a network:
+			$ kraft inspect --format '{{.Spec.Gateway}}' network/kraft0`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *InspectOptions) Pre(cmd *cobra.Command, _ []string) error {
+	return nil
+}
+
+func (opts *InspectOptions) Run(ctx context.Context, args []string) error {
+	var tmpl *template.Template
+
+	if opts.Format != "" {
+		var err error
+
+		tmpl, err = template.New("format").Funcs(templateFuncs).Parse(opts.Format)
+		if err != nil {
+			return fmt.Errorf("could not parse format: %w", err)
+		}
+	}
+
+	var objs []runtime.Object
+
+	for _, arg := range args {
+		obj, err := lookup(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		objs = append(objs, obj)
+	}
+
+	out := iostreams.G(ctx).Out
+
+	if tmpl == nil {
+		ret, err := json.MarshalIndent(objs, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s\n", ret)
+
+		return nil
+	}
+
+	for _, obj := range objs {
+		if err := tmpl.Execute(out, obj); err != nil {
+			return fmt.Errorf("could not execute format: %w", err)
+		}
+
+		fmt.Fprintln(out)
+	}
+
+	return nil
+}
+
+// lookup returns the object which is referenced by the provided argument.  The
+// platform configuration of machines is decoded into the configuration type of
+// their platform driver.
+func lookup(ctx context.Context, ref string) (runtime.Object, error) {
+	kind, name, err := objects.ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj runtime.Object
+
+	if kind == "" {
+		obj, err = objects.Find(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		obj, err = objects.Get(ctx, kind, name)
+		if err != nil {
+			return nil, err
+		} else if obj == nil {
+			return nil, fmt.Errorf("could not find %s: %s", strings.ToLower(kind.String()), name)
+		}
+	}
+
+	if machine, ok := obj.(*machineapi.Machine); ok {
+		machine.Status.PlatformConfig, err = mplatform.DecodePlatformConfig(machine)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode platform configuration of machine %s: %w", machine.Name, err)
+		}
+	}
+
+	return obj, nil
+}
+
+// templateFuncs are the functions which are available to the --format
+// template.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		ret, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		return string(ret), nil
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"split": strings.Split,
+}
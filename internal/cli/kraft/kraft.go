@@ -24,11 +24,18 @@ import (
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/log"
 
+	"kraftkit.sh/internal/cli/kraft/apply"
+	"kraftkit.sh/internal/cli/kraft/attach"
 	"kraftkit.sh/internal/cli/kraft/build"
 	"kraftkit.sh/internal/cli/kraft/clean"
 	"kraftkit.sh/internal/cli/kraft/cloud"
+	"kraftkit.sh/internal/cli/kraft/compose"
+	"kraftkit.sh/internal/cli/kraft/daemon"
+	"kraftkit.sh/internal/cli/kraft/delete"
 	"kraftkit.sh/internal/cli/kraft/events"
 	"kraftkit.sh/internal/cli/kraft/fetch"
+	"kraftkit.sh/internal/cli/kraft/get"
+	"kraftkit.sh/internal/cli/kraft/inspect"
 	"kraftkit.sh/internal/cli/kraft/login"
 	"kraftkit.sh/internal/cli/kraft/logs"
 	"kraftkit.sh/internal/cli/kraft/menu"
@@ -38,6 +45,8 @@ import (
 	"kraftkit.sh/internal/cli/kraft/remove"
 	"kraftkit.sh/internal/cli/kraft/run"
 	"kraftkit.sh/internal/cli/kraft/set"
+	"kraftkit.sh/internal/cli/kraft/snapshot"
+	"kraftkit.sh/internal/cli/kraft/stats"
 	"kraftkit.sh/internal/cli/kraft/stop"
 	"kraftkit.sh/internal/cli/kraft/unset"
 	"kraftkit.sh/internal/cli/kraft/version"
@@ -83,13 +92,24 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(pkg.NewCmd())
 
 	cmd.AddGroup(&cobra.Group{ID: "run", Title: "LOCAL RUNTIME COMMANDS"})
+	cmd.AddCommand(apply.NewCmd())
+	cmd.AddCommand(attach.NewCmd())
+	cmd.AddCommand(daemon.NewCmd())
+	cmd.AddCommand(delete.NewCmd())
 	cmd.AddCommand(events.NewCmd())
+	cmd.AddCommand(get.NewCmd())
+	cmd.AddCommand(inspect.NewCmd())
 	cmd.AddCommand(logs.NewCmd())
 	cmd.AddCommand(ps.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
 	cmd.AddCommand(run.NewCmd())
+	cmd.AddCommand(snapshot.NewCmd())
+	cmd.AddCommand(stats.NewCmd())
 	cmd.AddCommand(stop.NewCmd())
 
+	cmd.AddGroup(&cobra.Group{ID: "compose", Title: "COMPOSE COMMANDS"})
+	cmd.AddCommand(compose.NewCmd())
+
 	cmd.AddGroup(&cobra.Group{ID: "net", Title: "LOCAL NETWORKING COMMANDS"})
 	cmd.AddCommand(net.NewCmd())
 
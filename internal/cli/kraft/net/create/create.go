@@ -7,10 +7,9 @@ package create
 import (
 	"context"
 	"fmt"
-	"net"
 
+	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
-	"github.com/vishvananda/netlink"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	networkapi "kraftkit.sh/api/network/v1alpha1"
@@ -20,8 +19,9 @@ import (
 )
 
 type CreateOptions struct {
-	Driver  string `noattribute:"true"`
-	Network string `long:"network" short:"n" usage:"Set the gateway IP address and the subnet of the network in CIDR format."`
+	Driver  string   `noattribute:"true"`
+	Network string   `long:"network" short:"n" usage:"Set the gateway IP address and the subnet of the network in CIDR format (deprecated, use --subnet)."`
+	Subnets []string `long:"subnet" usage:"Set the gateway IP address and the subnet of the network in CIDR format, e.g. 172.18.0.1/16 or fd00::1/64 (repeatable, once per address family)" split:"false"`
 }
 
 // Create a new local machine network.
@@ -39,6 +39,21 @@ func NewCmd() *cobra.Command {
 		Use:     "create [FLAGS] NETWORK",
 		Aliases: []string{"add"},
 		Args:    cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Create a new machine network.
+
+			Networks may have an IPv4 subnet, an IPv6 subnet or both.  Unikernels
+			are configured with the addresses of each of their interfaces via the
+			netdev.ip and netdev.ip6 parameters, where the n-th value applies to the
+			n-th interface.  The addresses of interfaces which follow an interface
+			without an address of the same family therefore cannot be configured.`),
+		Example: heredoc.Doc(`
+			# Create an IPv4 network
+			$ kraft net create --subnet 172.18.0.1/16 my-network
+
+			# Create a dual-stack network
+			$ kraft net create --subnet 172.18.0.1/16 --subnet fd00::1/64 my-network
+		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "net",
 		},
@@ -62,7 +77,11 @@ func (opts *CreateOptions) Pre(cmd *cobra.Command, _ []string) error {
 	// if opts.Subnet == "" {
 	// 	return fmt.Errorf("cannot create network without subnet")
 	// }
-	if opts.Network == "" {
+	if opts.Network != "" {
+		opts.Subnets = append([]string{opts.Network}, opts.Subnets...)
+	}
+
+	if len(opts.Subnets) == 0 {
 		return fmt.Errorf("cannot create network without gateway and subnet in CIDR format")
 	}
 
@@ -82,19 +101,18 @@ func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	addr, err := netlink.ParseAddr(opts.Network)
-	if err != nil {
-		return err
+	var spec networkapi.NetworkSpec
+	for _, subnet := range opts.Subnets {
+		if err := spec.SetSubnet(subnet); err != nil {
+			return fmt.Errorf("invalid subnet %s: %w", subnet, err)
+		}
 	}
 
 	if _, err := controller.Create(ctx, &networkapi.Network{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: args[0],
 		},
-		Spec: networkapi.NetworkSpec{
-			Gateway: addr.IP.String(),
-			Netmask: net.IP(addr.Mask).String(),
-		},
+		Spec: spec,
 	}); err != nil {
 		return err
 	}
@@ -7,7 +7,7 @@ package list
 import (
 	"context"
 	"fmt"
-	"net"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -76,14 +76,10 @@ func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
 	var items []netTable
 
 	for _, network := range networks.Items {
-		addr := &net.IPNet{
-			IP:   net.ParseIP(network.Spec.Gateway),
-			Mask: net.IPMask(net.ParseIP(network.Spec.Netmask)),
-		}
 		items = append(items, netTable{
 			id:      string(network.UID),
 			name:    network.Name,
-			network: addr.String(),
+			network: strings.Join(network.Spec.Subnets(), ", "),
 			driver:  opts.Driver,
 			status:  network.Status.State,
 		})
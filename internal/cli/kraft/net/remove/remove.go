@@ -15,6 +15,7 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/dns"
 )
 
 type RemoveOptions struct {
@@ -73,6 +74,10 @@ func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if err := dns.Stop(ctx, args[0]); err != nil {
+		return err
+	}
+
 	fmt.Fprintln(iostreams.G(ctx).Out, args[0])
 
 	return nil
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -15,9 +16,11 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/machine/network"
+	"kraftkit.sh/machine/network/dns"
 )
 
 type UpOptions struct {
+	DNS    bool   `long:"dns" usage:"Start an embedded DNS responder which resolves the names of the machines on the network"`
 	Driver string `noattribute:"true"`
 }
 
@@ -33,9 +36,26 @@ func Up(ctx context.Context, opts *UpOptions, args ...string) error {
 func NewCmd() *cobra.Command {
 	cmd, err := cmdfactory.New(&UpOptions{}, cobra.Command{
 		Short:   "Bring a network online",
-		Use:     "up",
+		Use:     "up [FLAGS] NETWORK",
 		Aliases: []string{"start"},
 		Args:    cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Bring a network online.
+
+			With --dns, an embedded DNS responder is started which listens on the
+			gateway addresses of the network.  It resolves the names of the
+			machines on the network, as well as the names of compose services, to
+			the addresses of their interfaces and forwards all other queries to the
+			resolvers of the host.  Machines which are attached to the network
+			afterwards use the responder as their nameserver.  The responder lists
+			the machines through the daemon, which must be running (see kraft
+			daemon).
+		`),
+		Example: heredoc.Doc(`
+			# Bring a network online with an embedded DNS responder
+			$ kraft daemon &
+			$ kraft net up --dns my-network
+		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "net",
 		},
@@ -72,6 +92,18 @@ func (opts *UpOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if opts.DNS {
+		// Retrieve the gateway addresses of the network to listen on.
+		network, err = controller.Get(ctx, network)
+		if err != nil {
+			return err
+		}
+
+		if err := dns.Start(ctx, network); err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintln(iostreams.G(ctx).Out, network.Name)
 
 	return nil
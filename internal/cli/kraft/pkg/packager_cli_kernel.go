@@ -91,6 +91,7 @@ func (p *packagerCliKernel) Pack(ctx context.Context, opts *PkgOptions, args ...
 					packmanager.PackArgs(cmdShellArgs...),
 					packmanager.PackInitrd(opts.Rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
+					packmanager.PackKernelDbg(opts.Dbg),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 				)
@@ -258,6 +258,7 @@ func (p *packagerKraftfileRuntime) Pack(ctx context.Context, opts *PkgOptions, a
 					packmanager.PackArgs(cmdShellArgs...),
 					packmanager.PackInitrd(opts.Rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
+					packmanager.PackKernelDbg(opts.Dbg),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 				)
@@ -130,6 +130,7 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 					packmanager.PackArgs(cmdShellArgs...),
 					packmanager.PackInitrd(opts.Rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
+					packmanager.PackKernelDbg(opts.Dbg),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 				)
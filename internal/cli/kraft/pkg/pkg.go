@@ -36,7 +36,7 @@ import (
 type PkgOptions struct {
 	Architecture string                    `local:"true" long:"arch" short:"m" usage:"Filter the creation of the package by architecture of known targets"`
 	Args         []string                  `local:"true" long:"args" short:"a" usage:"Pass arguments that will be part of the running kernel's command line"`
-	Dbg          bool                      `local:"true" long:"dbg" usage:"Package the debuggable (symbolic) kernel image instead of the stripped image"`
+	Dbg          bool                      `local:"true" long:"dbg" usage:"Also package the debuggable (symbolic) kernel image, e.g. for use with kraft run --gdb"`
 	Force        bool                      `local:"true" long:"force-format" usage:"Force the use of a packaging handler format"`
 	Format       string                    `local:"true" long:"as" short:"M" usage:"Force the packaging despite possible conflicts" default:"oci"`
 	Kernel       string                    `local:"true" long:"kernel" short:"k" usage:"Override the path to the unikernel image"`
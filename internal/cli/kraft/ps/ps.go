@@ -7,6 +7,7 @@ package ps
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
@@ -16,13 +17,15 @@ import (
 	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
 
+	"github.com/MakeNowJust/heredoc"
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 )
 
 type PsOptions struct {
-	Architecture string `long:"arch" short:"m" usage:"Filter the list by architecture"`
-	Long         bool   `long:"long" short:"l" usage:"Show more information"`
+	Architecture string   `long:"arch" short:"m" usage:"Filter the list by architecture"`
+	Filter       []string `long:"filter" short:"f" usage:"Filter the list by label=KEY[=VALUE], state=STATE, plat=PLAT or arch=ARCH"`
+	Long         bool     `long:"long" short:"l" usage:"Show more information"`
 	platform     string
 	Quiet        bool   `long:"quiet" short:"q" usage:"Only display machine IDs"`
 	ShowAll      bool   `long:"all" short:"a" usage:"Show all machines (default shows just running)"`
@@ -39,6 +42,12 @@ func NewCmd() *cobra.Command {
 		Use:   "ps [FLAGS]",
 		Args:  cobra.MaximumNArgs(0),
 		Long:  "List running unikernels",
+		Example: heredoc.Doc(`
+			List the running unikernels labelled app=nginx on QEMU:
+			$ kraft ps --filter label=app=nginx,plat=qemu
+
+			List the unikernels which have exited or errored:
+			$ kraft ps --filter state=exited --filter state=errored`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
 		},
@@ -74,16 +83,23 @@ func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
 		kernel  string
 		args    string
 		created string
-		status  machineapi.MachineState
+		status  string
+		health  string
 		mem     string
 		ports   string
 		arch    string
 		plat    string
 		ips     []string
+		labels  []string
 	}
 
 	var items []psTable
 
+	filter, err := machineapi.ParseMachineFilter(opts.Filter...)
+	if err != nil {
+		return err
+	}
+
 	platform := mplatform.PlatformUnknown
 	var controller machineapi.MachineService
 
@@ -120,12 +136,16 @@ func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
 	}
 
 	for _, machine := range machines.Items {
+		if !filter.Matches(&machine) {
+			continue
+		}
+
 		entry := psTable{
 			id:      string(machine.UID),
 			name:    machine.Name,
 			args:    strings.Join(machine.Spec.ApplicationArgs, " "),
 			kernel:  machine.Spec.Kernel,
-			status:  machine.Status.State,
+			status:  machine.Status.State.String(),
 			mem:     fmt.Sprintf("%dMiB", machine.Spec.Resources.Requests.Memory().Value()/MemoryMiB),
 			created: humanize.Time(machine.ObjectMeta.CreationTimestamp.Time),
 			ports:   machine.Spec.Ports.String(),
@@ -134,12 +154,30 @@ func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
 			ips:     []string{},
 		}
 
+		// Show why the machine has exited, if it is known.
+		if machine.Status.ExitReason != "" {
+			switch machine.Status.State {
+			case machineapi.MachineStateExited, machineapi.MachineStateErrored, machineapi.MachineStateFailed:
+				entry.status = fmt.Sprintf("%s (%s)", entry.status, machine.Status.ExitReason)
+			}
+		}
+
+		if machine.Status.Health != nil {
+			entry.health = machine.Status.Health.Status.String()
+		}
+
 		for _, net := range machine.Spec.Networks {
 			for _, iface := range net.Interfaces {
 				entry.ips = append(entry.ips, iface.Spec.IP)
 			}
 		}
 
+		for key, value := range machine.Labels {
+			entry.labels = append(entry.labels, fmt.Sprintf("%s=%s", key, value))
+		}
+
+		sort.Strings(entry.labels)
+
 		items = append(items, entry)
 	}
 
@@ -169,11 +207,13 @@ func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
 	table.AddField("ARGS", cs.Bold)
 	table.AddField("CREATED", cs.Bold)
 	table.AddField("STATUS", cs.Bold)
+	table.AddField("HEALTH", cs.Bold)
 	table.AddField("MEM", cs.Bold)
 	if opts.Long {
 		table.AddField("PORTS", cs.Bold)
 		table.AddField("IP", cs.Bold)
 		table.AddField("ARCH", cs.Bold)
+		table.AddField("LABELS", cs.Bold)
 	}
 	table.AddField("PLAT", cs.Bold)
 	table.EndRow()
@@ -186,12 +226,14 @@ func (opts *PsOptions) Run(ctx context.Context, _ []string) error {
 		table.AddField(item.kernel, nil)
 		table.AddField(item.args, nil)
 		table.AddField(item.created, nil)
-		table.AddField(item.status.String(), nil)
+		table.AddField(item.status, nil)
+		table.AddField(item.health, nil)
 		table.AddField(item.mem, nil)
 		if opts.Long {
 			table.AddField(item.ports, nil)
 			table.AddField(strings.Join(item.ips, ","), nil)
 			table.AddField(item.arch, nil)
+			table.AddField(strings.Join(item.labels, ","), nil)
 			table.AddField(item.plat, nil)
 		} else {
 			table.AddField(fmt.Sprintf("%s/%s", item.plat, item.arch), nil)
@@ -22,8 +22,9 @@ import (
 )
 
 type RemoveOptions struct {
-	All      bool   `long:"all" usage:"Remove all machines"`
-	Platform string `noattribute:"true"`
+	All      bool     `long:"all" usage:"Remove all machines"`
+	Filter   []string `long:"filter" short:"f" usage:"Only remove machines matching label=KEY[=VALUE], state=STATE, plat=PLAT or arch=ARCH"`
+	Platform string   `noattribute:"true"`
 }
 
 // Remove stops and deletes a local Unikraft virtual machine.
@@ -43,6 +44,9 @@ func NewCmd() *cobra.Command {
 		Aliases: []string{"remove"},
 		Long: heredoc.Doc(`
 			Remove one or more running unikernels`),
+		Example: heredoc.Doc(`
+			Remove all unikernels which have exited:
+			$ kraft rm --filter state=exited`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
 		},
@@ -70,12 +74,15 @@ func (opts *RemoveOptions) Pre(cmd *cobra.Command, _ []string) error {
 }
 
 func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
-	var err error
-
-	if len(args) == 0 && !opts.All {
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 {
 		return fmt.Errorf("no machine(s) specified")
 	}
 
+	filter, err := machineapi.ParseMachineFilter(opts.Filter...)
+	if err != nil {
+		return err
+	}
+
 	platform := mplatform.PlatformUnknown
 	var controller machineapi.MachineService
 
@@ -114,13 +121,20 @@ func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
 	var remove []machineapi.Machine
 
 	for _, machine := range machines.Items {
-		if len(args) == 0 && opts.All {
-			remove = append(remove, machine)
+		if !filter.Matches(&machine) {
 			continue
 		}
 
-		if args[0] == machine.Name || args[0] == string(machine.UID) {
+		if len(args) == 0 {
 			remove = append(remove, machine)
+			continue
+		}
+
+		for _, arg := range args {
+			if arg == machine.Name || arg == string(machine.UID) {
+				remove = append(remove, machine)
+				break
+			}
 		}
 	}
 
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,37 +29,58 @@ import (
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network"
 	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/snapshot"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/unikraft/arch"
 )
 
 type RunOptions struct {
-	Architecture  string   `long:"arch" short:"m" usage:"Set the architecture"`
-	Detach        bool     `long:"detach" short:"d" usage:"Run unikernel in background"`
-	DisableAccel  bool     `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
-	InitRd        string   `long:"initrd" usage:"Use the specified initrd (readonly)" hidden:"true"`
-	IP            string   `long:"ip" usage:"Assign the provided IP address"`
-	KernelArgs    []string `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
-	Kraftfile     string   `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
-	MacAddress    string   `long:"mac" usage:"Assign the provided MAC address"`
-	Memory        string   `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
-	Name          string   `long:"name" short:"n" usage:"Name of the instance"`
-	Network       string   `long:"network" usage:"Attach instance to the provided network in the format <driver>:<network>, e.g. bridge:kraft0"`
-	Platform      string   `noattribute:"true"`
-	Ports         []string `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
-	Remove        bool     `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
-	Rootfs        string   `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
-	RunAs         string   `long:"as" usage:"Force a specific runner"`
-	Target        string   `long:"target" short:"t" usage:"Explicitly use the defined project target"`
-	Volumes       []string `long:"volume" short:"v" usage:"Bind a volume to the instance"`
-	WithKernelDbg bool     `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
-
-	workdir           string
-	platform          mplatform.Platform
-	networkDriver     string
-	networkName       string
-	networkController networkapi.NetworkService
-	machineController machineapi.MachineService
+	Annotations       []string      `long:"annotation" usage:"Set an annotation on the unikernel (KEY=VALUE)" split:"false"`
+	Architecture      string        `long:"arch" short:"m" usage:"Set the architecture"`
+	CPULimit          string        `long:"cpu-limit" usage:"Limit the CPU time of the VMM (e.g. 1.5 or 500m)"`
+	CPUWeight         int           `long:"cpu-weight" usage:"Set the relative share of CPU time of the VMM (1-10000, default 100)"`
+	CrashDump         bool          `long:"crash-dump" usage:"Dump the memory of the unikernel into its state directory when it panics (see 'kraft x coredump')"`
+	Detach            bool          `long:"detach" short:"d" usage:"Run unikernel in background"`
+	DisableAccel      bool          `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
+	FromSnapshot      string        `long:"from-snapshot" usage:"Restore the unikernel from the provided snapshot"`
+	GDB               int           `long:"gdb" usage:"Start a GDB server for the unikernel on the provided port (--gdb[=PORT], default 1234)"`
+	GDBHost           string        `long:"gdb-host" usage:"Listen for GDB on the provided host address instead of 127.0.0.1, e.g. 0.0.0.0 to debug remotely (implies --gdb)"`
+	GDBWait           bool          `long:"gdb-wait" usage:"Wait for GDB to continue the unikernel before it boots (implies --gdb)"`
+	HealthCheck       string        `long:"health-check" usage:"Periodically check the health of the unikernel (tcp://[HOST]:PORT, http[s]://[HOST]:PORT[/PATH] or log:REGEX)"`
+	HealthInterval    time.Duration `long:"health-interval" usage:"Time between health checks (default 30s)"`
+	HealthRetries     int           `long:"health-retries" usage:"Consecutive failed health checks after which the unikernel is unhealthy (default 3)"`
+	HealthStartPeriod time.Duration `long:"health-start-period" usage:"Time after starting during which failed health checks are not counted"`
+	HealthTimeout     time.Duration `long:"health-timeout" usage:"Time after which a health check is considered to have failed (default 30s)"`
+	IOReadBPS         string        `long:"io-read-bps" usage:"Limit the bytes per second the VMM reads from each block device of the host (K/Ki, M/Mi, G/Gi)"`
+	IOWeight          int           `long:"io-weight" usage:"Set the relative share of block IO of the VMM (1-10000, default 100)"`
+	IOWriteBPS        string        `long:"io-write-bps" usage:"Limit the bytes per second the VMM writes to each block device of the host (K/Ki, M/Mi, G/Gi)"`
+	InitRd            string        `long:"initrd" usage:"Use the specified initrd (readonly)" hidden:"true"`
+	IP                string        `long:"ip" usage:"Assign the provided IPv4 or IPv6 address"`
+	KernelArgs        []string      `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
+	Kraftfile         string        `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Labels            []string      `long:"label" short:"l" usage:"Set a label on the unikernel (KEY=VALUE)" split:"false"`
+	MacAddress        string        `long:"mac" usage:"Assign the provided MAC address"`
+	Memory            string        `long:"memory" short:"M" usage:"Assign memory to the unikernel (K/Ki, M/Mi, G/Gi)" default:"64Mi"`
+	MemoryLimit       string        `long:"memory-limit" usage:"Limit the host memory of the VMM, which includes the memory of the unikernel (K/Ki, M/Mi, G/Gi)"`
+	Name              string        `long:"name" short:"n" usage:"Name of the instance"`
+	Networks          []string      `long:"network" usage:"Attach instance to the provided network in the format <driver>:<network>[,ip=IP][,mac=MAC][,gateway=GATEWAY][,ifname=IFNAME], e.g. bridge:kraft0 (repeatable)" split:"false"`
+	NoStart           bool          `noattribute:"true"`
+	Platform          string        `noattribute:"true"`
+	Ports             []string      `long:"port" short:"p" usage:"Publish a machine's port(s) to the host" split:"false"`
+	Remove            bool          `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
+	Restart           string        `long:"restart" usage:"Set the restart policy applied when the unikernel exits (no, on-failure[:max-retries], always, unless-stopped)"`
+	Rootfs            string        `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
+	RunAs             string        `long:"as" usage:"Force a specific runner"`
+	Target            string        `long:"target" short:"t" usage:"Explicitly use the defined project target"`
+	Volumes           []string      `long:"volume" short:"v" usage:"Bind a volume to the instance"`
+	WithKernelDbg     bool          `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
+
+	workdir            string
+	platform           mplatform.Platform
+	networks           []networkapi.NetworkAttachment
+	networkControllers map[string]networkapi.NetworkService
+	snapshot           *snapshot.Snapshot
+	machineController  machineapi.MachineService
 }
 
 // Run a Unikraft unikernel virtual machine locally.
@@ -99,6 +121,9 @@ func NewCmd() *cobra.Command {
 			Attach the unikernel to an existing network kraft0 backed by the bridge driver:
 			$ kraft run --network bridge:kraft0
 
+			Attach the unikernel to a public and a private network, where the default gateway is the one of the public network:
+			$ kraft run --network bridge:public,ip=10.0.0.2 --network bridge:private,ip=172.16.0.2,ifname=private0 unikraft.org/nginx:latest
+
 			Run a Linux userspace binary in POSIX-/binary-compatibility mode:
 			$ kraft run a.out
 
@@ -116,6 +141,30 @@ func NewCmd() *cobra.Command {
 
 			Customize the default content directory of the official Unikraft NGINX OCI-compatible unikernel and map port 8080 to localhost:
 			$ kraft run -v ./path/to/html:/nginx/html -p 8080:80 unikraft.org/nginx:latest
+
+			Run a unikernel which is restarted up to 5 times should it crash (requires 'kraft events' to be running):
+			$ kraft run --restart on-failure:5 unikraft.org/nginx:latest
+
+			Run a unikernel which is healthy once it responds to HTTP requests on port 8080:
+			$ kraft run --health-check http://:8080/ --health-interval 5s -p 8080:8080 unikraft.org/nginx:latest
+
+			Run a unikernel whose VMM may use at most half a CPU and 256 megabytes of host memory:
+			$ kraft run --cpu-limit 500m --memory 128Mi --memory-limit 256Mi unikraft.org/nginx:latest
+
+			Debug the symbolic unikernel with GDB through the generated .gdbinit, where the unikernel boots once continued by GDB:
+			$ kraft run --gdb --gdb-wait unikraft.org/nginx:latest
+
+			Debug the unikernel from another host through a GDB server which listens on all addresses:
+			$ kraft run --gdb=1234 --gdb-host 0.0.0.0 unikraft.org/nginx:latest
+
+			Dump the memory of the symbolic unikernel when it panics, which can then be exported with 'kraft x coredump':
+			$ kraft run --crash-dump unikraft.org/nginx:latest
+
+			Run a unikernel labelled such that it can be selected with 'kraft ps --filter label=app=nginx':
+			$ kraft run --label app=nginx --label tier=frontend unikraft.org/nginx:latest
+
+			Restore a unikernel from a snapshot previously taken with 'kraft snapshot create':
+			$ kraft run --from-snapshot my-machine-20230101120000
 			`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
@@ -134,14 +183,29 @@ func NewCmd() *cobra.Command {
 		"Set the platform virtual machine monitor driver.",
 	)
 
+	// Allow --gdb to be used without a port.  Flags of the options are
+	// registered as persistent flags.
+	cmd.PersistentFlags().Lookup("gdb").NoOptDefVal = strconv.Itoa(machineapi.DefaultGDBPort)
+
 	return cmd
 }
 
-func (opts *RunOptions) Pre(cmd *cobra.Command, _ []string) error {
+func (opts *RunOptions) Pre(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
 	opts.Platform = cmd.Flag("plat").Value.String()
 
+	// Debugging, including of crash dumps, requires the symbols of the kernel.
+	if opts.GDB != 0 || opts.GDBHost != "" || opts.GDBWait || opts.CrashDump {
+		opts.WithKernelDbg = true
+	}
+
+	if opts.FromSnapshot != "" {
+		if err := opts.loadSnapshot(cmd, args); err != nil {
+			return err
+		}
+	}
+
 	if opts.RunAs == "" || !set.NewStringSet("kernel", "project").Contains(opts.RunAs) {
 		// Set use of the global package manager.
 		ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
@@ -221,30 +285,47 @@ func (opts *RunOptions) discoverMachineController(ctx context.Context) error {
 	return nil
 }
 
-func (opts *RunOptions) discoverNetworkController(ctx context.Context) error {
-	var err error
-
-	if opts.Network == "" && opts.IP != "" {
-		return fmt.Errorf("cannot assign IP address without providing --network")
-	} else if opts.Network != "" && !strings.Contains(opts.Network, ":") {
-		return fmt.Errorf("specifying a network must be in the format <driver>:<network> e.g. --network=bridge:kraft0")
+func (opts *RunOptions) discoverNetworkControllers(ctx context.Context) error {
+	if len(opts.Networks) == 0 && (opts.IP != "" || opts.MacAddress != "") {
+		return fmt.Errorf("cannot assign IP or MAC address without providing --network")
+	} else if len(opts.Networks) > 1 && (opts.IP != "" || opts.MacAddress != "") {
+		return fmt.Errorf("cannot use --ip or --mac with multiple networks: use the ip= and mac= parameters of --network instead")
 	}
 
-	if opts.Network != "" {
-		// TODO(nderjung): With a little bit more work, the driver can be
-		// automatically detected.
-		parts := strings.SplitN(opts.Network, ":", 2)
-		opts.networkDriver, opts.networkName = parts[0], parts[1]
-
-		networkStrategy, ok := network.Strategies()[opts.networkDriver]
-		if !ok {
-			return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", opts.networkDriver)
-		}
+	opts.networks = make([]networkapi.NetworkAttachment, 0, len(opts.Networks))
+	opts.networkControllers = map[string]networkapi.NetworkService{}
 
-		opts.networkController, err = networkStrategy.NewNetworkV1alpha1(ctx)
+	for _, flag := range opts.Networks {
+		attachment, err := networkapi.ParseNetworkAttachment(flag)
 		if err != nil {
 			return err
 		}
+
+		// The --ip and --mac flags apply to the only network.
+		if opts.IP != "" && strings.Contains(opts.IP, ":") {
+			attachment.Interface.IP6 = opts.IP
+		} else if opts.IP != "" {
+			attachment.Interface.IP = opts.IP
+		}
+		if opts.MacAddress != "" {
+			attachment.Interface.MacAddress = opts.MacAddress
+		}
+
+		if _, ok := opts.networkControllers[attachment.Driver]; !ok {
+			// TODO(nderjung): With a little bit more work, the driver can be
+			// automatically detected.
+			networkStrategy, ok := network.Strategies()[attachment.Driver]
+			if !ok {
+				return fmt.Errorf("unsupported network driver strategy: %v (contributions welcome!)", attachment.Driver)
+			}
+
+			opts.networkControllers[attachment.Driver], err = networkStrategy.NewNetworkV1alpha1(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		opts.networks = append(opts.networks, attachment)
 	}
 
 	return nil
@@ -257,7 +338,7 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	if err = opts.discoverNetworkController(ctx); err != nil {
+	if err = opts.discoverNetworkControllers(ctx); err != nil {
 		return err
 	}
 
@@ -277,61 +358,85 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		},
 	}
 
-	var run runner
-	var errs []error
-	runners, err := runners()
-	if err != nil {
-		return err
-	}
-
-	// Iterate through the list of built-in runners which sequentially tests and
-	// first test whether the --as flag has been set to force a specific runner or
-	// whether the current context matches the requirements for being run given
-	// its context.  The first to test positive is used to prepare the machine
-	// specification which is later passed to the controller.
-	for _, candidate := range runners {
-		if opts.RunAs != "" && candidate.String() != opts.RunAs {
-			continue
+	if opts.snapshot != nil {
+		opts.restoreSnapshot(machine)
+	} else {
+		var run runner
+		var errs []error
+		runners, err := runners()
+		if err != nil {
+			return err
 		}
 
-		log.G(ctx).
-			WithField("runner", candidate.String()).
-			Trace("checking runnability")
+		// Iterate through the list of built-in runners which sequentially tests and
+		// first test whether the --as flag has been set to force a specific runner or
+		// whether the current context matches the requirements for being run given
+		// its context.  The first to test positive is used to prepare the machine
+		// specification which is later passed to the controller.
+		for _, candidate := range runners {
+			if opts.RunAs != "" && candidate.String() != opts.RunAs {
+				continue
+			}
 
-		capable, err := candidate.Runnable(ctx, opts, args...)
-		if capable && err == nil {
-			run = candidate
-			break
-		} else if err != nil {
-			errs = append(errs, err)
 			log.G(ctx).
 				WithField("runner", candidate.String()).
-				Debugf("cannot run because: %v", err)
+				Trace("checking runnability")
+
+			capable, err := candidate.Runnable(ctx, opts, args...)
+			if capable && err == nil {
+				run = candidate
+				break
+			} else if err != nil {
+				errs = append(errs, err)
+				log.G(ctx).
+					WithField("runner", candidate.String()).
+					Debugf("cannot run because: %v", err)
+			}
+		}
+		if run == nil {
+			return fmt.Errorf("could not determine how to run provided input: %w", errors.Join(errs...))
+		}
+
+		log.G(ctx).WithField("runner", run.String()).Debug("using")
+
+		// Prepare the machine specification based on the compatible runner.
+		if err := run.Prepare(ctx, opts, machine, args...); err != nil {
+			return err
+		}
+
+		// Override with command-line flags
+		if len(opts.KernelArgs) > 0 {
+			machine.Spec.KernelArgs = opts.KernelArgs
+		}
+
+		if len(opts.Memory) > 0 {
+			quantity, err := resource.ParseQuantity(opts.Memory)
+			if err != nil {
+				return err
+			}
+
+			machine.Spec.Resources.Requests[corev1.ResourceMemory] = quantity
 		}
-	}
-	if run == nil {
-		return fmt.Errorf("could not determine how to run provided input: %w", errors.Join(errs...))
 	}
 
-	log.G(ctx).WithField("runner", run.String()).Debug("using")
+	if err := opts.parseMetadata(ctx, machine); err != nil {
+		return err
+	}
 
-	// Prepare the machine specification based on the compatible runner.
-	if err := run.Prepare(ctx, opts, machine, args...); err != nil {
+	if err := opts.parseRestartPolicy(ctx, machine); err != nil {
 		return err
 	}
 
-	// Override with command-line flags
-	if len(opts.KernelArgs) > 0 {
-		machine.Spec.KernelArgs = opts.KernelArgs
+	if err := opts.parseHealthCheck(ctx, machine); err != nil {
+		return err
 	}
 
-	if len(opts.Memory) > 0 {
-		quantity, err := resource.ParseQuantity(opts.Memory)
-		if err != nil {
-			return err
-		}
+	if err := opts.parseResourceLimits(ctx, machine); err != nil {
+		return err
+	}
 
-		machine.Spec.Resources.Requests[corev1.ResourceMemory] = quantity
+	if err := opts.parseDebug(ctx, machine); err != nil {
+		return err
 	}
 
 	if err := opts.parsePorts(ctx, machine); err != nil {
@@ -360,6 +465,17 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if machine.Spec.Debug != nil {
+		if err := opts.writeGdbInit(ctx, machine); err != nil {
+			return err
+		}
+	}
+
+	// Only create the machine, e.g. on behalf of `docker create`.
+	if opts.NoStart {
+		return nil
+	}
+
 	var exitErr error
 	requestShutdown := false
 	logsFinished := make(chan bool, 1)
@@ -467,32 +583,10 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		fmt.Fprintf(iostreams.G(ctx).Out, "%s\n", machine.Name)
 	}
 
-	// Set up a clean up method to remove the interface if the machine exits and
-	// we are requesting to remove the machine.
-	if opts.Remove && !opts.Detach && len(machine.Spec.Networks) > 0 {
-		// Get the latest version of the network.
-		found, err := opts.networkController.Get(ctx, &networkapi.Network{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: opts.networkName,
-			},
-		})
-		if err != nil {
-			return fmt.Errorf("could not get network information for %s: %v", opts.networkName, err)
-		}
-
-		// Remove the new network interface
-		for i, iface := range found.Spec.Interfaces {
-			if iface.UID == machine.Spec.Networks[0].Interfaces[0].UID {
-				ret := make([]networkapi.NetworkInterfaceTemplateSpec, 0)
-				ret = append(ret, found.Spec.Interfaces[:i]...)
-				found.Spec.Interfaces = append(ret, found.Spec.Interfaces[i+1:]...)
-				break
-			}
-		}
-
-		if _, err = opts.networkController.Update(ctx, found); err != nil {
-			return fmt.Errorf("could not update network %s: %v", opts.networkName, err)
-		}
+	// Remove the interfaces if the machine exits and we are requesting to
+	// remove the machine.
+	if opts.Remove && !opts.Detach {
+		opts.detachNetworks(ctx, machine)
 	}
 
 	if !opts.Detach {
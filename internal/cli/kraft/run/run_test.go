@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package run
+
+import (
+	"testing"
+)
+
+func TestGDBFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "without port",
+			args: []string{"--gdb"},
+			want: "1234",
+		},
+		{
+			name: "with port",
+			args: []string{"--gdb=4321"},
+			want: "4321",
+		},
+		{
+			name: "unset",
+			want: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewCmd()
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags(%v) error = %v", tt.args, err)
+			}
+
+			if got := cmd.Flag("gdb").Value.String(); got != tt.want {
+				t.Errorf("--gdb = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
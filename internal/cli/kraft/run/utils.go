@@ -2,16 +2,22 @@ package run
 
 import (
 	"context"
+	"debug/elf"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/containerd/nerdctl/pkg/strutil"
 	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	networkapi "kraftkit.sh/api/network/v1alpha1"
@@ -19,6 +25,9 @@ import (
 	"kraftkit.sh/initrd"
 	"kraftkit.sh/log"
 	machinename "kraftkit.sh/machine/name"
+	"kraftkit.sh/machine/network/dns"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/snapshot"
 	"kraftkit.sh/machine/volume"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
@@ -42,64 +51,318 @@ func (opts *RunOptions) parsePorts(_ context.Context, machine *machineapi.Machin
 	return nil
 }
 
-// Was a network specified? E.g. --network=bridge:kraft0
-func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.Machine) error {
-	if opts.Network == "" {
+// Were labels or annotations specified? E.g. --label=app=nginx
+func (opts *RunOptions) parseMetadata(_ context.Context, machine *machineapi.Machine) error {
+	for _, label := range opts.Labels {
+		key, value, _ := strings.Cut(label, "=")
+
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key '%s': %s", key, strings.Join(errs, "; "))
+		}
+
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid value of label '%s': %s", key, strings.Join(errs, "; "))
+		}
+
+		if machine.Labels == nil {
+			machine.Labels = map[string]string{}
+		}
+
+		machine.Labels[key] = value
+	}
+
+	for _, annotation := range opts.Annotations {
+		key, value, _ := strings.Cut(annotation, "=")
+
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation key '%s': %s", key, strings.Join(errs, "; "))
+		}
+
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+
+		machine.Annotations[key] = value
+	}
+
+	return nil
+}
+
+// Was a restart policy specified? E.g. --restart=on-failure:5
+func (opts *RunOptions) parseRestartPolicy(_ context.Context, machine *machineapi.Machine) error {
+	if opts.Restart == "" {
 		return nil
 	}
 
-	// Try to discover the user-provided network.
-	found, err := opts.networkController.Get(ctx, &networkapi.Network{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: opts.networkName,
-		},
-	})
+	policy, err := machineapi.ParseRestartPolicy(opts.Restart)
 	if err != nil {
 		return err
 	}
 
-	// Generate the UID pre-emptively so that we can uniquely reference the
-	// network interface which will allow us to clean it up later. Additionally,
-	// it's OK if the IP or MAC address are empty, the network controller will
-	// populate values if they are unset and will populate with new values
-	// following the returning from the Update operation.
-	newIface := networkapi.NetworkInterfaceTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			UID: uuid.NewUUID(),
-		},
-		Spec: networkapi.NetworkInterfaceSpec{
-			IP:         opts.IP,
-			MacAddress: opts.MacAddress,
-		},
+	if opts.Remove && policy.Name != machineapi.RestartPolicyNo {
+		return fmt.Errorf("cannot use --restart=%s with --rm", policy.String())
 	}
 
-	// Update the list of interfaces
-	if found.Spec.Interfaces == nil {
-		found.Spec.Interfaces = []networkapi.NetworkInterfaceTemplateSpec{}
+	machine.Spec.RestartPolicy = policy
+
+	return nil
+}
+
+// Was a health check specified? E.g. --health-check=http://:8080/health
+func (opts *RunOptions) parseHealthCheck(_ context.Context, machine *machineapi.Machine) error {
+	if opts.HealthCheck == "" {
+		return nil
 	}
-	found.Spec.Interfaces = append(found.Spec.Interfaces, newIface)
 
-	// Update the network with the new interface.
-	found, err = opts.networkController.Update(ctx, found)
+	check, err := machineapi.ParseHealthCheck(opts.HealthCheck)
 	if err != nil {
 		return err
 	}
 
-	// Only use the single new interface.
-	for _, iface := range found.Spec.Interfaces {
-		if iface.UID == newIface.UID {
-			newIface = iface
-			break
+	if opts.HealthInterval > 0 {
+		check.Interval = metav1.Duration{Duration: opts.HealthInterval}
+	}
+
+	if opts.HealthTimeout > 0 {
+		check.Timeout = metav1.Duration{Duration: opts.HealthTimeout}
+	}
+
+	if opts.HealthRetries > 0 {
+		check.Retries = opts.HealthRetries
+	}
+
+	if opts.HealthStartPeriod > 0 {
+		check.StartPeriod = metav1.Duration{Duration: opts.HealthStartPeriod}
+	}
+
+	machine.Spec.HealthCheck = check
+
+	return nil
+}
+
+// Were limits of the host resources of the VMM specified? E.g. --cpu-limit=1.5
+func (opts *RunOptions) parseResourceLimits(_ context.Context, machine *machineapi.Machine) error {
+	quantities := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:            opts.CPULimit,
+		corev1.ResourceMemory:         opts.MemoryLimit,
+		machineapi.ResourceIOReadBPS:  opts.IOReadBPS,
+		machineapi.ResourceIOWriteBPS: opts.IOWriteBPS,
+	}
+
+	if opts.CPUWeight != 0 {
+		quantities[machineapi.ResourceCPUWeight] = strconv.Itoa(opts.CPUWeight)
+	}
+
+	if opts.IOWeight != 0 {
+		quantities[machineapi.ResourceIOWeight] = strconv.Itoa(opts.IOWeight)
+	}
+
+	for name, value := range quantities {
+		if value == "" {
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s limit: %w", name, err)
 		}
+
+		if machine.Spec.Resources.Limits == nil {
+			machine.Spec.Resources.Limits = make(corev1.ResourceList, len(quantities))
+		}
+
+		machine.Spec.Resources.Limits[name] = quantity
+	}
+
+	return nil
+}
+
+// Was a GDB server or a crash dump requested? E.g. --gdb=1234
+func (opts *RunOptions) parseDebug(_ context.Context, machine *machineapi.Machine) error {
+	machine.Spec.CrashDump = opts.CrashDump
+
+	if opts.GDB == 0 && opts.GDBHost == "" && !opts.GDBWait {
+		return nil
+	}
+
+	port := opts.GDB
+	if port == 0 {
+		port = machineapi.DefaultGDBPort
 	}
 
-	// Set the interface on the machine.
-	found.Spec.Interfaces = []networkapi.NetworkInterfaceTemplateSpec{newIface}
-	machine.Spec.Networks = []networkapi.NetworkSpec{found.Spec}
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("invalid GDB port: %d", port)
+	}
+
+	if opts.GDBHost != "" && net.ParseIP(opts.GDBHost) == nil {
+		return fmt.Errorf("invalid GDB host address: %s", opts.GDBHost)
+	}
+
+	machine.Spec.Debug = &machineapi.MachineDebug{
+		GDBPort: int32(port),
+		GDBHost: opts.GDBHost,
+		Wait:    opts.GDBWait,
+	}
 
 	return nil
 }
 
+// writeGdbInit generates a .gdbinit within the state directory of the machine
+// which loads the symbols of its kernel and connects to its GDB server.
+func (opts *RunOptions) writeGdbInit(ctx context.Context, machine *machineapi.Machine) error {
+	if !hasSymbols(machine.Status.KernelPath) {
+		log.G(ctx).Warnf("kernel %s has no symbols: the debuggable (symbolic) kernel is not available", machine.Status.KernelPath)
+	}
+
+	gdbinit := filepath.Join(machine.Status.StateDir, ".gdbinit")
+	// The GDB server is reached locally, unless it only listens on a specific
+	// address of the host.
+	remote := fmt.Sprintf("localhost:%d", machine.Spec.Debug.GDBPort)
+	if host := net.ParseIP(machine.Spec.Debug.GDBHost); host != nil && !host.IsUnspecified() {
+		remote = machine.Spec.Debug.Address()
+	}
+
+	script := fmt.Sprintf("file %s\ntarget remote %s\n", machine.Status.KernelPath, remote)
+
+	if err := os.WriteFile(gdbinit, []byte(script), 0o644); err != nil {
+		return fmt.Errorf("could not write .gdbinit: %w", err)
+	}
+
+	log.G(ctx).Infof("debug the unikernel with: gdb -x %s", gdbinit)
+
+	if machine.Spec.Debug.Wait {
+		log.G(ctx).Info("the unikernel boots once it is continued by gdb")
+	}
+
+	return nil
+}
+
+// hasSymbols returns whether the provided ELF file contains a symbol table.
+func hasSymbols(path string) bool {
+	f, err := elf.Open(path)
+	if err != nil {
+		return false
+	}
+
+	defer f.Close()
+
+	return f.Section(".symtab") != nil
+}
+
+// Were networks specified? E.g. --network=bridge:kraft0
+//
+// The machine is attached to either all or none of the networks, i.e. it is
+// detached again from the networks it has already been attached to if it
+// cannot be attached to one of them.
+func (opts *RunOptions) parseNetworks(ctx context.Context, machine *machineapi.Machine) (err error) {
+	defer func() {
+		if err != nil {
+			opts.detachNetworks(ctx, machine)
+			machine.Spec.Networks = nil
+		}
+	}()
+
+	for _, attachment := range opts.networks {
+		controller := opts.networkControllers[attachment.Driver]
+
+		// Try to discover the user-provided network.
+		found, err := controller.Get(ctx, &networkapi.Network{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: attachment.Network,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		// Generate the UID pre-emptively so that we can uniquely reference the
+		// network interface which will allow us to clean it up later.
+		// Additionally, it's OK if the IP or MAC address are empty, the network
+		// controller will populate values if they are unset and will populate
+		// with new values following the returning from the Update operation.
+		newIface := networkapi.NetworkInterfaceTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				UID: uuid.NewUUID(),
+			},
+			Spec: attachment.Interface,
+		}
+
+		// Update the list of interfaces
+		if found.Spec.Interfaces == nil {
+			found.Spec.Interfaces = []networkapi.NetworkInterfaceTemplateSpec{}
+		}
+		found.Spec.Interfaces = append(found.Spec.Interfaces, newIface)
+
+		// Update the network with the new interface.
+		found, err = controller.Update(ctx, found)
+		if err != nil {
+			return err
+		}
+
+		// Only use the single new interface.
+		for _, iface := range found.Spec.Interfaces {
+			if iface.UID == newIface.UID {
+				newIface = iface
+				break
+			}
+		}
+
+		// Resolve names via the embedded DNS responder of the network, if any.
+		if nameservers := dns.Nameservers(ctx, found); len(nameservers) > 0 {
+			found.Spec.Nameservers = nameservers
+		}
+
+		// Attach the machine to the network with the new interface.
+		found.Spec.Interfaces = []networkapi.NetworkInterfaceTemplateSpec{newIface}
+		machine.Spec.Networks = append(machine.Spec.Networks, found.Spec)
+	}
+
+	return nil
+}
+
+// detachNetworks removes the interfaces of the machine from the networks it
+// has been attached to via parseNetworks.  A network from which the machine
+// cannot be detached is logged and skipped, such that it is still detached
+// from the others.
+func (opts *RunOptions) detachNetworks(ctx context.Context, machine *machineapi.Machine) {
+	for i, attachment := range opts.networks {
+		if i >= len(machine.Spec.Networks) {
+			break
+		}
+
+		if len(machine.Spec.Networks[i].Interfaces) == 0 {
+			continue
+		}
+
+		controller := opts.networkControllers[attachment.Driver]
+
+		// Get the latest version of the network.
+		found, err := controller.Get(ctx, &networkapi.Network{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: attachment.Network,
+			},
+		})
+		if err != nil {
+			log.G(ctx).Errorf("could not get network information for %s: %v", attachment.Network, err)
+			continue
+		}
+
+		// Remove the new network interface
+		for j, iface := range found.Spec.Interfaces {
+			if iface.UID == machine.Spec.Networks[i].Interfaces[0].UID {
+				ret := make([]networkapi.NetworkInterfaceTemplateSpec, 0)
+				ret = append(ret, found.Spec.Interfaces[:j]...)
+				found.Spec.Interfaces = append(ret, found.Spec.Interfaces[j+1:]...)
+				break
+			}
+		}
+
+		if _, err = controller.Update(ctx, found); err != nil {
+			log.G(ctx).Errorf("could not update network %s: %v", attachment.Network, err)
+		}
+	}
+}
+
 // assignName determines the machine instance's name either from a provided
 // argument or randomly generates one.
 func (opts *RunOptions) assignName(ctx context.Context, machine *machineapi.Machine) error {
@@ -294,3 +557,73 @@ func (opts *RunOptions) prepareRootfs(ctx context.Context, machine *machineapi.M
 
 	return nil
 }
+
+// loadSnapshot retrieves the snapshot provided via --from-snapshot and adopts
+// the platform and network of the machine it was taken from.  The hardware of
+// the restored machine must be identical to the machine the snapshot was taken
+// from, such that flags which would alter it cannot be used in conjunction.
+func (opts *RunOptions) loadSnapshot(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("cannot provide arguments when restoring from a snapshot")
+	}
+
+	for _, flag := range []string{
+		"arch",
+		"disable-acceleration",
+		"initrd",
+		"ip",
+		"kernel-arg",
+		"mac",
+		"memory",
+		"network",
+		"port",
+		"rootfs",
+		"volume",
+	} {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("cannot use --%s when restoring from a snapshot", flag)
+		}
+	}
+
+	var err error
+	opts.snapshot, err = snapshot.Get(cmd.Context(), opts.FromSnapshot)
+	if err != nil {
+		return err
+	}
+
+	spec := opts.snapshot.Machine.Spec
+
+	if opts.Platform != "" && opts.Platform != "auto" && mplatform.PlatformByName(opts.Platform) != mplatform.PlatformByName(spec.Platform) {
+		return fmt.Errorf("cannot restore snapshot of %s machine on platform %s", spec.Platform, opts.Platform)
+	}
+
+	opts.Platform = spec.Platform
+	opts.Architecture = spec.Architecture
+
+	// Re-attach the machine to its networks with interfaces identical to the
+	// ones recorded, since the guest retains its configuration.
+	opts.Networks = nil
+	for _, network := range spec.Networks {
+		for _, iface := range network.Interfaces {
+			opts.Networks = append(opts.Networks, networkapi.NetworkAttachment{
+				Driver:    network.Driver,
+				Network:   network.IfName,
+				Interface: iface.Spec,
+			}.String())
+		}
+	}
+
+	return nil
+}
+
+// restoreSnapshot prepares the machine specification from the snapshot
+// provided via --from-snapshot.  Networks are attached separately by
+// parseNetworks.
+func (opts *RunOptions) restoreSnapshot(machine *machineapi.Machine) {
+	machine.Labels = opts.snapshot.Machine.Labels
+	machine.Spec = opts.snapshot.Machine.Spec
+	machine.Spec.Networks = nil
+	machine.Status.KernelPath = opts.snapshot.Machine.Status.KernelPath
+	machine.Status.InitrdPath = opts.snapshot.Machine.Status.InitrdPath
+	machine.Status.SnapshotDir = opts.snapshot.Dir()
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/snapshot"
+)
+
+type CreateOptions struct {
+	Name string `long:"name" short:"n" usage:"Name of the snapshot (default is the machine name and the current time)"`
+	Stop bool   `long:"stop" usage:"Stop the unikernel once the snapshot has been taken"`
+}
+
+// Create a snapshot of a local Unikraft virtual machine.
+func Create(ctx context.Context, opts *CreateOptions, args ...string) error {
+	if opts == nil {
+		opts = &CreateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CreateOptions{}, cobra.Command{
+		Short: "Take a snapshot of a unikernel",
+		Use:   "create [FLAGS] MACHINE",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			Take a snapshot of a unikernel
+
+			The unikernel is paused whilst its state is saved and is resumed
+			afterwards, unless the --stop flag is provided.  The specification of the
+			unikernel is recorded alongside its state such that restoring it recreates
+			identical networking and volumes.`),
+		Example: heredoc.Doc(`
+			Take a snapshot of a running unikernel:
+			$ kraft snapshot create my-machine
+
+			Take a named snapshot of a running unikernel and stop it afterwards:
+			$ kraft snapshot create --name warm --stop my-machine`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CreateOptions) Run(ctx context.Context, args []string) error {
+	controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var machine *machineapi.Machine
+
+	for i := range machines.Items {
+		if args[0] == machines.Items[i].Name || args[0] == string(machines.Items[i].UID) {
+			machine = &machines.Items[i]
+			break
+		}
+	}
+
+	if machine == nil {
+		return fmt.Errorf("machine not found: %s", args[0])
+	}
+
+	snapshotter, err := mplatform.NewMachineSnapshotterV1alpha1(ctx, mplatform.PlatformByName(machine.Spec.Platform))
+	if err != nil {
+		return err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = snapshot.NewName(machine)
+	}
+
+	snap, err := snapshot.New(ctx, name, machine)
+	if err != nil {
+		return err
+	}
+
+	log.G(ctx).
+		WithField("machine", machine.Name).
+		WithField("snapshot", snap.Name).
+		Info("taking snapshot")
+
+	if err := snapshotter.Snapshot(ctx, machine, snap.Dir()); err != nil {
+		if err2 := snapshot.Remove(ctx, snap.Name); err2 != nil {
+			log.G(ctx).Debugf("could not clean up snapshot: %v", err2)
+		}
+
+		return fmt.Errorf("could not take snapshot of %s: %w", machine.Name, err)
+	}
+
+	if err := snap.Save(); err != nil {
+		return err
+	}
+
+	if opts.Stop {
+		if _, err := controller.Stop(ctx, machine); err != nil {
+			return fmt.Errorf("could not stop machine %s: %w", machine.Name, err)
+		}
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, snap.Name)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package list
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/snapshot"
+)
+
+type ListOptions struct {
+	Long   bool   `long:"long" short:"l" usage:"Show more information"`
+	Output string `long:"output" short:"o" usage:"Set output format" default:"table"`
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ListOptions{}, cobra.Command{
+		Short:   "List machine snapshots",
+		Use:     "ls [FLAGS]",
+		Aliases: []string{"list"},
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ListOptions) Run(ctx context.Context, _ []string) error {
+	snapshots, err := snapshot.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = iostreams.G(ctx).StartPager()
+	if err != nil {
+		log.G(ctx).Errorf("error starting pager: %v", err)
+	}
+
+	defer iostreams.G(ctx).StopPager()
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("NAME", cs.Bold)
+	table.AddField("MACHINE", cs.Bold)
+	table.AddField("KERNEL", cs.Bold)
+	table.AddField("CREATED", cs.Bold)
+	table.AddField("SIZE", cs.Bold)
+	if opts.Long {
+		table.AddField("PATH", cs.Bold)
+	}
+	table.AddField("PLAT", cs.Bold)
+	table.EndRow()
+
+	for _, snap := range snapshots {
+		size, err := snap.Size()
+		if err != nil {
+			return err
+		}
+
+		table.AddField(snap.Name, nil)
+		table.AddField(snap.Machine.Name, nil)
+		table.AddField(snap.Machine.Spec.Kernel, nil)
+		table.AddField(humanize.Time(snap.CreatedAt), nil)
+		table.AddField(humanize.IBytes(uint64(size)), nil)
+		if opts.Long {
+			table.AddField(snap.Dir(), nil)
+		}
+		table.AddField(fmt.Sprintf("%s/%s", snap.Machine.Spec.Platform, snap.Machine.Spec.Architecture), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
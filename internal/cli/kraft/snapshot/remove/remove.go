@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package remove
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/snapshot"
+)
+
+type RemoveOptions struct {
+	All bool `long:"all" usage:"Remove all snapshots"`
+}
+
+// Remove one or more machine snapshots.
+func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
+	if opts == nil {
+		opts = &RemoveOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
+		Short:   "Remove one or more snapshots",
+		Use:     "rm [FLAGS] SNAPSHOT [SNAPSHOT [...]]",
+		Aliases: []string{"remove", "delete", "del"},
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RemoveOptions) Pre(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && !opts.All {
+		return fmt.Errorf("please supply a snapshot name or use the --all flag")
+	}
+
+	return nil
+}
+
+func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
+	names := args
+
+	if opts.All {
+		snapshots, err := snapshot.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		names = nil
+		for _, snap := range snapshots {
+			names = append(names, snap.Name)
+		}
+	}
+
+	for _, name := range names {
+		if err := snapshot.Remove(ctx, name); err != nil {
+			log.G(ctx).Errorf("could not remove snapshot %s: %v", name, err)
+		} else {
+			fmt.Fprintln(iostreams.G(ctx).Out, name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package snapshot
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/snapshot/create"
+	"kraftkit.sh/internal/cli/kraft/snapshot/list"
+	"kraftkit.sh/internal/cli/kraft/snapshot/remove"
+)
+
+type SnapshotOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SnapshotOptions{}, cobra.Command{
+		Short: "Manage machine snapshots",
+		Use:   "snapshot SUBCOMMAND",
+		Long: heredoc.Doc(`
+			Save the complete state of running unikernels to disk and manage the
+			resulting snapshots.  A snapshot is restored with 'kraft run --from-snapshot'.`),
+		Example: heredoc.Doc(`
+			Take a snapshot of a running unikernel:
+			$ kraft snapshot create my-machine
+
+			List all snapshots:
+			$ kraft snapshot ls
+
+			Restore a unikernel from a snapshot:
+			$ kraft run --from-snapshot my-machine-20230101120000`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(list.NewCmd())
+	cmd.AddCommand(remove.NewCmd())
+
+	return cmd
+}
+
+func (opts *SnapshotOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type StatsOptions struct {
+	NoStream bool   `long:"no-stream" usage:"Output the resource usage once instead of streaming it"`
+	Output   string `long:"output" short:"o" usage:"Set output format" default:"table"`
+	platform string
+}
+
+// sampleInterval is the time between two consecutive samples of the resource
+// usage of the machines, over which their CPU usage is averaged.
+const sampleInterval = time.Second
+
+// Stats streams the resource usage of local Unikraft virtual machines.
+func Stats(ctx context.Context, opts *StatsOptions, args ...string) error {
+	if opts == nil {
+		opts = &StatsOptions{
+			Output: "table",
+		}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&StatsOptions{}, cobra.Command{
+		Short: "Display the resource usage of unikernels",
+		Use:   "stats [FLAGS] [MACHINE...]",
+		Long: heredoc.Doc(`
+			Display a live stream of the resource usage of the VMM of each running
+			unikernel, or of the provided unikernels.  The usage comprises the CPU
+			time, the resident memory and the block IO of the VMM as well as the
+			traffic received (RX) and transmitted (TX) by the unikernel on its
+			networks.`),
+		Example: heredoc.Doc(`
+			Stream the resource usage of all running unikernels:
+			$ kraft stats
+
+			Output the resource usage of the unikernel named my-machine once as JSON:
+			$ kraft stats --no-stream -o json my-machine`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "run",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.  Set to 'auto' to detect the guest's platform and 'host' to use the host platform.",
+	)
+
+	return cmd
+}
+
+func (opts *StatsOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.platform = cmd.Flag("plat").Value.String()
+	return nil
+}
+
+func (opts *StatsOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.platform == "" || opts.platform == "auto" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.platform == "host" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var selected []machineapi.Machine
+
+	if len(args) == 0 {
+		for _, machine := range machines.Items {
+			if isActive(&machine) {
+				selected = append(selected, machine)
+			}
+		}
+	} else {
+	args:
+		for _, arg := range args {
+			for _, machine := range machines.Items {
+				if arg != machine.Name && arg != string(machine.UID) {
+					continue
+				}
+
+				if !isActive(&machine) {
+					return fmt.Errorf("cannot display usage of %s: instance is %s", machine.Name, machine.Status.State)
+				}
+
+				selected = append(selected, machine)
+				continue args
+			}
+
+			return fmt.Errorf("could not find instance %s", arg)
+		}
+	}
+
+	// The CPU usage is the difference of the CPU time of two samples, such that
+	// the first sample is only taken as a reference.
+	prev := sampleAll(ctx, selected)
+	tty := iostreams.G(ctx).IsStdoutTTY()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sampleInterval):
+		}
+
+		next := sampleAll(ctx, selected)
+
+		// Redraw the table in place whilst streaming to a terminal.
+		if !opts.NoStream && tty && opts.Output == string(tableprinter.OutputFormatTable) {
+			fmt.Fprint(iostreams.G(ctx).Out, "\033[2J\033[H")
+		}
+
+		if err := opts.render(ctx, selected, prev, next); err != nil {
+			return err
+		}
+
+		if opts.NoStream {
+			return nil
+		}
+
+		prev = next
+	}
+}
+
+// isActive returns whether the VMM of the provided machine is running.
+func isActive(machine *machineapi.Machine) bool {
+	switch machine.Status.State {
+	case machineapi.MachineStateRunning, machineapi.MachineStatePaused, machineapi.MachineStateSuspended:
+		return machine.Status.Pid > 0
+	}
+
+	return false
+}
+
+// render outputs the resource usage of each machine between the two provided
+// samples.  Machines whose VMM has exited in the meantime are omitted.
+func (opts *StatsOptions) render(ctx context.Context, machines []machineapi.Machine, prev, next map[int32]*usage) error {
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Sizes are only humanized in tables such that JSON and YAML can be further
+	// processed.
+	size := func(b uint64) string {
+		if opts.Output == string(tableprinter.OutputFormatTable) {
+			return humanize.IBytes(b)
+		}
+
+		return strconv.FormatUint(b, 10)
+	}
+
+	// Header row
+	table.AddField("MACHINE ID", cs.Bold)
+	table.AddField("NAME", cs.Bold)
+	table.AddField("CPU %", cs.Bold)
+	table.AddField("MEM USAGE", cs.Bold)
+	table.AddField("MEM %", cs.Bold)
+	table.AddField("NET RX", cs.Bold)
+	table.AddField("NET TX", cs.Bold)
+	table.AddField("BLOCK READ", cs.Bold)
+	table.AddField("BLOCK WRITE", cs.Bold)
+	table.EndRow()
+
+	for _, machine := range machines {
+		cur, ok := next[machine.Status.Pid]
+		if !ok {
+			continue
+		}
+
+		cpu := "--"
+		if before, ok := prev[machine.Status.Pid]; ok {
+			cpu = fmt.Sprintf("%.2f", cur.cpuPercent(before))
+		}
+
+		rx, tx := "--", "--"
+		if ifaces := interfaces(&machine); len(ifaces) > 0 {
+			recv, sent := cur.network(ifaces)
+			rx, tx = size(recv), size(sent)
+		}
+
+		table.AddField(string(machine.UID), nil)
+		table.AddField(machine.Name, nil)
+		table.AddField(cpu, nil)
+		table.AddField(size(cur.rss), nil)
+		table.AddField(fmt.Sprintf("%.2f", cur.memPercent), nil)
+		table.AddField(rx, nil)
+		table.AddField(tx, nil)
+		table.AddField(size(cur.readBytes), nil)
+		table.AddField(size(cur.writeBytes), nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// interfaces returns the names of the host interfaces of the networks the
+// provided machine is attached to.
+func interfaces(machine *machineapi.Machine) []string {
+	var ifaces []string
+
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			if iface.Spec.IfName != "" {
+				ifaces = append(ifaces, iface.Spec.IfName)
+			}
+		}
+	}
+
+	return ifaces
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	gonet "github.com/shirou/gopsutil/v3/net"
+	goprocess "github.com/shirou/gopsutil/v3/process"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// usage is a sample of the resource usage of the VMM of a machine.
+type usage struct {
+	// sampledAt represents when the sample was taken.
+	sampledAt time.Time
+
+	// cpuTime is the total user and system CPU time of the VMM in seconds.
+	cpuTime float64
+
+	// rss is the resident set size of the VMM in bytes.
+	rss uint64
+
+	// memPercent is the percentage of the memory of the host used by the VMM.
+	memPercent float32
+
+	// readBytes and writeBytes are the number of bytes read and written by the
+	// VMM from and to block devices.
+	readBytes  uint64
+	writeBytes uint64
+
+	// counters are the counters of the network interfaces of the host at the
+	// time of the sample, indexed by their name.
+	counters map[string]gonet.IOCountersStat
+}
+
+// sampleAll samples the resource usage of the VMM of each of the provided
+// machines, indexed by its PID.  Machines whose VMM cannot be sampled, e.g.
+// because it has exited, are omitted.
+func sampleAll(ctx context.Context, machines []machineapi.Machine) map[int32]*usage {
+	samples := make(map[int32]*usage, len(machines))
+
+	counters := make(map[string]gonet.IOCountersStat)
+	if stats, err := gonet.IOCountersWithContext(ctx, true); err == nil {
+		for _, stat := range stats {
+			counters[stat.Name] = stat
+		}
+	} else {
+		log.G(ctx).Debugf("could not read network counters: %v", err)
+	}
+
+	for _, machine := range machines {
+		sample, err := sampleProcess(ctx, machine.Status.Pid)
+		if err != nil {
+			log.G(ctx).Debugf("could not sample %s: %v", machine.Name, err)
+			continue
+		}
+
+		sample.counters = counters
+		samples[machine.Status.Pid] = sample
+	}
+
+	return samples
+}
+
+// sampleProcess samples the resource usage of the process with the provided
+// PID.
+func sampleProcess(ctx context.Context, pid int32) (*usage, error) {
+	process, err := goprocess.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	times, err := process.TimesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := process.MemoryInfoWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := usage{
+		sampledAt: time.Now(),
+		cpuTime:   times.User + times.System,
+		rss:       info.RSS,
+	}
+
+	if vmem, err := mem.VirtualMemoryWithContext(ctx); err == nil && vmem.Total > 0 {
+		sample.memPercent = float32(float64(info.RSS) / float64(vmem.Total) * 100)
+	}
+
+	// Reading the IO counters of a process may require privileges which the
+	// other statistics do not.
+	if io, err := process.IOCountersWithContext(ctx); err == nil {
+		sample.readBytes = io.ReadBytes
+		sample.writeBytes = io.WriteBytes
+	}
+
+	return &sample, nil
+}
+
+// cpuPercent returns the percentage of a single CPU used by the VMM since the
+// provided previous sample, which exceeds 100 if multiple CPUs are used.
+func (u *usage) cpuPercent(prev *usage) float64 {
+	elapsed := u.sampledAt.Sub(prev.sampledAt).Seconds()
+	if elapsed <= 0 || u.cpuTime < prev.cpuTime {
+		return 0
+	}
+
+	return (u.cpuTime - prev.cpuTime) / elapsed * 100
+}
+
+// network returns the number of bytes received and transmitted by the machine
+// on the provided host interfaces.  As these are the host side of the
+// interfaces of the machine, what they transmit is received by the machine and
+// vice versa.
+func (u *usage) network(ifaces []string) (rx, tx uint64) {
+	for _, iface := range ifaces {
+		stat, ok := u.counters[iface]
+		if !ok {
+			continue
+		}
+
+		rx += stat.BytesSent
+		tx += stat.BytesRecv
+	}
+
+	return rx, tx
+}
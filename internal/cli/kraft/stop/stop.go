@@ -19,7 +19,8 @@ import (
 )
 
 type StopOptions struct {
-	All      bool `long:"all" usage:"Remove all machines"`
+	All      bool     `long:"all" usage:"Remove all machines"`
+	Filter   []string `long:"filter" short:"f" usage:"Only stop machines matching label=KEY[=VALUE], state=STATE, plat=PLAT or arch=ARCH"`
 	platform string
 }
 
@@ -38,6 +39,9 @@ func NewCmd() *cobra.Command {
 		Use:   "stop [FLAGS] MACHINE [MACHINE [...]]",
 		Long: heredoc.Doc(`
 			Stop one or more running unikernels`),
+		Example: heredoc.Doc(`
+			Stop all unikernels labelled app=nginx:
+			$ kraft stop --filter label=app=nginx`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "run",
 		},
@@ -60,8 +64,8 @@ func NewCmd() *cobra.Command {
 }
 
 func (opts *StopOptions) Pre(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 && !opts.All {
-		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 {
+		return fmt.Errorf("please supply a machine ID or name or use the --all or --filter flag")
 	}
 
 	opts.platform = cmd.Flag("plat").Value.String()
@@ -69,11 +73,14 @@ func (opts *StopOptions) Pre(cmd *cobra.Command, args []string) error {
 }
 
 func (opts *StopOptions) Run(ctx context.Context, args []string) error {
-	if len(args) == 0 && !opts.All {
-		return fmt.Errorf("please supply a machine ID or name or use the --all flag")
+	if len(args) == 0 && !opts.All && len(opts.Filter) == 0 {
+		return fmt.Errorf("please supply a machine ID or name or use the --all or --filter flag")
 	}
 
-	var err error
+	filter, err := machineapi.ParseMachineFilter(opts.Filter...)
+	if err != nil {
+		return err
+	}
 
 	platform := mplatform.PlatformUnknown
 	var controller machineapi.MachineService
@@ -113,13 +120,20 @@ func (opts *StopOptions) Run(ctx context.Context, args []string) error {
 	var stop []machineapi.Machine
 
 	for _, machine := range machines.Items {
-		if opts.All {
-			stop = append(stop, machine)
+		if !filter.Matches(&machine) {
 			continue
 		}
 
-		if args[0] == machine.Name || args[0] == string(machine.UID) {
+		if opts.All || len(args) == 0 {
 			stop = append(stop, machine)
+			continue
+		}
+
+		for _, arg := range args {
+			if arg == machine.Name || arg == string(machine.UID) {
+				stop = append(stop, machine)
+				break
+			}
 		}
 	}
 
@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package coredump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+type CoredumpOptions struct {
+	Dump     string `long:"dump" short:"d" usage:"Name of the crash dump to export (default is the most recent)"`
+	Export   string `long:"export" short:"e" usage:"Export the crash dump together with the kernel into the provided directory"`
+	Output   string `long:"output" short:"o" usage:"Set output format" default:"table"`
+	platform string
+}
+
+// Coredump lists or exports the crash dumps of a local Unikraft virtual
+// machine.
+func Coredump(ctx context.Context, opts *CoredumpOptions, args ...string) error {
+	if opts == nil {
+		opts = &CoredumpOptions{
+			Output: "table",
+		}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CoredumpOptions{}, cobra.Command{
+		Short: "List and export the crash dumps of a unikernel",
+		Use:   "coredump [FLAGS] MACHINE",
+		Args:  cobra.ExactArgs(1),
+		Long: heredoc.Doc(`
+			List and export the crash dumps of a unikernel which has been run with
+			--crash-dump.  The memory of such a unikernel is dumped as an ELF core
+			into its state directory whenever it panics.  An exported crash dump is
+			accompanied by the symbolic kernel of the unikernel such that it can be
+			loaded into GDB.`),
+		Example: heredoc.Doc(`
+			List the crash dumps of the unikernel named my-machine:
+			$ kraft x coredump my-machine
+
+			Export the most recent crash dump and the kernel into the current directory:
+			$ kraft x coredump --export . my-machine
+
+			Load the exported crash dump into GDB:
+			$ gdb my-machine.dbg 20230101120000.core`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "experimental",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().VarP(
+		cmdfactory.NewEnumFlag[mplatform.Platform](
+			mplatform.Platforms(),
+			mplatform.Platform("auto"),
+		),
+		"plat",
+		"p",
+		"Set the platform virtual machine monitor driver.  Set to 'auto' to detect the guest's platform and 'host' to use the host platform.",
+	)
+
+	return cmd
+}
+
+func (opts *CoredumpOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.platform = cmd.Flag("plat").Value.String()
+	return nil
+}
+
+func (opts *CoredumpOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	platform := mplatform.PlatformUnknown
+	var controller machineapi.MachineService
+
+	if opts.platform == "" || opts.platform == "auto" {
+		controller, err = mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	} else {
+		if opts.platform == "host" {
+			platform, _, err = mplatform.Detect(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			var ok bool
+			platform, ok = mplatform.PlatformsByName()[opts.platform]
+			if !ok {
+				return fmt.Errorf("unknown platform driver: %s", opts.platform)
+			}
+		}
+
+		strategy, ok := mplatform.Strategies()[platform]
+		if !ok {
+			return fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+		}
+
+		controller, err = strategy.NewMachineV1alpha1(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	machines, err := controller.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return err
+	}
+
+	var machine *machineapi.Machine
+
+	for _, candidate := range machines.Items {
+		if args[0] == candidate.Name || string(candidate.UID) == args[0] {
+			machine = &candidate
+			break
+		}
+	}
+
+	if machine == nil {
+		return fmt.Errorf("could not find instance %s", args[0])
+	}
+
+	dumps, err := crashDumps(machine)
+	if err != nil {
+		return err
+	}
+
+	if opts.Export != "" {
+		return opts.export(ctx, machine, dumps)
+	}
+
+	err = iostreams.G(ctx).StartPager()
+	if err != nil {
+		log.G(ctx).Errorf("error starting pager: %v", err)
+	}
+
+	defer iostreams.G(ctx).StopPager()
+
+	cs := iostreams.G(ctx).ColorScheme()
+
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+		tableprinter.WithOutputFormatFromString(opts.Output),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Header row
+	table.AddField("DUMP", cs.Bold)
+	table.AddField("CREATED", cs.Bold)
+	table.AddField("SIZE", cs.Bold)
+	table.AddField("PATH", cs.Bold)
+	table.EndRow()
+
+	for _, dump := range dumps {
+		table.AddField(dump.name, nil)
+		table.AddField(humanize.Time(dump.info.ModTime()), nil)
+		table.AddField(humanize.IBytes(uint64(dump.info.Size())), nil)
+		table.AddField(dump.path, nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// crashDump is an ELF core of the guest of a machine.
+type crashDump struct {
+	name string
+	path string
+	info os.FileInfo
+}
+
+// crashDumps returns the crash dumps of the provided machine from the oldest to
+// the most recent.
+func crashDumps(machine *machineapi.Machine) ([]crashDump, error) {
+	dir := filepath.Join(machine.Status.StateDir, machineapi.CrashDumpDir)
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read crash dumps: %w", err)
+	}
+
+	var dumps []crashDump
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), machineapi.CrashDumpExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		dumps = append(dumps, crashDump{
+			name: name,
+			path: filepath.Join(dir, entry.Name()),
+			info: info,
+		})
+	}
+
+	sort.SliceStable(dumps, func(i, j int) bool {
+		return dumps[i].info.ModTime().Before(dumps[j].info.ModTime())
+	})
+
+	return dumps, nil
+}
+
+// export copies the selected crash dump and the kernel of the machine into the
+// export directory.
+func (opts *CoredumpOptions) export(ctx context.Context, machine *machineapi.Machine, dumps []crashDump) error {
+	if len(dumps) == 0 {
+		return fmt.Errorf("instance %s has no crash dumps", machine.Name)
+	}
+
+	dump := dumps[len(dumps)-1]
+
+	if opts.Dump != "" {
+		found := false
+		for _, candidate := range dumps {
+			if candidate.name == strings.TrimSuffix(opts.Dump, machineapi.CrashDumpExt) {
+				dump = candidate
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("instance %s has no crash dump %s", machine.Name, opts.Dump)
+		}
+	}
+
+	if err := os.MkdirAll(opts.Export, 0o755); err != nil {
+		return err
+	}
+
+	core := filepath.Join(opts.Export, dump.name+machineapi.CrashDumpExt)
+	if err := copyFile(dump.path, core); err != nil {
+		return fmt.Errorf("could not export crash dump: %w", err)
+	}
+
+	kernel := filepath.Join(opts.Export, machine.Name+".dbg")
+	if err := copyFile(machine.Status.KernelPath, kernel); err != nil {
+		return fmt.Errorf("could not export kernel: %w", err)
+	}
+
+	log.G(ctx).Infof("exported crash dump %s of %s", dump.name, machine.Name)
+	log.G(ctx).Infof("load it into gdb with: gdb %s %s", kernel, core)
+
+	return nil
+}
+
+// copyFile copies the contents of the source file to the destination file.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
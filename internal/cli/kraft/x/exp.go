@@ -12,6 +12,7 @@ import (
 
 	"kraftkit.sh/cmdfactory"
 
+	"kraftkit.sh/internal/cli/kraft/x/coredump"
 	"kraftkit.sh/internal/cli/kraft/x/probe"
 )
 
@@ -30,6 +31,7 @@ func NewCmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.AddCommand(coredump.NewCmd())
 	cmd.AddCommand(probe.NewCmd())
 
 	return cmd
@@ -0,0 +1,468 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dockerapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/cli/kraft/remove"
+	"kraftkit.sh/internal/cli/kraft/run"
+	"kraftkit.sh/log"
+	machinename "kraftkit.sh/machine/name"
+)
+
+// errNotFound is returned when no machine matches the provided identifier.
+var errNotFound = errors.New("no such container")
+
+// lookup returns the machine which is identified by the provided name, UID or
+// unique prefix of its UID.
+func (server *Server) lookup(ctx context.Context, id string) (*machineapi.Machine, error) {
+	machines, err := server.machines.List(ctx, &machineapi.MachineList{})
+	if err != nil {
+		return nil, err
+	}
+
+	id = strings.TrimPrefix(id, "/")
+
+	var found *machineapi.Machine
+
+	for i, machine := range machines.Items {
+		if machine.Name == id || string(machine.UID) == id {
+			return &machines.Items[i], nil
+		}
+
+		if strings.HasPrefix(string(machine.UID), id) {
+			if found != nil {
+				return nil, fmt.Errorf("multiple containers match %s", id)
+			}
+
+			found = &machines.Items[i]
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", errNotFound, id)
+	}
+
+	return found, nil
+}
+
+// writeLookupError responds with the error of a failed lookup.
+func writeLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNotFound) {
+		writeError(w, http.StatusNotFound, err)
+	} else {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// createContainer handles POST /containers/create by creating, but not
+// starting, a machine from the provided image.
+func (server *Server) createContainer(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("could not decode request: %w", err))
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Query().Get("name"), "/")
+	if name == "" {
+		name = machinename.NewRandomMachineName(0)
+	} else if _, err := server.lookup(r.Context(), name); err == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("the container name %s is already in use", name))
+		return
+	}
+
+	opts, args, warnings, err := runOptions(name, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, done, err := quiet(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	defer done()
+
+	if err := run.Run(ctx, opts, args...); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("could not create container: %w", err))
+		return
+	}
+
+	machine, err := server.lookup(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.G(server.ctx).
+		WithField("machine", machine.Name).
+		Info("created")
+
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	writeJSON(w, http.StatusCreated, container.CreateResponse{
+		ID:       string(machine.UID),
+		Warnings: warnings,
+	})
+}
+
+// listContainers handles GET /containers/json.  Only running machines are
+// listed unless all machines are requested.
+func (server *Server) listContainers(w http.ResponseWriter, r *http.Request) {
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	machines, err := server.machines.List(r.Context(), &machineapi.MachineList{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now()
+	containers := []types.Container{}
+
+	for _, machine := range machines.Items {
+		if !all && machine.Status.State != machineapi.MachineStateRunning {
+			continue
+		}
+
+		containers = append(containers, toContainer(&machine, now))
+	}
+
+	writeJSON(w, http.StatusOK, containers)
+}
+
+// inspectContainer handles GET /containers/{id}/json.
+func (server *Server) inspectContainer(w http.ResponseWriter, r *http.Request, id string) {
+	machine, err := server.lookup(r.Context(), id)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toContainerJSON(machine))
+}
+
+// startContainer handles POST /containers/{id}/start.
+func (server *Server) startContainer(w http.ResponseWriter, r *http.Request, id string) {
+	machine, err := server.lookup(r.Context(), id)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	if machine.Status.State == machineapi.MachineStateRunning {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if _, err := server.machines.Start(r.Context(), machine); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("could not start container: %w", err))
+		return
+	}
+
+	log.G(server.ctx).
+		WithField("machine", machine.Name).
+		Info("started")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stopContainer handles POST /containers/{id}/stop.
+func (server *Server) stopContainer(w http.ResponseWriter, r *http.Request, id string) {
+	machine, err := server.lookup(r.Context(), id)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	if machine.Status.State != machineapi.MachineStateRunning && machine.Status.State != machineapi.MachineStatePaused {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if _, err := server.machines.Stop(r.Context(), machine); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("could not stop container: %w", err))
+		return
+	}
+
+	log.G(server.ctx).
+		WithField("machine", machine.Name).
+		Info("stopped")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteContainer handles DELETE /containers/{id}.  Running machines are only
+// removed when forced.
+func (server *Server) deleteContainer(w http.ResponseWriter, r *http.Request, id string) {
+	machine, err := server.lookup(r.Context(), id)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	if !force && machine.Status.State == machineapi.MachineStateRunning {
+		writeError(w, http.StatusConflict, fmt.Errorf("cannot remove running container %s: stop the container before removing or force remove", machine.Name))
+		return
+	}
+
+	ctx, done, err := quiet(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	defer done()
+
+	if err := remove.Remove(ctx, &remove.RemoveOptions{Platform: "auto"}, machine.Name); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("could not remove container: %w", err))
+		return
+	}
+
+	log.G(server.ctx).
+		WithField("machine", machine.Name).
+		Info("removed")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// waitContainer handles POST /containers/{id}/wait by responding once the
+// machine is no longer running.  With the "next-exit" condition, a machine
+// which is not running is waited for until it has been started and exited.
+func (server *Server) waitContainer(w http.ResponseWriter, r *http.Request, id string) {
+	machine, err := server.lookup(r.Context(), id)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	condition := container.WaitCondition(r.URL.Query().Get("condition"))
+
+	// Respond with the headers straight away such that clients, e.g. `docker
+	// run`, can start the container whilst waiting for it.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	if condition == container.WaitConditionNextExit || isRunning(machine) {
+		machine, err = server.waitForExit(r.Context(), machine, condition == container.WaitConditionNextExit)
+	}
+
+	resp := container.WaitResponse{}
+	if err != nil {
+		resp.Error = &container.WaitExitError{Message: err.Error()}
+	} else {
+		resp.StatusCode = int64(machine.Status.ExitCode)
+	}
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// waitForExit watches the provided machine until it is no longer running.  If
+// started is set, the machine must have been seen running before.
+func (server *Server) waitForExit(ctx context.Context, machine *machineapi.Machine, started bool) (*machineapi.Machine, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs, err := server.machines.Watch(ctx, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	running := !started
+
+	for {
+		select {
+		case update, ok := <-events:
+			if !ok {
+				return nil, fmt.Errorf("stopped watching container")
+			}
+
+			if isRunning(update) {
+				running = true
+			} else if running && update.Status.State != machineapi.MachineStateCreated {
+				return update, nil
+			}
+
+		case err, ok := <-errs:
+			if ok {
+				return nil, err
+			}
+
+			errs = nil
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// isRunning returns whether the provided machine is running or about to.
+func isRunning(machine *machineapi.Machine) bool {
+	switch machine.Status.State {
+	case machineapi.MachineStateRunning, machineapi.MachineStatePaused, machineapi.MachineStateRestarting:
+		return true
+	}
+
+	return false
+}
+
+// containerLogs handles GET /containers/{id}/logs.  The log of a machine is
+// its serial console, such that it is written as the stdout stream.
+func (server *Server) containerLogs(w http.ResponseWriter, r *http.Request, id string) {
+	machine, err := server.lookup(r.Context(), id)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	follow, _ := strconv.ParseBool(query.Get("follow"))
+	stdout, _ := strconv.ParseBool(query.Get("stdout"))
+
+	w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+	w.WriteHeader(http.StatusOK)
+
+	if !stdout {
+		return
+	}
+
+	out := stdcopy.NewStdWriter(flushWriter{w}, stdcopy.Stdout)
+
+	if follow && machine.Status.State == machineapi.MachineStateRunning {
+		server.followLogs(r.Context(), out, machine)
+		return
+	}
+
+	if err := copyLogs(out, machine.Status.LogFile, query.Get("tail")); err != nil {
+		log.G(server.ctx).
+			WithField("machine", machine.Name).
+			Errorf("could not read logs: %v", err)
+	}
+}
+
+// followLogs writes the log of the provided machine until it exits or the
+// context is cancelled.
+func (server *Server) followLogs(ctx context.Context, out io.Writer, machine *machineapi.Machine) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		defer cancel()
+
+		if _, err := server.waitForExit(ctx, machine, false); err != nil && ctx.Err() == nil {
+			log.G(server.ctx).
+				WithField("machine", machine.Name).
+				Errorf("could not listen for machine updates: %v", err)
+		}
+	}()
+
+	logs, errs, err := server.machines.Logs(ctx, machine)
+	if err != nil {
+		log.G(server.ctx).
+			WithField("machine", machine.Name).
+			Errorf("could not listen for machine logs: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case line, ok := <-logs:
+			if !ok {
+				return
+			}
+
+			if _, err := io.WriteString(out, line); err != nil {
+				return
+			}
+
+		case err, ok := <-errs:
+			if ok && !errors.Is(err, io.EOF) {
+				log.G(server.ctx).
+					WithField("machine", machine.Name).
+					Errorf("received log error: %v", err)
+			}
+
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// copyLogs writes the provided log file, or only its last lines if tail is a
+// number.
+func copyLogs(out io.Writer, logFile, tail string) error {
+	f, err := os.Open(logFile)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	n, err := strconv.Atoi(tail)
+	if err != nil || n < 0 {
+		_, err := io.Copy(out, f)
+		return err
+	}
+
+	var lines []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text()+"\n")
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(out, strings.Join(lines, ""))
+	return err
+}
+
+// flushWriter flushes each write such that streamed logs are received as soon
+// as they are written.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+// Write implements io.Writer
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return n, err
+}
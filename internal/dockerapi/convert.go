@@ -0,0 +1,389 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dockerapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	corev1 "k8s.io/api/core/v1"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/internal/cli/kraft/run"
+)
+
+// createRequest is the body of POST /containers/create.
+type createRequest struct {
+	*container.Config
+	HostConfig       *container.HostConfig
+	NetworkingConfig *network.NetworkingConfig
+}
+
+// runOptions converts the provided request to create a container into the
+// options and arguments of `kraft run` which create the equivalent machine.
+// Settings which have no equivalent are returned as warnings.
+func runOptions(name string, req *createRequest) (*run.RunOptions, []string, []string, error) {
+	if req.Config == nil || req.Image == "" {
+		return nil, nil, nil, fmt.Errorf("no image specified")
+	}
+
+	var warnings, networks []string
+
+	opts := &run.RunOptions{
+		Detach:  true,
+		Name:    name,
+		NoStart: true,
+	}
+
+	for _, key := range sortedKeys(req.Labels) {
+		opts.Labels = append(opts.Labels, key+"="+req.Labels[key])
+	}
+
+	if len(req.Env) > 0 {
+		warnings = append(warnings, "environment variables are not supported and have been ignored")
+	}
+
+	if len(req.Entrypoint) > 0 {
+		warnings = append(warnings, "the entrypoint of a unikernel cannot be changed and has been ignored")
+	}
+
+	if hc := req.HostConfig; hc != nil {
+		if hc.Memory > 0 {
+			opts.Memory = strconv.FormatInt(hc.Memory, 10)
+		}
+
+		if hc.NanoCPUs > 0 {
+			opts.CPULimit = fmt.Sprintf("%dm", hc.NanoCPUs/1e6)
+		}
+
+		if hc.RestartPolicy.Name != "" {
+			opts.Restart = hc.RestartPolicy.Name
+			if hc.RestartPolicy.MaximumRetryCount > 0 {
+				opts.Restart = fmt.Sprintf("%s:%d", opts.Restart, hc.RestartPolicy.MaximumRetryCount)
+			}
+		}
+
+		if hc.AutoRemove {
+			warnings = append(warnings, "automatically removing a unikernel when it exits is not supported and has been ignored")
+		}
+
+		opts.Volumes = append(opts.Volumes, hc.Binds...)
+
+		ports := make([]string, 0, len(hc.PortBindings))
+		for port := range hc.PortBindings {
+			ports = append(ports, string(port))
+		}
+
+		sort.Strings(ports)
+
+		for _, port := range ports {
+			bindings := hc.PortBindings[nat.Port(port)]
+			if len(bindings) == 0 {
+				bindings = []nat.PortBinding{{}}
+			}
+
+			for _, binding := range bindings {
+				opts.Ports = append(opts.Ports, portFlag(nat.Port(port), binding))
+			}
+		}
+
+		switch mode := string(hc.NetworkMode); mode {
+		case "host":
+			warnings = append(warnings, "the host network is not supported and has been ignored")
+		default:
+			networks = append(networks, mode)
+		}
+	}
+
+	var endpointsConfig map[string]*network.EndpointSettings
+	if req.NetworkingConfig != nil {
+		endpointsConfig = req.NetworkingConfig.EndpointsConfig
+		for _, name := range sortedKeys(endpointsConfig) {
+			networks = append(networks, name)
+		}
+	}
+
+	seen := map[string]bool{}
+
+	for _, name := range networks {
+		// The default network of Docker has no equivalent, such that a unikernel
+		// is only attached to networks which have been created with `kraft net
+		// create`.
+		if seen[name] || name == "" || name == "default" || name == "bridge" || name == "none" || name == "host" {
+			continue
+		}
+
+		seen[name] = true
+
+		attachment := networkapi.NetworkAttachment{
+			Driver:  "bridge",
+			Network: name,
+		}
+
+		if endpoint := endpointsConfig[name]; endpoint != nil {
+			attachment.Interface.MacAddress = endpoint.MacAddress
+			if endpoint.IPAMConfig != nil {
+				attachment.Interface.IP = endpoint.IPAMConfig.IPv4Address
+				attachment.Interface.IP6 = endpoint.IPAMConfig.IPv6Address
+			}
+		}
+
+		opts.Networks = append(opts.Networks, attachment.String())
+	}
+
+	return opts, append([]string{req.Image}, req.Cmd...), warnings, nil
+}
+
+// portFlag returns the provided port binding in the syntax which is accepted
+// by `kraft run -p`.  The machine port is published on the same port of the
+// host unless a host port is provided.
+func portFlag(port nat.Port, binding nat.PortBinding) string {
+	hostPort := binding.HostPort
+	if hostPort == "" {
+		hostPort = port.Port()
+	}
+
+	ret := fmt.Sprintf("%s:%s/%s", hostPort, port.Port(), port.Proto())
+	if binding.HostIP != "" {
+		ret = binding.HostIP + ":" + ret
+	}
+
+	return ret
+}
+
+// containerState returns the state of a container which is equivalent to the
+// provided state of a machine.
+func containerState(state machineapi.MachineState) string {
+	switch state {
+	case machineapi.MachineStateCreated:
+		return "created"
+	case machineapi.MachineStateRunning:
+		return "running"
+	case machineapi.MachineStatePaused, machineapi.MachineStateSuspended:
+		return "paused"
+	case machineapi.MachineStateRestarting:
+		return "restarting"
+	case machineapi.MachineStateExited, machineapi.MachineStateFailed, machineapi.MachineStateErrored:
+		return "exited"
+	}
+
+	return "dead"
+}
+
+// containerStatus returns the human-readable status of the provided machine
+// as it is shown by `docker ps`, e.g. "Up 5 minutes".
+func containerStatus(machine *machineapi.Machine, now time.Time) string {
+	switch containerState(machine.Status.State) {
+	case "created":
+		return "Created"
+	case "running":
+		return "Up " + units.HumanDuration(now.Sub(machine.Status.StartedAt))
+	case "paused":
+		return "Up " + units.HumanDuration(now.Sub(machine.Status.StartedAt)) + " (Paused)"
+	case "restarting":
+		return fmt.Sprintf("Restarting (%d) %s ago", machine.Status.ExitCode, units.HumanDuration(now.Sub(machine.Status.ExitedAt)))
+	case "exited":
+		return fmt.Sprintf("Exited (%d) %s ago", machine.Status.ExitCode, units.HumanDuration(now.Sub(machine.Status.ExitedAt)))
+	}
+
+	return "Dead"
+}
+
+// image returns the image of the provided machine, i.e. its kernel without the
+// format of its package.
+func image(machine *machineapi.Machine) string {
+	if _, ref, ok := strings.Cut(machine.Spec.Kernel, "://"); ok {
+		return ref
+	}
+
+	return machine.Spec.Kernel
+}
+
+// ports returns the published ports of the provided machine.
+func ports(machine *machineapi.Machine) []types.Port {
+	ret := make([]types.Port, 0, len(machine.Spec.Ports))
+
+	for _, port := range machine.Spec.Ports {
+		ret = append(ret, types.Port{
+			IP:          port.HostIP,
+			PrivatePort: uint16(port.MachinePort),
+			PublicPort:  uint16(port.HostPort),
+			Type:        protocol(port.Protocol),
+		})
+	}
+
+	return ret
+}
+
+// portMap returns the published ports of the provided machine indexed by
+// their machine port.
+func portMap(machine *machineapi.Machine) nat.PortMap {
+	ret := nat.PortMap{}
+
+	for _, port := range machine.Spec.Ports {
+		key := nat.Port(fmt.Sprintf("%d/%s", port.MachinePort, protocol(port.Protocol)))
+		ret[key] = append(ret[key], nat.PortBinding{
+			HostIP:   port.HostIP,
+			HostPort: strconv.Itoa(int(port.HostPort)),
+		})
+	}
+
+	return ret
+}
+
+// protocol returns the lower-case protocol of a port, which defaults to TCP.
+func protocol(p corev1.Protocol) string {
+	if p == "" {
+		return "tcp"
+	}
+
+	return strings.ToLower(string(p))
+}
+
+// endpoints returns the settings of each network which the provided machine
+// is attached to.
+func endpoints(machine *machineapi.Machine) map[string]*network.EndpointSettings {
+	ret := map[string]*network.EndpointSettings{}
+
+	for _, net := range machine.Spec.Networks {
+		settings := &network.EndpointSettings{
+			NetworkID: net.IfName,
+		}
+
+		if len(net.Interfaces) > 0 {
+			settings.EndpointID = string(net.Interfaces[0].UID)
+			settings.IPAddress = net.Interfaces[0].Spec.IP
+			settings.GlobalIPv6Address = net.Interfaces[0].Spec.IP6
+			settings.MacAddress = net.Interfaces[0].Spec.MacAddress
+		}
+
+		ret[net.IfName] = settings
+	}
+
+	return ret
+}
+
+// toContainer returns the summary of the provided machine as it is listed by
+// GET /containers/json.
+func toContainer(machine *machineapi.Machine, now time.Time) types.Container {
+	ret := types.Container{
+		ID:      string(machine.UID),
+		Names:   []string{"/" + machine.Name},
+		Image:   image(machine),
+		Command: strings.Join(machine.Spec.ApplicationArgs, " "),
+		Created: machine.CreationTimestamp.Unix(),
+		Ports:   ports(machine),
+		Labels:  machine.Labels,
+		State:   containerState(machine.Status.State),
+		Status:  containerStatus(machine, now),
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: endpoints(machine),
+		},
+	}
+
+	if ret.Labels == nil {
+		ret.Labels = map[string]string{}
+	}
+
+	return ret
+}
+
+// toContainerJSON returns the details of the provided machine as they are
+// returned by GET /containers/{id}/json.
+func toContainerJSON(machine *machineapi.Machine) types.ContainerJSON {
+	state := containerState(machine.Status.State)
+
+	ret := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:      string(machine.UID),
+			Created: machine.CreationTimestamp.UTC().Format(time.RFC3339Nano),
+			Path:    machine.Spec.Kernel,
+			Args:    machine.Spec.ApplicationArgs,
+			State: &types.ContainerState{
+				Status:     state,
+				Running:    state == "running",
+				Paused:     state == "paused",
+				Restarting: state == "restarting",
+				Dead:       state == "dead",
+				Pid:        int(machine.Status.Pid),
+				ExitCode:   machine.Status.ExitCode,
+				StartedAt:  timestamp(machine.Status.StartedAt),
+				FinishedAt: timestamp(machine.Status.ExitedAt),
+			},
+			Image:        image(machine),
+			LogPath:      machine.Status.LogFile,
+			Name:         "/" + machine.Name,
+			RestartCount: machine.Status.RestartCount,
+			Driver:       machine.Spec.Platform,
+			Platform:     machine.Spec.Architecture,
+			HostConfig: &container.HostConfig{
+				PortBindings: portMap(machine),
+				RestartPolicy: container.RestartPolicy{
+					Name:              string(machine.Spec.RestartPolicy.Name),
+					MaximumRetryCount: machine.Spec.RestartPolicy.MaximumRetryCount,
+				},
+			},
+		},
+		Config: &container.Config{
+			Hostname: machine.Name,
+			Image:    image(machine),
+			Cmd:      machine.Spec.ApplicationArgs,
+			Labels:   machine.Labels,
+		},
+		NetworkSettings: &types.NetworkSettings{
+			NetworkSettingsBase: types.NetworkSettingsBase{
+				Ports: portMap(machine),
+			},
+			Networks: endpoints(machine),
+		},
+	}
+
+	if machine.Status.ExitReason == machineapi.MachineExitReasonPanic {
+		ret.State.Error = "unikernel panicked"
+	}
+
+	if memory, ok := machine.Spec.Resources.Requests[corev1.ResourceMemory]; ok {
+		ret.HostConfig.Memory = memory.Value()
+	}
+
+	for _, endpoint := range ret.NetworkSettings.Networks {
+		ret.NetworkSettings.IPAddress = endpoint.IPAddress
+		ret.NetworkSettings.MacAddress = endpoint.MacAddress
+		break
+	}
+
+	return ret
+}
+
+// timestamp formats the provided time as it is returned by the Docker Engine
+// API, where the zero time denotes a point in time which has not happened.
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return "0001-01-01T00:00:00Z"
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// sortedKeys returns the keys of the provided map in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dockerapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func TestRunOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          createRequest
+		wantArgs     []string
+		wantMemory   string
+		wantCPULimit string
+		wantRestart  string
+		wantNetworks []string
+		wantPorts    []string
+		wantLabels   []string
+		wantWarnings int
+		wantErr      bool
+	}{
+		{
+			name:    "no image",
+			req:     createRequest{Config: &container.Config{}},
+			wantErr: true,
+		},
+		{
+			name:     "image and command",
+			req:      createRequest{Config: &container.Config{Image: "unikraft.org/nginx:latest", Cmd: []string{"-c", "/nginx/conf/nginx.conf"}}},
+			wantArgs: []string{"unikraft.org/nginx:latest", "-c", "/nginx/conf/nginx.conf"},
+		},
+		{
+			name: "resources and restart policy",
+			req: createRequest{
+				Config: &container.Config{Image: "nginx"},
+				HostConfig: &container.HostConfig{
+					Resources:     container.Resources{Memory: 134217728, NanoCPUs: 1500000000},
+					RestartPolicy: container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+				},
+			},
+			wantArgs:     []string{"nginx"},
+			wantMemory:   "134217728",
+			wantCPULimit: "1500m",
+			wantRestart:  "on-failure:3",
+		},
+		{
+			name: "ports",
+			req: createRequest{
+				Config: &container.Config{Image: "nginx"},
+				HostConfig: &container.HostConfig{
+					PortBindings: nat.PortMap{
+						"80/tcp":  {{HostPort: "8080"}},
+						"443/tcp": {{HostIP: "127.0.0.1", HostPort: "8443"}},
+						"53/udp":  nil,
+					},
+				},
+			},
+			wantArgs:  []string{"nginx"},
+			wantPorts: []string{"127.0.0.1:8443:443/tcp", "53:53/udp", "8080:80/tcp"},
+		},
+		{
+			name: "network",
+			req: createRequest{
+				Config:     &container.Config{Image: "nginx"},
+				HostConfig: &container.HostConfig{NetworkMode: "kraft0"},
+			},
+			wantArgs:     []string{"nginx"},
+			wantNetworks: []string{"bridge:kraft0"},
+		},
+		{
+			name: "multiple networks",
+			req: createRequest{
+				Config:     &container.Config{Image: "nginx"},
+				HostConfig: &container.HostConfig{NetworkMode: "public"},
+				NetworkingConfig: &network.NetworkingConfig{
+					EndpointsConfig: map[string]*network.EndpointSettings{
+						"public":  {},
+						"private": {IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.16.0.2"}, MacAddress: "02:b0:b0:00:00:01"},
+					},
+				},
+			},
+			wantArgs:     []string{"nginx"},
+			wantNetworks: []string{"bridge:public", "bridge:private,ip=172.16.0.2,mac=02:b0:b0:00:00:01"},
+		},
+		{
+			name: "default network",
+			req: createRequest{
+				Config:     &container.Config{Image: "nginx"},
+				HostConfig: &container.HostConfig{NetworkMode: "bridge"},
+			},
+			wantArgs: []string{"nginx"},
+		},
+		{
+			name: "labels and unsupported settings",
+			req: createRequest{
+				Config: &container.Config{
+					Image:      "nginx",
+					Labels:     map[string]string{"tier": "frontend", "app": "nginx"},
+					Env:        []string{"FOO=bar"},
+					Entrypoint: []string{"/bin/sh"},
+				},
+				HostConfig: &container.HostConfig{AutoRemove: true, NetworkMode: "host"},
+			},
+			wantArgs:     []string{"nginx"},
+			wantLabels:   []string{"app=nginx", "tier=frontend"},
+			wantWarnings: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, args, warnings, err := runOptions("test", &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runOptions() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if !opts.Detach || !opts.NoStart || opts.Name != "test" {
+				t.Errorf("runOptions() = %+v, want detached machine named test which is not started", opts)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("runOptions() args = %v, want %v", args, tt.wantArgs)
+			}
+
+			if opts.Memory != tt.wantMemory {
+				t.Errorf("runOptions().Memory = %q, want %q", opts.Memory, tt.wantMemory)
+			}
+
+			if opts.CPULimit != tt.wantCPULimit {
+				t.Errorf("runOptions().CPULimit = %q, want %q", opts.CPULimit, tt.wantCPULimit)
+			}
+
+			if opts.Restart != tt.wantRestart {
+				t.Errorf("runOptions().Restart = %q, want %q", opts.Restart, tt.wantRestart)
+			}
+
+			if !reflect.DeepEqual(opts.Networks, tt.wantNetworks) {
+				t.Errorf("runOptions().Networks = %v, want %v", opts.Networks, tt.wantNetworks)
+			}
+
+			if !reflect.DeepEqual(opts.Ports, tt.wantPorts) {
+				t.Errorf("runOptions().Ports = %v, want %v", opts.Ports, tt.wantPorts)
+			}
+
+			if !reflect.DeepEqual(opts.Labels, tt.wantLabels) {
+				t.Errorf("runOptions().Labels = %v, want %v", opts.Labels, tt.wantLabels)
+			}
+
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("runOptions() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestContainerStatus(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status     machineapi.MachineStatus
+		wantState  string
+		wantStatus string
+	}{
+		{
+			status:     machineapi.MachineStatus{State: machineapi.MachineStateCreated},
+			wantState:  "created",
+			wantStatus: "Created",
+		},
+		{
+			status:     machineapi.MachineStatus{State: machineapi.MachineStateRunning, StartedAt: now.Add(-5 * time.Minute)},
+			wantState:  "running",
+			wantStatus: "Up 5 minutes",
+		},
+		{
+			status:     machineapi.MachineStatus{State: machineapi.MachineStateSuspended, StartedAt: now.Add(-time.Hour)},
+			wantState:  "paused",
+			wantStatus: "Up About an hour (Paused)",
+		},
+		{
+			status:     machineapi.MachineStatus{State: machineapi.MachineStateFailed, ExitCode: 1, ExitedAt: now.Add(-10 * time.Second)},
+			wantState:  "exited",
+			wantStatus: "Exited (1) 10 seconds ago",
+		},
+		{
+			status:     machineapi.MachineStatus{State: machineapi.MachineStateUnknown},
+			wantState:  "dead",
+			wantStatus: "Dead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status.State), func(t *testing.T) {
+			machine := &machineapi.Machine{Status: tt.status}
+
+			if got := containerState(machine.Status.State); got != tt.wantState {
+				t.Errorf("containerState(%q) = %q, want %q", machine.Status.State, got, tt.wantState)
+			}
+
+			if got := containerStatus(machine, now); got != tt.wantStatus {
+				t.Errorf("containerStatus(%q) = %q, want %q", machine.Status.State, got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dockerapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/log"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
+	ukarch "kraftkit.sh/unikraft/arch"
+	"kraftkit.sh/unikraft/target"
+)
+
+// listImages handles GET /images/json by listing the OCI packages of
+// unikernels which are available locally.
+func (server *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	packs, err := packmanager.G(server.ctx).Catalog(r.Context(),
+		packmanager.WithTypes(unikraft.ComponentTypeApp),
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	images := []types.ImageSummary{}
+
+	for _, p := range packs {
+		if p.Format() != oci.OCIFormat {
+			continue
+		}
+
+		summary := types.ImageSummary{
+			ID:          imageID(p),
+			RepoTags:    []string{p.String()},
+			RepoDigests: []string{},
+			Labels:      map[string]string{},
+			Containers:  -1,
+			SharedSize:  -1,
+		}
+
+		if config, ok := p.Metadata().(*ocispec.Image); ok && config != nil && config.Created != nil {
+			summary.Created = config.Created.Unix()
+		}
+
+		images = append(images, summary)
+	}
+
+	writeJSON(w, http.StatusOK, images)
+}
+
+// pullImage handles POST /images/create by pulling the package of the
+// unikernel for the host from its registry.  The progress is streamed as JSON
+// messages.
+func (server *Server) pullImage(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("fromImage")
+	if ref == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no image specified"))
+		return
+	}
+
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		if strings.HasPrefix(tag, "sha256:") {
+			ref += "@" + tag
+		} else {
+			ref += ":" + tag
+		}
+	}
+
+	arch, err := ukarch.HostArchitecture()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("could not get host architecture: %w", err))
+		return
+	}
+
+	plat, _, err := mplatform.Detect(server.ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("could not get host platform: %w", err))
+		return
+	}
+
+	packs, err := packmanager.G(server.ctx).Catalog(r.Context(),
+		packmanager.WithTypes(unikraft.ComponentTypeApp),
+		packmanager.WithName(ref),
+		packmanager.WithUpdate(true),
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var found pack.Package
+	for _, p := range packs {
+		if t, ok := p.(target.Target); ok && t.Architecture().String() == arch && t.Platform().String() == plat.String() {
+			found = p
+			break
+		}
+	}
+
+	if found == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("could not find image %s for %s/%s", ref, plat.String(), arch))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	flush := func(msg progressMessage) {
+		_ = enc.Encode(msg)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	flush(progressMessage{Status: "Pulling from " + found.Name(), ID: found.Version()})
+
+	if err := found.Pull(r.Context(), pack.WithPullProgressFunc(func(progress float64) {
+		flush(progressMessage{
+			Status: "Downloading",
+			ID:     found.Version(),
+			Progress: &progressDetail{
+				Current: int64(progress * 100),
+				Total:   100,
+			},
+		})
+	})); err != nil {
+		log.G(server.ctx).WithField("image", ref).Errorf("could not pull: %v", err)
+		flush(progressMessage{
+			Error:       err.Error(),
+			ErrorDetail: &errorDetail{Message: err.Error()},
+		})
+		return
+	}
+
+	flush(progressMessage{Status: "Digest: " + imageID(found)})
+	flush(progressMessage{Status: "Status: Downloaded image for " + found.String()})
+}
+
+// progressMessage is a message of the JSON stream with which the progress of
+// pulling an image is reported.
+type progressMessage struct {
+	Status      string          `json:"status,omitempty"`
+	ID          string          `json:"id,omitempty"`
+	Progress    *progressDetail `json:"progressDetail,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	ErrorDetail *errorDetail    `json:"errorDetail,omitempty"`
+}
+
+// progressDetail is the progress of a step of pulling an image.
+type progressDetail struct {
+	Current int64 `json:"current,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+}
+
+// errorDetail is the error with which pulling an image has failed.
+type errorDetail struct {
+	Message string `json:"message"`
+}
+
+// imageID returns the identifier of the provided package, which is the digest
+// of its manifest for OCI packages.
+func imageID(p pack.Package) string {
+	if ided, ok := p.(interface{ ID() string }); ok {
+		return ided.ID()
+	}
+
+	return p.String()
+}
@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package dockerapi serves a subset of the Docker Engine API such that tools
+// which speak it, e.g. the docker CLI, can manage unikernels.  Containers are
+// translated to machines and images to the OCI packages of unikernels.
+//
+// The subset comprises creating, starting, stopping, listing, inspecting,
+// waiting for and deleting containers, reading their logs, and listing and
+// pulling images.
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/version"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/daemon"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+// APIVersion is the version of the Docker Engine API which is served.
+const APIVersion = "1.43"
+
+// versionPrefix matches the optional version with which the paths of requests
+// are prefixed, e.g. /v1.43/containers/json.
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+\.[0-9]+`)
+
+// Server serves the Docker Engine API on behalf of the machine services of the
+// host and the package manager of the context.
+type Server struct {
+	ctx      context.Context
+	machines machineapi.MachineService
+}
+
+// New prepares a server which manages the machines of all platforms that are
+// supported by the host.  The services are always instantiated directly and
+// the context must provide a package manager through which images are pulled.
+func New(ctx context.Context) (*Server, error) {
+	ctx = daemon.WithDirect(ctx)
+
+	machines, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		ctx:      ctx,
+		machines: machines,
+	}, nil
+}
+
+// Serve accepts connections on the provided listener until the context is
+// cancelled.
+func (server *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{
+		Handler: server,
+		// The services rely on the logger and configuration of the context.
+		BaseContext: func(net.Listener) context.Context {
+			return server.ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := versionPrefix.ReplaceAllString(r.URL.Path, "")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	log.G(server.ctx).
+		WithField("method", r.Method).
+		WithField("path", r.URL.Path).
+		Trace("docker api")
+
+	w.Header().Set("Api-Version", APIVersion)
+	w.Header().Set("Server", "kraftkit/"+version.Version())
+
+	switch {
+	case path == "/_ping" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte("OK"))
+		}
+
+	case path == "/version" && r.Method == http.MethodGet:
+		server.version(w, r)
+
+	case path == "/info" && r.Method == http.MethodGet:
+		server.info(w, r)
+
+	case path == "/containers/create" && r.Method == http.MethodPost:
+		server.createContainer(w, r)
+
+	case path == "/containers/json" && r.Method == http.MethodGet:
+		server.listContainers(w, r)
+
+	case len(parts) == 2 && parts[0] == "containers" && r.Method == http.MethodDelete:
+		server.deleteContainer(w, r, parts[1])
+
+	case len(parts) == 3 && parts[0] == "containers":
+		switch {
+		case parts[2] == "json" && r.Method == http.MethodGet:
+			server.inspectContainer(w, r, parts[1])
+		case parts[2] == "start" && r.Method == http.MethodPost:
+			server.startContainer(w, r, parts[1])
+		case parts[2] == "stop" && r.Method == http.MethodPost:
+			server.stopContainer(w, r, parts[1])
+		case parts[2] == "wait" && r.Method == http.MethodPost:
+			server.waitContainer(w, r, parts[1])
+		case parts[2] == "logs" && r.Method == http.MethodGet:
+			server.containerLogs(w, r, parts[1])
+		default:
+			server.notImplemented(w, r)
+		}
+
+	case path == "/images/json" && r.Method == http.MethodGet:
+		server.listImages(w, r)
+
+	case path == "/images/create" && r.Method == http.MethodPost:
+		server.pullImage(w, r)
+
+	default:
+		server.notImplemented(w, r)
+	}
+}
+
+// notImplemented responds to requests which are not part of the served subset
+// of the API.
+func (server *Server) notImplemented(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotImplemented, fmt.Errorf("%s %s is not supported by kraft", r.Method, r.URL.Path))
+}
+
+// version handles GET /version.
+func (server *Server) version(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, types.Version{
+		Platform: struct{ Name string }{
+			Name: "KraftKit",
+		},
+		Version:       version.Version(),
+		APIVersion:    APIVersion,
+		MinAPIVersion: "1.24",
+		GoVersion:     runtime.Version(),
+		Os:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+	})
+}
+
+// info handles GET /info.
+func (server *Server) info(w http.ResponseWriter, r *http.Request) {
+	machines, err := server.machines.List(r.Context(), &machineapi.MachineList{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	info := types.Info{
+		ID:              "kraftkit",
+		Driver:          "kraftkit",
+		OperatingSystem: runtime.GOOS,
+		OSType:          runtime.GOOS,
+		Architecture:    runtime.GOARCH,
+		NCPU:            runtime.NumCPU(),
+		ServerVersion:   version.Version(),
+		Containers:      len(machines.Items),
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		info.Name = hostname
+	}
+
+	for _, machine := range machines.Items {
+		switch containerState(machine.Status.State) {
+		case "running", "restarting":
+			info.ContainersRunning++
+		case "paused":
+			info.ContainersPaused++
+		default:
+			info.ContainersStopped++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// quiet returns a context whose output streams are discarded, such that the
+// names which commands print are not written to the output of the daemon.
+func quiet(ctx context.Context) (context.Context, func(), error) {
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	io := iostreams.System()
+	io.SetOut(devnull)
+
+	return iostreams.WithIOStreams(ctx, io), func() { devnull.Close() }, nil
+}
+
+// writeJSON responds with the provided value encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with the provided error as it is returned by the Docker
+// Engine API.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Message string `json:"message"`
+	}{
+		Message: err.Error(),
+	})
+}
@@ -24,6 +24,17 @@ const (
 // the file, which are propagated through the error channel.  If a fatal error
 // occurs during the initialization of this method, the last error is returned.
 func NewLogTail(ctx context.Context, logFile string) (chan string, chan error, error) {
+	return newLogTail(ctx, logFile, io.SeekStart)
+}
+
+// NewLogTailFromEnd is like NewLogTail but skips the lines which the logFile
+// already contains when it is opened, such that only the lines which are
+// subsequently appended to it are received.
+func NewLogTailFromEnd(ctx context.Context, logFile string) (chan string, chan error, error) {
+	return newLogTail(ctx, logFile, io.SeekEnd)
+}
+
+func newLogTail(ctx context.Context, logFile string, whence int) (chan string, chan error, error) {
 	f, err := os.Open(logFile)
 	if err != nil {
 		return nil, nil, err
@@ -38,6 +49,12 @@ func NewLogTail(ctx context.Context, logFile string) (chan string, chan error, e
 		return nil, nil, err
 	}
 
+	// Only seek once the file is watched, so that no write which happens in the
+	// meantime is missed.
+	if _, err := f.Seek(0, whence); err != nil {
+		return nil, nil, err
+	}
+
 	logs := make(chan string)
 	errs := make(chan error)
 	reader := bufio.NewReaderSize(f, DefaultTailBufferSize)
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package objects
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"kraftkit.sh/iostreams"
+)
+
+const (
+	// FormatYAML encodes objects as a stream of YAML documents.
+	FormatYAML = "yaml"
+
+	// FormatJSON encodes objects as a stream of JSON documents.
+	FormatJSON = "json"
+)
+
+// Decode reads all the objects from the provided stream of YAML documents,
+// separated by "---", or of JSON documents.  Each document must have the
+// apiVersion and kind of a supported object and must not contain unknown
+// fields.
+func Decode(r io.Reader) ([]runtime.Object, error) {
+	var objs []runtime.Object
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not decode document %d: %w", i, err)
+		}
+
+		// Skip empty documents, e.g. those which only contain comments.
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+
+		var meta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("could not decode document %d: %w", i, err)
+		}
+
+		obj, err := newFromTypeMeta(meta)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode document %d: %w", i, err)
+		}
+
+		strict := json.NewDecoder(bytes.NewReader(raw))
+		strict.DisallowUnknownFields()
+
+		if err := strict.Decode(obj); err != nil {
+			return nil, fmt.Errorf("could not decode %s in document %d: %w", meta.Kind, i, err)
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+// newFromTypeMeta returns an empty object of the kind described by the
+// provided type information.
+func newFromTypeMeta(meta metav1.TypeMeta) (runtime.Object, error) {
+	if meta.APIVersion == "" || meta.Kind == "" {
+		return nil, fmt.Errorf("missing apiVersion or kind")
+	}
+
+	gv, err := schema.ParseGroupVersion(meta.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range Kinds() {
+		if kind.GroupVersionKind() == gv.WithKind(meta.Kind) {
+			return New(kind)
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported object %s of %s", meta.Kind, meta.APIVersion)
+}
+
+// Encode writes the provided objects to the provided writer in the provided
+// format, either FormatYAML or FormatJSON, such that they can be read by
+// Decode.  The apiVersion and kind of each object is set.
+func Encode(w io.Writer, format string, objs ...runtime.Object) error {
+	for i, obj := range objs {
+		kind, err := KindOf(obj)
+		if err != nil {
+			return err
+		}
+
+		obj.GetObjectKind().SetGroupVersionKind(kind.GroupVersionKind())
+
+		var data []byte
+
+		switch format {
+		case FormatYAML:
+			if i > 0 {
+				if _, err := io.WriteString(w, "---\n"); err != nil {
+					return err
+				}
+			}
+
+			data, err = yaml.Marshal(obj)
+
+		case FormatJSON:
+			data, err = json.MarshalIndent(obj, "", "  ")
+			data = append(data, '\n')
+
+		default:
+			return fmt.Errorf("unsupported output format '%s': expected one of %s or %s", format, FormatYAML, FormatJSON)
+		}
+		if err != nil {
+			return fmt.Errorf("could not encode %s: %w", kind, err)
+		}
+
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadFiles decodes the objects of the provided files in order, where "-"
+// denotes the standard input.
+func ReadFiles(ctx context.Context, paths ...string) ([]runtime.Object, error) {
+	var objs []runtime.Object
+
+	for _, path := range paths {
+		var r io.Reader
+
+		if path == "-" {
+			r = iostreams.G(ctx).In
+		} else {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+
+			defer f.Close()
+
+			r = f
+		}
+
+		decoded, err := Decode(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", path, err)
+		}
+
+		objs = append(objs, decoded...)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package objects
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		wantKinds []Kind
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name: "multiple documents",
+			in: `# The network of the machine
+apiVersion: network.unikraft.io/v1alpha1
+kind: Network
+metadata:
+  name: kraft0
+spec:
+  gateway: 172.18.0.1
+  netmask: 255.255.0.0
+---
+---
+apiVersion: machine.unikraft.io/v1alpha1
+kind: Machine
+metadata:
+  name: nginx
+  labels:
+    app: nginx
+spec:
+  kernel: unikraft.org/nginx:latest
+  plat: qemu
+  arch: x86_64
+`,
+			wantKinds: []Kind{KindNetwork, KindMachine},
+			wantNames: []string{"kraft0", "nginx"},
+		},
+		{
+			name:      "json",
+			in:        `{"apiVersion": "volume.unikraft.io/v1alpha1", "kind": "Volume", "metadata": {"name": "data"}, "spec": {"source": "/tmp/data"}}`,
+			wantKinds: []Kind{KindVolume},
+			wantNames: []string{"data"},
+		},
+		{
+			name:    "missing kind",
+			in:      "apiVersion: machine.unikraft.io/v1alpha1\nmetadata:\n  name: nginx\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported kind",
+			in:      "apiVersion: v1\nkind: Pod\nmetadata:\n  name: nginx\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			in:      "apiVersion: machine.unikraft.io/v1alpha1\nkind: Machine\nmetadata:\n  name: nginx\nspec:\n  kernal: nginx\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := Decode(strings.NewReader(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if len(objs) != len(tt.wantKinds) {
+				t.Fatalf("Decode() returned %d objects, want %d", len(objs), len(tt.wantKinds))
+			}
+
+			for i, obj := range objs {
+				kind, err := KindOf(obj)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if kind != tt.wantKinds[i] {
+					t.Errorf("Decode()[%d] kind = %s, want %s", i, kind, tt.wantKinds[i])
+				}
+
+				if name := obj.(metav1.Object).GetName(); name != tt.wantNames[i] {
+					t.Errorf("Decode()[%d] name = %s, want %s", i, name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	objs := []runtime.Object{
+		&networkapi.Network{
+			ObjectMeta: metav1.ObjectMeta{Name: "kraft0"},
+			Spec:       networkapi.NetworkSpec{Driver: "bridge", Gateway: "172.18.0.1", Netmask: "255.255.0.0"},
+		},
+		&machineapi.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Labels: map[string]string{"app": "nginx"}},
+			Spec:       machineapi.MachineSpec{Kernel: "unikraft.org/nginx:latest", ApplicationArgs: []string{"-c", "/nginx/conf/nginx.conf"}},
+		},
+	}
+
+	for _, format := range []string{FormatYAML, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Encode(&b, format, objs...); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			decoded, err := Decode(&b)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			if len(decoded) != len(objs) {
+				t.Fatalf("Decode() returned %d objects, want %d", len(decoded), len(objs))
+			}
+
+			machine, ok := decoded[1].(*machineapi.Machine)
+			if !ok {
+				t.Fatalf("Decode()[1] = %T, want *Machine", decoded[1])
+			}
+
+			if machine.Labels["app"] != "nginx" || len(machine.Spec.ApplicationArgs) != 2 {
+				t.Errorf("Decode()[1] = %+v, want the encoded machine", machine)
+			}
+		})
+	}
+}
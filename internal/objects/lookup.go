@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package objects
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/network"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/volume"
+)
+
+// List returns all the objects of the provided kind across all platforms or
+// drivers.  The driver of networks and volumes is set to that which manages
+// them such that they can be re-created from their declarative form.
+func List(ctx context.Context, kind Kind) ([]runtime.Object, error) {
+	var objs []runtime.Object
+
+	switch kind {
+	case KindMachine:
+		controller, err := mplatform.NewMachineV1alpha1ServiceIterator(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		machines, err := controller.List(ctx, &machineapi.MachineList{})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range machines.Items {
+			objs = append(objs, &machines.Items[i])
+		}
+
+	case KindNetwork:
+		strategies := network.Strategies()
+
+		for _, driver := range sortedKeys(strategies) {
+			controller, err := strategies[driver].NewNetworkV1alpha1(ctx)
+			if err != nil {
+				log.G(ctx).
+					WithField("driver", driver).
+					Debugf("could not instantiate network driver: %v", err)
+				continue
+			}
+
+			networks, err := controller.List(ctx, &networkapi.NetworkList{})
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range networks.Items {
+				if networks.Items[i].Spec.Driver == "" {
+					networks.Items[i].Spec.Driver = driver
+				}
+
+				objs = append(objs, &networks.Items[i])
+			}
+		}
+
+	case KindVolume:
+		strategies := volume.Strategies()
+
+		for _, driver := range sortedKeys(strategies) {
+			controller, err := strategies[driver].NewVolumeV1alpha1(ctx)
+			if err != nil {
+				log.G(ctx).
+					WithField("driver", driver).
+					Debugf("could not instantiate volume driver: %v", err)
+				continue
+			}
+
+			volumes, err := controller.List(ctx, &volumeapi.VolumeList{})
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range volumes.Items {
+				if volumes.Items[i].Spec.Driver == "" {
+					volumes.Items[i].Spec.Driver = driver
+				}
+
+				objs = append(objs, &volumes.Items[i])
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported object kind '%s'", kind)
+	}
+
+	return objs, nil
+}
+
+// Get returns the object of the provided kind with the provided name or UID.
+// A nil object is returned if it does not exist.
+func Get(ctx context.Context, kind Kind, name string) (runtime.Object, error) {
+	objs, err := List(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if accessor.GetName() == name || string(accessor.GetUID()) == name {
+			return obj, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Find returns the object with the provided name or UID of any kind.  An
+// error is returned if it does not exist or if objects of different kinds
+// have the same name.
+func Find(ctx context.Context, name string) (runtime.Object, error) {
+	var found runtime.Object
+	var foundKind Kind
+
+	for _, kind := range Kinds() {
+		obj, err := Get(ctx, kind, name)
+		if err != nil {
+			return nil, err
+		} else if obj == nil {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("'%s' is ambiguous: both a %s and a %s exist with this name, use TYPE/NAME", name, strings.ToLower(foundKind.String()), strings.ToLower(kind.String()))
+		}
+
+		found = obj
+		foundKind = kind
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("could not find machine, network or volume: %s", name)
+	}
+
+	return found, nil
+}
+
+// sortedKeys returns the keys of the provided map in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
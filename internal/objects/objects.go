@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package objects reads and writes machines, networks and volumes in their
+// declarative Kubernetes-style representation, i.e. as YAML or JSON documents
+// with an apiVersion and kind, and retrieves them from their services.
+package objects
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	networkapi "kraftkit.sh/api/network/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// Kind is the kind of a declarative object.
+type Kind string
+
+const (
+	KindMachine = Kind("Machine")
+	KindNetwork = Kind("Network")
+	KindVolume  = Kind("Volume")
+)
+
+// Kinds returns all the supported kinds of objects.
+func Kinds() []Kind {
+	return []Kind{
+		KindMachine,
+		KindNetwork,
+		KindVolume,
+	}
+}
+
+// String implements fmt.Stringer
+func (kind Kind) String() string {
+	return string(kind)
+}
+
+// GroupVersionKind returns the API group, version and kind of objects of the
+// kind.
+func (kind Kind) GroupVersionKind() schema.GroupVersionKind {
+	switch kind {
+	case KindMachine:
+		return machineapi.SchemeGroupVersion.WithKind(kind.String())
+	case KindNetwork:
+		return networkapi.SchemeGroupVersion.WithKind(kind.String())
+	case KindVolume:
+		return volumeapi.SchemeGroupVersion.WithKind(kind.String())
+	}
+
+	return schema.GroupVersionKind{}
+}
+
+// ParseKind parses the kind of an object as it is provided on the command
+// line, i.e. case-insensitively in its singular, plural or short form, e.g.
+// "machine", "machines" or "m".
+func ParseKind(s string) (Kind, error) {
+	switch strings.ToLower(s) {
+	case "machine", "machines", "m":
+		return KindMachine, nil
+	case "network", "networks", "net":
+		return KindNetwork, nil
+	case "volume", "volumes", "vol":
+		return KindVolume, nil
+	}
+
+	return "", fmt.Errorf("unknown object type '%s': expected one of machine, network or volume", s)
+}
+
+// ParseReference parses a reference to an object as it is provided on the
+// command line, i.e. either TYPE/NAME or NAME, in which case the returned kind
+// is empty.
+func ParseReference(ref string) (Kind, string, error) {
+	typ, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		if ref == "" {
+			return "", "", fmt.Errorf("empty object reference")
+		}
+
+		return "", ref, nil
+	}
+
+	kind, err := ParseKind(typ)
+	if err != nil {
+		return "", "", err
+	}
+
+	if name == "" {
+		return "", "", fmt.Errorf("no name in object reference '%s'", ref)
+	}
+
+	return kind, name, nil
+}
+
+// KindOf returns the kind of the provided object.
+func KindOf(obj runtime.Object) (Kind, error) {
+	switch obj.(type) {
+	case *machineapi.Machine:
+		return KindMachine, nil
+	case *networkapi.Network:
+		return KindNetwork, nil
+	case *volumeapi.Volume:
+		return KindVolume, nil
+	}
+
+	return "", fmt.Errorf("unsupported object type %T", obj)
+}
+
+// New returns an empty object of the provided kind.
+func New(kind Kind) (runtime.Object, error) {
+	switch kind {
+	case KindMachine:
+		return &machineapi.Machine{}, nil
+	case KindNetwork:
+		return &networkapi.Network{}, nil
+	case KindVolume:
+		return &volumeapi.Volume{}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported object kind '%s'", kind)
+}
+
+// SortByDependency orders the provided objects such that volumes and networks
+// precede the machines which may use them, whilst retaining the order of
+// objects of the same kind.
+func SortByDependency(objs []runtime.Object) []runtime.Object {
+	rank := func(obj runtime.Object) int {
+		switch obj.(type) {
+		case *volumeapi.Volume:
+			return 0
+		case *networkapi.Network:
+			return 1
+		}
+
+		return 2
+	}
+
+	sorted := make([]runtime.Object, len(objs))
+	copy(sorted, objs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+
+	return sorted
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package objects
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantKind Kind
+		wantName string
+		wantErr  bool
+	}{
+		{in: "nginx", wantName: "nginx"},
+		{in: "machine/nginx", wantKind: KindMachine, wantName: "nginx"},
+		{in: "Machines/nginx", wantKind: KindMachine, wantName: "nginx"},
+		{in: "net/kraft0", wantKind: KindNetwork, wantName: "kraft0"},
+		{in: "volume/data", wantKind: KindVolume, wantName: "data"},
+		{in: "", wantErr: true},
+		{in: "machine/", wantErr: true},
+		{in: "pod/nginx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			kind, name, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if kind != tt.wantKind || name != tt.wantName {
+				t.Errorf("ParseReference(%q) = (%q, %q), want (%q, %q)", tt.in, kind, name, tt.wantKind, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package pidfile records the PIDs of the helper processes which KraftKit
+// re-executes itself as and looks them up again, such that a PID which has
+// since been reused by an unrelated process is never mistaken for the helper.
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	goprocess "github.com/shirou/gopsutil/v3/process"
+)
+
+// Write records the provided PID at the provided path.
+func Write(path string, pid int) error {
+	return os.WriteFile(path, []byte(strconv.Itoa(pid)), 0o644)
+}
+
+// Lookup returns the PID recorded at the provided path if it still belongs to
+// a process which was re-executed under the provided name, or 0 if that
+// process has exited.  The error wraps os.ErrNotExist if nothing has been
+// recorded at the path.
+func Lookup(path, name string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("could not parse pid in %s: %q", path, data)
+	}
+
+	process, err := goprocess.NewProcess(int32(pid))
+	if errors.Is(err, goprocess.ErrorProcessNotRunning) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	// A re-executed process is started with its registered name as the first
+	// argument, which an unrelated process that has been assigned the same PID
+	// will not have.
+	args, err := process.CmdlineSlice()
+	if err != nil || len(args) == 0 || filepath.Base(args[0]) != name {
+		return 0, nil
+	}
+
+	return pid, nil
+}
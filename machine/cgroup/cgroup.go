@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package cgroup manages the cgroup v2 of each machine, i.e.
+// kraftkit.slice/<machine-uid>, in which its VMM is created such that the host
+// resources consumed by the VMM are constrained by the limits of the machine.
+package cgroup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+const (
+	// Root is the in-host path at which the cgroup v2 hierarchy is mounted.
+	Root = "/sys/fs/cgroup"
+
+	// Slice is the name of the cgroup which contains the cgroup of each
+	// machine.
+	Slice = "kraftkit.slice"
+
+	// cpuPeriod is the period in microseconds over which the CPU quota of a
+	// machine is enforced.
+	cpuPeriod = 100000
+
+	minWeight = 1
+	maxWeight = 10000
+)
+
+// Path returns the in-host path to the cgroup of the machine.
+func Path(machine *machinev1alpha1.Machine) string {
+	return filepath.Join(Root, Slice, string(machine.ObjectMeta.UID))
+}
+
+// setting is the value which is written to an interface file of a cgroup.
+type setting struct {
+	file  string
+	value string
+}
+
+// settings returns the values of the interface files of a cgroup which
+// implement the provided limits, together with the controllers which provide
+// these files.  IO bandwidth limits apply to each of the provided block
+// devices, which are identified by their "MAJ:MIN" numbers.
+func settings(limits corev1.ResourceList, devices []string) ([]setting, []string, error) {
+	var cpu, memory, io []setting
+
+	if quantity, ok := limits[corev1.ResourceCPU]; ok {
+		quota := quantity.MilliValue() * cpuPeriod / 1000
+		if quota < 1000 {
+			return nil, nil, fmt.Errorf("cpu limit must be at least 10m: %s", quantity.String())
+		}
+
+		cpu = append(cpu, setting{"cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)})
+	}
+
+	if quantity, ok := limits[machinev1alpha1.ResourceCPUWeight]; ok {
+		weight := quantity.Value()
+		if weight < minWeight || weight > maxWeight {
+			return nil, nil, fmt.Errorf("cpu weight must be between %d and %d: %d", minWeight, maxWeight, weight)
+		}
+
+		cpu = append(cpu, setting{"cpu.weight", fmt.Sprintf("%d", weight)})
+	}
+
+	if quantity, ok := limits[corev1.ResourceMemory]; ok {
+		if quantity.Value() <= 0 {
+			return nil, nil, fmt.Errorf("memory limit must be positive: %s", quantity.String())
+		}
+
+		memory = append(memory, setting{"memory.max", fmt.Sprintf("%d", quantity.Value())})
+	}
+
+	if quantity, ok := limits[machinev1alpha1.ResourceIOWeight]; ok {
+		weight := quantity.Value()
+		if weight < minWeight || weight > maxWeight {
+			return nil, nil, fmt.Errorf("io weight must be between %d and %d: %d", minWeight, maxWeight, weight)
+		}
+
+		io = append(io, setting{"io.weight", fmt.Sprintf("default %d", weight)})
+	}
+
+	var bandwidth []string
+
+	for _, limit := range []struct {
+		resource corev1.ResourceName
+		key      string
+	}{
+		{machinev1alpha1.ResourceIOReadBPS, "rbps"},
+		{machinev1alpha1.ResourceIOWriteBPS, "wbps"},
+	} {
+		quantity, ok := limits[limit.resource]
+		if !ok {
+			continue
+		}
+
+		if quantity.Value() <= 0 {
+			return nil, nil, fmt.Errorf("%s must be positive: %s", limit.resource, quantity.String())
+		}
+
+		bandwidth = append(bandwidth, fmt.Sprintf("%s=%d", limit.key, quantity.Value()))
+	}
+
+	if len(bandwidth) > 0 {
+		for _, device := range devices {
+			io = append(io, setting{"io.max", device + " " + strings.Join(bandwidth, " ")})
+		}
+	}
+
+	var controllers []string
+	var all []setting
+
+	for _, controller := range []struct {
+		name     string
+		settings []setting
+	}{
+		{"cpu", cpu},
+		{"memory", memory},
+		{"io", io},
+	} {
+		if len(controller.settings) == 0 {
+			continue
+		}
+
+		controllers = append(controllers, controller.name)
+		all = append(all, controller.settings...)
+	}
+
+	return all, controllers, nil
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cgroup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// Create creates the cgroup of the machine and applies the limits of the
+// resources of the machine to it.  It returns the in-host path of the cgroup
+// in which the VMM of the machine is to be created, see exec.WithCgroup.
+func Create(machine *machinev1alpha1.Machine) (string, error) {
+	if _, err := os.Stat(filepath.Join(Root, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", Root)
+	}
+
+	devices, err := blockDevices()
+	if err != nil {
+		return "", fmt.Errorf("could not list block devices: %w", err)
+	}
+
+	settings, controllers, err := settings(machine.Spec.Resources.Limits, devices)
+	if err != nil {
+		return "", err
+	}
+
+	slice := filepath.Join(Root, Slice)
+	if err := os.MkdirAll(slice, 0o755); err != nil {
+		return "", fmt.Errorf("could not create cgroup %s: %w", slice, err)
+	}
+
+	// Delegate the controllers down to the cgroup of the machine.
+	for _, parent := range []string{Root, slice} {
+		for _, controller := range controllers {
+			if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+"+controller), 0o644); err != nil {
+				return "", fmt.Errorf("could not enable %s controller of cgroup %s: %w", controller, parent, err)
+			}
+		}
+	}
+
+	// A machine which is created again, e.g. when it is restarted, is placed in
+	// a new cgroup such that limits which have since been removed do not remain.
+	path := Path(machine)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("could not remove previous cgroup %s: %w", path, err)
+	}
+
+	if err := os.Mkdir(path, 0o755); err != nil {
+		return "", fmt.Errorf("could not create cgroup %s: %w", path, err)
+	}
+
+	for _, setting := range settings {
+		if err := os.WriteFile(filepath.Join(path, setting.file), []byte(setting.value), 0o644); err != nil {
+			_ = os.Remove(path)
+			return "", fmt.Errorf("could not set %s of cgroup %s to '%s': %w", setting.file, path, setting.value, err)
+		}
+	}
+
+	return path, nil
+}
+
+// Delete removes the cgroup of the machine.  The VMM of the machine must have
+// exited beforehand.
+func Delete(machine *machinev1alpha1.Machine) error {
+	path := Path(machine)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove cgroup %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// blockDevices returns the "MAJ:MIN" numbers of the physical block devices of
+// the host.
+func blockDevices() ([]string, error) {
+	entries, err := os.ReadDir("/sys/block")
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+
+	for _, entry := range entries {
+		// Virtual devices, e.g. loop or RAM disks, have no backing device.
+		if _, err := os.Stat(filepath.Join("/sys/block", entry.Name(), "device")); err != nil {
+			continue
+		}
+
+		dev, err := os.ReadFile(filepath.Join("/sys/block", entry.Name(), "dev"))
+		if err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, strings.TrimSpace(string(dev)))
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cgroup
+
+import (
+	"fmt"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// Create is not supported on this host.
+func Create(_ *machinev1alpha1.Machine) (string, error) {
+	return "", fmt.Errorf("cgroups are not supported on this host")
+}
+
+// Delete is a no-op on this host.
+func Delete(_ *machinev1alpha1.Machine) error {
+	return nil
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package cgroup
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+func TestSettings(t *testing.T) {
+	tests := []struct {
+		name            string
+		limits          corev1.ResourceList
+		wantSettings    []setting
+		wantControllers []string
+		wantErr         bool
+	}{
+		{
+			name: "no limits",
+		},
+		{
+			name: "cpu quota and weight",
+			limits: corev1.ResourceList{
+				corev1.ResourceCPU:                resource.MustParse("1500m"),
+				machinev1alpha1.ResourceCPUWeight: resource.MustParse("200"),
+			},
+			wantSettings: []setting{
+				{"cpu.max", "150000 100000"},
+				{"cpu.weight", "200"},
+			},
+			wantControllers: []string{"cpu"},
+		},
+		{
+			name: "memory",
+			limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+			wantSettings: []setting{
+				{"memory.max", "268435456"},
+			},
+			wantControllers: []string{"memory"},
+		},
+		{
+			name: "io",
+			limits: corev1.ResourceList{
+				machinev1alpha1.ResourceIOWeight:   resource.MustParse("50"),
+				machinev1alpha1.ResourceIOWriteBPS: resource.MustParse("1Mi"),
+				machinev1alpha1.ResourceIOReadBPS:  resource.MustParse("2Mi"),
+			},
+			wantSettings: []setting{
+				{"io.weight", "default 50"},
+				{"io.max", "8:0 rbps=2097152 wbps=1048576"},
+				{"io.max", "259:0 rbps=2097152 wbps=1048576"},
+			},
+			wantControllers: []string{"io"},
+		},
+		{
+			name: "cpu quota too small",
+			limits: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1m"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "cpu weight out of range",
+			limits: corev1.ResourceList{
+				machinev1alpha1.ResourceCPUWeight: resource.MustParse("10001"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, controllers, err := settings(tt.limits, []string{"8:0", "259:0"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("settings() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(settings, tt.wantSettings) {
+				t.Errorf("settings() = %v, want %v", settings, tt.wantSettings)
+			}
+
+			if !reflect.DeepEqual(controllers, tt.wantControllers) {
+				t.Errorf("settings() controllers = %v, want %v", controllers, tt.wantControllers)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"kraftkit.sh/internal/httpunix"
+)
+
+// client sends the requests of the services of a single driver of a resource
+// to the daemon.
+type client struct {
+	http     *http.Client
+	resource string
+	driver   string
+}
+
+// newClient returns a client of the provided driver of a resource of the
+// daemon which listens on the socket configured in the provided context.
+func newClient(ctx context.Context, resource, driver string) *client {
+	return &client{
+		http: &http.Client{
+			Transport: httpunix.NewRoundTripper(Socket(ctx)),
+		},
+		resource: resource,
+		driver:   driver,
+	}
+}
+
+// do sends the provided input of a method to the daemon and returns the body
+// of its response, which must be closed by the caller.
+func (c *client) do(ctx context.Context, method string, in any) (io.ReadCloser, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(in); err != nil {
+		return nil, fmt.Errorf("could not encode request: %w", err)
+	}
+
+	// The host is ignored as the request is sent via the unix socket.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://kraftd"+Path(c.resource, c.driver, method), &body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", ContentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach daemon: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		msg, _ := io.ReadAll(resp.Body)
+		if len(msg) == 0 {
+			return nil, fmt.Errorf("daemon responded with %s", resp.Status)
+		}
+
+		return nil, errors.New(strings.TrimSpace(string(msg)))
+	}
+
+	return resp.Body, nil
+}
+
+// call invokes the provided method of the client and returns its output.
+func call[In, Out any](ctx context.Context, c *client, method string, in In) (Out, error) {
+	var out Out
+
+	body, err := c.do(ctx, method, in)
+	if err != nil {
+		return out, err
+	}
+
+	defer body.Close()
+
+	if err := json.NewDecoder(body).Decode(&out); err != nil {
+		return out, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return out, nil
+}
+
+// stream invokes the provided streaming method of the client and forwards the
+// received values and errors to the returned channels until the context is
+// cancelled or the daemon closes the stream.
+func stream[In, Out any](ctx context.Context, c *client, method string, in In) (chan Out, chan error, error) {
+	body, err := c.do(ctx, method, in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(chan Out)
+	errs := make(chan error)
+
+	go func() {
+		defer body.Close()
+
+		decoder := json.NewDecoder(body)
+
+		for {
+			var event StreamEvent[Out]
+			if err := decoder.Decode(&event); err != nil {
+				if ctx.Err() == nil {
+					select {
+					case errs <- fmt.Errorf("daemon closed stream: %w", err):
+					case <-ctx.Done():
+					}
+				}
+
+				return
+			}
+
+			if event.Err != "" {
+				select {
+				case errs <- errors.New(event.Err):
+				case <-ctx.Done():
+					return
+				}
+
+				continue
+			}
+
+			select {
+			case values <- event.Value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return values, errs, nil
+}
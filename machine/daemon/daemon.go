@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package daemon provides access to the machine, network and volume services
+// which are served by the kraft daemon over a unix socket.  When the daemon is
+// running, the constructors of the platform, network and volume drivers return
+// clients of its services such that the store and the VMMs are driven by a
+// single long-running process.  Otherwise, the services are accessed directly.
+//
+// Both ends of the daemon are Zip API service handlers, i.e. the clients are
+// wrapped with NewMachineServiceHandler, NewNetworkServiceHandler and
+// NewVolumeServiceHandler exactly like the services of the drivers which they
+// are served by.  Each method is sent as an HTTP POST request to
+// /VERSION/RESOURCE/DRIVER/METHOD, e.g. /v1alpha1/machines/qemu/create, where
+// the body of the request and the response is the JSON representation of the
+// API object.  Streaming methods, i.e. Watch and Logs, respond with a sequence
+// of JSON-encoded StreamEvent values.
+//
+// Monitor follows the machines of a machine service, enforcing their restart
+// policies and reporting their health, and is run by the daemon for each
+// platform.
+package daemon
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"kraftkit.sh/config"
+)
+
+const (
+	// Version is the version of the API which is served by the daemon.
+	Version = "v1alpha1"
+
+	// ContentType is the content type of requests and responses.
+	ContentType = "application/json"
+
+	// ResourceMachines, ResourceNetworks and ResourceVolumes are the resources
+	// which are served by the daemon.
+	ResourceMachines = "machines"
+	ResourceNetworks = "networks"
+	ResourceVolumes  = "volumes"
+
+	// PingPath is the path which responds successfully whilst the daemon is
+	// serving requests.
+	PingPath = "/_ping"
+
+	dialTimeout = 100 * time.Millisecond
+)
+
+// StreamEvent is a single value, or error, of a streaming method.
+type StreamEvent[T any] struct {
+	Value T      `json:"value,omitempty"`
+	Err   string `json:"error,omitempty"`
+}
+
+// contextKey is used to retrieve whether services are accessed directly from
+// the context.
+type contextKey struct{}
+
+// WithDirect returns a context in which services are always accessed
+// directly, i.e. never via the daemon.  It is used by the daemon itself.
+func WithDirect(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, true)
+}
+
+// IsDirect returns whether services are accessed directly in the provided
+// context.
+func IsDirect(ctx context.Context) bool {
+	direct, _ := ctx.Value(contextKey{}).(bool)
+	return direct
+}
+
+// Socket returns the path to the unix socket of the daemon.
+func Socket(ctx context.Context) string {
+	return config.G[config.KraftKit](ctx).DaemonSocket
+}
+
+// Running returns whether the daemon accepts connections on its socket.
+func Running(ctx context.Context) bool {
+	socket := Socket(ctx)
+	if socket == "" {
+		return false
+	}
+
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return false
+	}
+
+	_ = conn.Close()
+
+	return true
+}
+
+// Path returns the path of the provided method of the provided driver of a
+// resource.
+func Path(resource, driver, method string) string {
+	return "/" + strings.Join([]string{Version, resource, driver, method}, "/")
+}
+
+// ParsePath returns the resource, driver and method of the provided path.
+func ParsePath(path string) (resource, driver, method string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 4 || parts[0] != Version {
+		return "", "", "", false
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return "", "", "", false
+		}
+	}
+
+	return parts[1], parts[2], parts[3], true
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import "testing"
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		in           string
+		wantResource string
+		wantDriver   string
+		wantMethod   string
+		wantOk       bool
+	}{
+		{
+			in:           Path(ResourceMachines, "qemu", "create"),
+			wantResource: ResourceMachines,
+			wantDriver:   "qemu",
+			wantMethod:   "create",
+			wantOk:       true,
+		},
+		{
+			in:           "/v1alpha1/networks/bridge/list",
+			wantResource: ResourceNetworks,
+			wantDriver:   "bridge",
+			wantMethod:   "list",
+			wantOk:       true,
+		},
+		{in: "/v1alpha2/machines/qemu/create"},
+		{in: "/v1alpha1/machines/qemu"},
+		{in: "/v1alpha1/machines//create"},
+		{in: "/v1alpha1/machines/qemu/create/extra"},
+		{in: PingPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			resource, driver, method, ok := ParsePath(tt.in)
+			if ok != tt.wantOk {
+				t.Fatalf("ParsePath(%q) ok = %v, want %v", tt.in, ok, tt.wantOk)
+			}
+
+			if resource != tt.wantResource || driver != tt.wantDriver || method != tt.wantMethod {
+				t.Errorf("ParsePath(%q) = (%q, %q, %q), want (%q, %q, %q)", tt.in, resource, driver, method, tt.wantResource, tt.wantDriver, tt.wantMethod)
+			}
+		})
+	}
+}
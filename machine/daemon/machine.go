@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// MachineV1alpha1 returns a constructor of the machine service of the provided
+// platform which returns a client of the daemon whilst it is running and the
+// service returned by the provided direct constructor otherwise.  Services
+// which are constructed with options are always constructed directly as the
+// options cannot be passed to the daemon.
+func MachineV1alpha1(platform string, direct func(context.Context, ...any) (machinev1alpha1.MachineService, error)) func(context.Context, ...any) (machinev1alpha1.MachineService, error) {
+	return func(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
+		if len(opts) == 0 && !IsDirect(ctx) && Running(ctx) {
+			return NewMachineV1alpha1Client(ctx, platform)
+		}
+
+		return direct(ctx, opts...)
+	}
+}
+
+// machineV1alpha1Client implements machinev1alpha1.MachineService by
+// forwarding each method to the daemon.
+type machineV1alpha1Client struct {
+	*client
+}
+
+// NewMachineV1alpha1Client returns a client of the machine service of the
+// provided platform of the daemon, which is wrapped by the same Zip API service
+// handler as the services of the drivers which it is served by.
+func NewMachineV1alpha1Client(ctx context.Context, platform string) (machinev1alpha1.MachineService, error) {
+	return machinev1alpha1.NewMachineServiceHandler(ctx, &machineV1alpha1Client{newClient(ctx, ResourceMachines, platform)})
+}
+
+// Create implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Create(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "create", machine)
+}
+
+// Start implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Start(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "start", machine)
+}
+
+// Pause implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Pause(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "pause", machine)
+}
+
+// Stop implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Stop(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "stop", machine)
+}
+
+// Update implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Update(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "update", machine)
+}
+
+// Delete implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Delete(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "delete", machine)
+}
+
+// Get implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Get(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	return call[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "get", machine)
+}
+
+// List implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) List(ctx context.Context, machines *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
+	return call[*machinev1alpha1.MachineList, *machinev1alpha1.MachineList](ctx, c.client, "list", machines)
+}
+
+// Watch implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Watch(ctx context.Context, machine *machinev1alpha1.Machine) (chan *machinev1alpha1.Machine, chan error, error) {
+	return stream[*machinev1alpha1.Machine, *machinev1alpha1.Machine](ctx, c.client, "watch", machine)
+}
+
+// Logs implements kraftkit.sh/api/machine/v1alpha1.MachineService
+func (c *machineV1alpha1Client) Logs(ctx context.Context, machine *machinev1alpha1.Machine) (chan string, chan error, error) {
+	return stream[*machinev1alpha1.Machine, string](ctx, c.client, "logs", machine)
+}
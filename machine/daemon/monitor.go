@@ -0,0 +1,338 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	zip "api.zip"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/qemu/qmp"
+)
+
+const (
+	// restartBackoffInitial is the delay before a machine which has exited is
+	// restarted for the first time.  The delay also allows for an explicit stop
+	// of the machine to be recorded before it is mistaken for an exit.
+	restartBackoffInitial = time.Second
+
+	// restartBackoffMax is the upper bound of the exponentially increasing delay
+	// between consecutive restarts of a machine.
+	restartBackoffMax = time.Minute
+
+	// restartBackoffReset is the duration a machine must have been running for
+	// before the delay is reset to restartBackoffInitial.
+	restartBackoffReset = 10 * time.Second
+
+	// healthPollInterval is the interval at which the health of a machine with
+	// a health check is polled for transitions.
+	healthPollInterval = time.Second
+)
+
+// Monitor watches the provided store of machines and monitors each machine as
+// it appears or changes, i.e. it follows their events and health and restarts
+// them as their restart policy demands, until the context is cancelled.  Each
+// machine is monitored through the controller of its platform and machines of
+// other platforms are skipped.  Only the machines for which the provided
+// filter returns true are monitored, or all if it is nil.  With quitTogether,
+// it returns as soon as no machine is monitored any longer.
+func Monitor(ctx context.Context, controllers map[string]machineapi.MachineService, machines zip.Store, filter func(*machineapi.Machine) bool, quitTogether bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// observations are the machines which are being monitored.  They are only
+	// accessed by this goroutine, to which each monitor reports its end via done.
+	observations := map[types.UID]bool{}
+	seen := map[types.UID]bool{}
+	done := make(chan types.UID)
+
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+	defer cancel()
+
+	consider := func(machine *machineapi.Machine) {
+		if filter != nil && !filter(machine) {
+			return
+		}
+
+		// Machines which are already being monitored, including those which
+		// are waiting to be restarted, are skipped.
+		if observations[machine.UID] {
+			return
+		}
+
+		controller, ok := controllers[machine.Spec.Platform]
+		if !ok {
+			log.G(ctx).Debugf("%s : no controller for platform %s", machine.Name, machine.Spec.Platform)
+			return
+		}
+
+		startup := !seen[machine.UID]
+		seen[machine.UID] = true
+
+		switch machine.Status.State {
+		case machineapi.MachineStateFailed,
+			machineapi.MachineStateExited,
+			machineapi.MachineStateErrored,
+			machineapi.MachineStateUnknown:
+			// Machines which have exited whilst they were not monitored are only
+			// of interest if they are to be restarted.
+			if !machine.Spec.RestartPolicy.ShouldRestart(machine.Status, startup) {
+				return
+			}
+
+		default:
+			startup = false
+		}
+
+		observations[machine.UID] = true
+		wg.Add(1)
+
+		// The object is shared with the watcher of the store.
+		machine = machine.DeepCopyObject().(*machineapi.Machine)
+
+		go func() {
+			defer wg.Done()
+			defer func() {
+				select {
+				case <-ctx.Done():
+				case done <- machine.UID:
+				}
+			}()
+
+			// The machine is re-read through its controller, which also decodes
+			// its platform-specific configuration.
+			latest, err := controller.Get(ctx, machine)
+			if err != nil {
+				log.G(ctx).Debugf("%s : %v", machine.Name, err)
+				return
+			}
+
+			monitor(ctx, controller, latest, startup)
+		}()
+	}
+
+	// The store acts as the source-of-truth for machines which are being
+	// instantiated by KraftKit and may be updated elsewhere at any time.  The
+	// machines which exist are considered first, after which only their changes
+	// are received.
+	list := &machineapi.MachineList{}
+	if err := machines.GetList(ctx, "", storage.ListOptions{}, list); err != nil {
+		return fmt.Errorf("could not list machines: %w", err)
+	}
+
+	for i := range list.Items {
+		consider(&list.Items[i])
+	}
+
+	if quitTogether && len(observations) == 0 {
+		return nil
+	}
+
+	watcher, err := machines.Watch(ctx, "", storage.ListOptions{
+		ResourceVersion: list.ResourceVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("could not watch machines: %w", err)
+	}
+
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case uid := <-done:
+			delete(observations, uid)
+
+			if quitTogether && len(observations) == 0 {
+				return nil
+			}
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				return fmt.Errorf("could not watch machines: watch ended")
+			}
+
+			// Machines which are removed are no longer monitored once their VMM
+			// has exited.
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if machine, ok := event.Object.(*machineapi.Machine); ok {
+					consider(machine)
+				}
+			}
+		}
+	}
+}
+
+// monitor follows the events of the provided machine until it exits and then
+// restarts it for as long as its restart policy demands, with an exponential
+// backoff between consecutive restarts.  The startup parameter indicates
+// whether the machine had already exited when it was first encountered.
+func monitor(ctx context.Context, controller machineapi.MachineService, machine *machineapi.Machine, startup bool) {
+	backoff := restartBackoffInitial
+
+	for {
+		switch machine.Status.State {
+		case machineapi.MachineStateFailed,
+			machineapi.MachineStateExited,
+			machineapi.MachineStateErrored,
+			machineapi.MachineStateUnknown:
+		default:
+			if !follow(ctx, controller, machine) {
+				return
+			}
+		}
+
+		switch machine.Spec.RestartPolicy.Name {
+		case "", machineapi.RestartPolicyNo:
+			return
+		}
+
+		if time.Since(machine.Status.StartedAt) >= restartBackoffReset {
+			backoff = restartBackoffInitial
+		}
+
+		log.G(ctx).Infof("%s : restarting in %s", machine.Name, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, restartBackoffMax)
+
+		// Re-read the machine, since the state reported by the platform driver
+		// when it exited is not conclusive, and it may have been stopped or
+		// removed explicitly in the meantime.
+		latest, err := controller.Get(ctx, machine.DeepCopyObject().(*machineapi.Machine))
+		if err != nil {
+			log.G(ctx).Debugf("%s : %v", machine.Name, err)
+			return
+		}
+
+		if !latest.Spec.RestartPolicy.ShouldRestart(latest.Status, startup) {
+			return
+		}
+
+		startup = false
+
+		machine, err = restart(ctx, controller, latest)
+		if err != nil {
+			log.G(ctx).Errorf("could not restart %s: %v", latest.Name, err)
+			return
+		}
+
+		log.G(ctx).Infof("%s : restarted (%d)", machine.Name, machine.Status.RestartCount)
+	}
+}
+
+// follow logs the events of the provided machine, including the transitions
+// of its health, until it exits.  It returns false if the context was
+// cancelled beforehand.
+func follow(ctx context.Context, controller machineapi.MachineService, machine *machineapi.Machine) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs, err := controller.Watch(ctx, machine)
+	if err != nil {
+		log.G(ctx).Debugf("could not listen for status updates for %s: %v", machine.Name, err)
+		return true
+	}
+
+	// Machines without a health check are not polled, since receiving from a nil
+	// channel blocks forever.
+	var poll <-chan time.Time
+	var health machineapi.MachineHealthStatus
+
+	if machine.Spec.HealthCheck != nil {
+		ticker := time.NewTicker(healthPollInterval)
+		defer ticker.Stop()
+
+		poll = ticker.C
+	}
+
+	for {
+		// Wait on any channel
+		select {
+		case <-poll:
+			latest, err := controller.Get(ctx, machine)
+			if err != nil || latest.Status.Health == nil || latest.Status.Health.Status == health {
+				continue
+			}
+
+			health = latest.Status.Health.Status
+
+			if health == machineapi.MachineHealthUnhealthy && latest.Status.Health.LastError != "" {
+				log.G(ctx).Infof("%s : %s (%s)", latest.Name, health, latest.Status.Health.LastError)
+			} else {
+				log.G(ctx).Infof("%s : %s", latest.Name, health)
+			}
+
+		case update := <-events:
+			if update.Status.ExitReason != "" {
+				log.G(ctx).Infof("%s : %s (%s)", update.Name, update.Status.State.String(), update.Status.ExitReason)
+			} else {
+				log.G(ctx).Infof("%s : %s", update.Name, update.Status.State.String())
+			}
+
+			switch update.Status.State {
+			case machineapi.MachineStateExited,
+				machineapi.MachineStateFailed,
+				machineapi.MachineStateErrored:
+				return true
+			}
+
+		case err := <-errs:
+			if errors.Is(err, qmp.ErrAcceptedNonEvent) {
+				continue
+			}
+
+			// The platform driver can no longer observe the machine, which is
+			// usually the result of its VMM having exited.
+			log.G(ctx).Errorf("%v", err)
+			return ctx.Err() == nil
+
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// restart instantiates the VMM of the provided machine anew and starts it.
+func restart(ctx context.Context, controller machineapi.MachineService, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	// The VMM of a machine which has crashed may still be alive, e.g. QEMU
+	// retains guests which have panicked.
+	if machine.Status.State != machineapi.MachineStateExited {
+		if _, err := controller.Stop(ctx, machine); err != nil {
+			return nil, fmt.Errorf("could not stop: %w", err)
+		}
+	}
+
+	machine.Status.RestartCount++
+
+	machine, err := controller.Create(ctx, machine)
+	if err != nil {
+		return nil, fmt.Errorf("could not create: %w", err)
+	}
+
+	return controller.Start(ctx, machine)
+}
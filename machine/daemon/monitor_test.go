@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/machine/store"
+)
+
+// testController is a machine service which reports the machines whose events
+// are followed.
+type testController struct {
+	machineapi.MachineService
+	watched chan types.UID
+}
+
+func (controller *testController) Get(_ context.Context, machine *machineapi.Machine) (*machineapi.Machine, error) {
+	return machine, nil
+}
+
+func (controller *testController) Watch(ctx context.Context, machine *machineapi.Machine) (chan *machineapi.Machine, chan error, error) {
+	controller.watched <- machine.UID
+	return make(chan *machineapi.Machine), make(chan error), nil
+}
+
+func newTestMachine(uid, platform string) *machineapi.Machine {
+	return &machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: uid,
+			UID:  types.UID(uid),
+		},
+		Spec: machineapi.MachineSpec{
+			Platform: platform,
+		},
+		Status: machineapi.MachineStatus{
+			State: machineapi.MachineStateRunning,
+		},
+	}
+}
+
+func TestMonitorWatch(t *testing.T) {
+	machines, err := store.NewEmbeddedStore[machineapi.MachineSpec, machineapi.MachineStatus](t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	create := func(machine *machineapi.Machine) {
+		t.Helper()
+
+		if err := machines.Create(ctx, "/machines/"+string(machine.UID), machine, machine, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	controller := &testController{watched: make(chan types.UID)}
+	controllers := map[string]machineapi.MachineService{"test": controller}
+
+	create(newTestMachine("existing", "test"))
+
+	errs := make(chan error)
+	go func() {
+		errs <- Monitor(ctx, controllers, machines, nil, false)
+	}()
+
+	next := func() types.UID {
+		t.Helper()
+
+		select {
+		case uid := <-controller.watched:
+			return uid
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the machine to be followed")
+		}
+
+		return ""
+	}
+
+	if uid := next(); uid != "existing" {
+		t.Errorf("followed %s, want existing", uid)
+	}
+
+	// Machines of platforms without a controller are not monitored.
+	create(newTestMachine("other", "other"))
+	create(newTestMachine("created", "test"))
+
+	if uid := next(); uid != "created" {
+		t.Errorf("followed %s, want created", uid)
+	}
+
+	cancel()
+
+	if err := <-errs; err != nil {
+		t.Errorf("Monitor() error = %v", err)
+	}
+}
+
+func TestMonitorQuitTogether(t *testing.T) {
+	machines, err := store.NewEmbeddedStore[machineapi.MachineSpec, machineapi.MachineStatus](t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	controllers := map[string]machineapi.MachineService{
+		"test": &testController{watched: make(chan types.UID)},
+	}
+
+	if err := Monitor(ctx, controllers, machines, nil, true); err != nil {
+		t.Errorf("Monitor() error = %v", err)
+	}
+
+	if ctx.Err() != nil {
+		t.Errorf("Monitor() did not return before the context was cancelled")
+	}
+}
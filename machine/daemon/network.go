@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// NetworkV1alpha1 returns a constructor of the network service of the provided
+// driver which returns a client of the daemon whilst it is running and the
+// service returned by the provided direct constructor otherwise.
+func NetworkV1alpha1(driver string, direct func(context.Context, ...any) (networkv1alpha1.NetworkService, error)) func(context.Context, ...any) (networkv1alpha1.NetworkService, error) {
+	return func(ctx context.Context, opts ...any) (networkv1alpha1.NetworkService, error) {
+		if len(opts) == 0 && !IsDirect(ctx) && Running(ctx) {
+			return NewNetworkV1alpha1Client(ctx, driver)
+		}
+
+		return direct(ctx, opts...)
+	}
+}
+
+// networkV1alpha1Client implements networkv1alpha1.NetworkService by
+// forwarding each method to the daemon.
+type networkV1alpha1Client struct {
+	*client
+}
+
+// NewNetworkV1alpha1Client returns a client of the network service of the
+// provided driver of the daemon, which is wrapped by the same Zip API service
+// handler as the services of the drivers which it is served by.
+func NewNetworkV1alpha1Client(ctx context.Context, driver string) (networkv1alpha1.NetworkService, error) {
+	return networkv1alpha1.NewNetworkServiceHandler(ctx, &networkV1alpha1Client{newClient(ctx, ResourceNetworks, driver)})
+}
+
+// Create implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) Create(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[*networkv1alpha1.Network, *networkv1alpha1.Network](ctx, c.client, "create", network)
+}
+
+// Start implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) Start(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[*networkv1alpha1.Network, *networkv1alpha1.Network](ctx, c.client, "start", network)
+}
+
+// Stop implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) Stop(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[*networkv1alpha1.Network, *networkv1alpha1.Network](ctx, c.client, "stop", network)
+}
+
+// Update implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) Update(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[*networkv1alpha1.Network, *networkv1alpha1.Network](ctx, c.client, "update", network)
+}
+
+// Delete implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) Delete(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[*networkv1alpha1.Network, *networkv1alpha1.Network](ctx, c.client, "delete", network)
+}
+
+// Get implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) Get(ctx context.Context, network *networkv1alpha1.Network) (*networkv1alpha1.Network, error) {
+	return call[*networkv1alpha1.Network, *networkv1alpha1.Network](ctx, c.client, "get", network)
+}
+
+// List implements kraftkit.sh/api/network/v1alpha1.NetworkService
+func (c *networkV1alpha1Client) List(ctx context.Context, networks *networkv1alpha1.NetworkList) (*networkv1alpha1.NetworkList, error) {
+	return call[*networkv1alpha1.NetworkList, *networkv1alpha1.NetworkList](ctx, c.client, "list", networks)
+}
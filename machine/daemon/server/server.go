@@ -0,0 +1,397 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package server serves the machine, network and volume services of the host
+// on behalf of the kraft daemon.  See kraftkit.sh/machine/daemon for the
+// protocol and the client.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	zip "api.zip"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/daemon"
+	"kraftkit.sh/machine/network"
+	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/machine/volume"
+)
+
+// Server serves the services of the drivers which are supported by the host.
+type Server struct {
+	ctx      context.Context
+	machines map[string]machinev1alpha1.MachineService
+	networks map[string]networkv1alpha1.NetworkService
+	volumes  map[string]volumev1alpha1.VolumeService
+}
+
+// New instantiates the services of all drivers which are supported by the
+// host.  The services are always instantiated directly and drivers which
+// cannot be instantiated are skipped.  Each service is served through a Zip
+// API service handler which rehydrates the configuration of the driver of the
+// requested object, as the configuration does not survive its JSON
+// representation.
+func New(ctx context.Context) (*Server, error) {
+	ctx = daemon.WithDirect(ctx)
+
+	server := Server{
+		ctx:      ctx,
+		machines: map[string]machinev1alpha1.MachineService{},
+		networks: map[string]networkv1alpha1.NetworkService{},
+		volumes:  map[string]volumev1alpha1.VolumeService{},
+	}
+
+	for platform, strategy := range mplatform.Strategies() {
+		service, err := strategy.NewMachineV1alpha1(ctx)
+		if err != nil {
+			log.G(ctx).
+				WithField("platform", platform.String()).
+				Debugf("could not instantiate machine driver: %v", err)
+			continue
+		}
+
+		service, err = machinev1alpha1.NewMachineServiceHandler(
+			ctx,
+			service,
+			zip.WithBefore(rehydrate(service.List, func(status *machinev1alpha1.MachineStatus) *interface{} {
+				return &status.PlatformConfig
+			})),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		server.machines[platform.String()] = service
+	}
+
+	for driver, strategy := range network.Strategies() {
+		service, err := strategy.NewNetworkV1alpha1(ctx)
+		if err != nil {
+			log.G(ctx).
+				WithField("driver", driver).
+				Debugf("could not instantiate network driver: %v", err)
+			continue
+		}
+
+		service, err = networkv1alpha1.NewNetworkServiceHandler(
+			ctx,
+			service,
+			zip.WithBefore(rehydrate(service.List, func(status *networkv1alpha1.NetworkStatus) *interface{} {
+				return &status.DriverConfig
+			})),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		server.networks[driver] = service
+	}
+
+	for driver, strategy := range volume.Strategies() {
+		service, err := strategy.NewVolumeV1alpha1(ctx)
+		if err != nil {
+			log.G(ctx).
+				WithField("driver", driver).
+				Debugf("could not instantiate volume driver: %v", err)
+			continue
+		}
+
+		service, err = volumev1alpha1.NewVolumeServiceHandler(
+			ctx,
+			service,
+			zip.WithBefore(rehydrate(service.List, func(status *volumev1alpha1.VolumeStatus) *interface{} {
+				return &status.DriverConfig
+			})),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		server.volumes[driver] = service
+	}
+
+	if len(server.machines) == 0 {
+		return nil, fmt.Errorf("no machine driver is supported by the host")
+	}
+
+	return &server, nil
+}
+
+// Machines returns the machine services of the server by the name of their
+// platform.
+func (server *Server) Machines() map[string]machinev1alpha1.MachineService {
+	return server.machines
+}
+
+// Serve accepts connections on the provided listener until the context is
+// cancelled.
+func (server *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{
+		Handler: server,
+		// The services rely on the logger and configuration of the context.
+		BaseContext: func(net.Listener) context.Context {
+			return server.ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == daemon.PingPath {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resource, driver, method, ok := daemon.ParsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	log.G(r.Context()).
+		WithField("resource", resource).
+		WithField("driver", driver).
+		WithField("method", method).
+		Trace("handling request")
+
+	switch resource {
+	case daemon.ResourceMachines:
+		service, ok := server.machines[driver]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported platform driver: %s", driver), http.StatusNotFound)
+			return
+		}
+
+		serveMachine(w, r, service, method)
+
+	case daemon.ResourceNetworks:
+		service, ok := server.networks[driver]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported network driver strategy: %s", driver), http.StatusNotFound)
+			return
+		}
+
+		serveNetwork(w, r, service, method)
+
+	case daemon.ResourceVolumes:
+		service, ok := server.volumes[driver]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported volume driver strategy: %s", driver), http.StatusNotFound)
+			return
+		}
+
+		serveVolume(w, r, service, method)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveMachine invokes the provided method of the machine service.
+func serveMachine(w http.ResponseWriter, r *http.Request, service machinev1alpha1.MachineService, method string) {
+	switch method {
+	case "create":
+		serve(w, r, service.Create)
+	case "start":
+		serve(w, r, service.Start)
+	case "pause":
+		serve(w, r, service.Pause)
+	case "stop":
+		serve(w, r, service.Stop)
+	case "update":
+		serve(w, r, service.Update)
+	case "delete":
+		serve(w, r, service.Delete)
+	case "get":
+		serve(w, r, service.Get)
+	case "list":
+		serve(w, r, service.List)
+	case "watch":
+		serveStream(w, r, service.Watch)
+	case "logs":
+		serveStream(w, r, service.Logs)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveNetwork invokes the provided method of the network service.
+func serveNetwork(w http.ResponseWriter, r *http.Request, service networkv1alpha1.NetworkService, method string) {
+	switch method {
+	case "create":
+		serve(w, r, service.Create)
+	case "start":
+		serve(w, r, service.Start)
+	case "stop":
+		serve(w, r, service.Stop)
+	case "update":
+		serve(w, r, service.Update)
+	case "delete":
+		serve(w, r, service.Delete)
+	case "get":
+		serve(w, r, service.Get)
+	case "list":
+		serve(w, r, service.List)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveVolume invokes the provided method of the volume service.
+func serveVolume(w http.ResponseWriter, r *http.Request, service volumev1alpha1.VolumeService, method string) {
+	switch method {
+	case "create":
+		serve(w, r, service.Create)
+	case "delete":
+		serve(w, r, service.Delete)
+	case "get":
+		serve(w, r, service.Get)
+	case "list":
+		serve(w, r, service.List)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// rehydrate returns a hook which replaces the configuration of the driver of
+// the requested object, as returned by the provided accessor, with that of the
+// object of the same UID which is listed by the direct service.  The
+// configuration is typed by the driver and is therefore only decoded as a
+// generic map from the JSON representation of the object.
+func rehydrate[Spec, Status any](list func(context.Context, *zip.ObjectList[Spec, Status]) (*zip.ObjectList[Spec, Status], error), config func(*Status) *interface{}) zip.OnBefore {
+	return func(ctx context.Context, req zip.ReferenceObject) (any, error) {
+		obj, ok := req.(*zip.Object[Spec, Status])
+		if !ok || obj.UID == "" {
+			return req, nil
+		}
+
+		// Objects which have not been received over the socket, e.g. those of
+		// the monitor of the daemon, retain the configuration as typed by the
+		// driver.
+		if _, decoded := (*config(&obj.Status)).(map[string]interface{}); !decoded {
+			return req, nil
+		}
+
+		objs, err := list(ctx, &zip.ObjectList[Spec, Status]{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stored := range objs.Items {
+			if stored.UID == obj.UID {
+				*config(&obj.Status) = *config(&stored.Status)
+				break
+			}
+		}
+
+		return obj, nil
+	}
+}
+
+// serve decodes the input of the provided method from the request and responds
+// with its output, or its error.
+func serve[In, Out any](w http.ResponseWriter, r *http.Request, fn func(context.Context, In) (Out, error)) {
+	var in In
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, fmt.Sprintf("could not decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	out, err := fn(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", daemon.ContentType)
+
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		log.G(r.Context()).Errorf("could not encode response: %v", err)
+	}
+}
+
+// serveStream decodes the input of the provided streaming method from the
+// request and responds with each of its values and errors until the client
+// disconnects.
+func serveStream[In, Out any](w http.ResponseWriter, r *http.Request, fn func(context.Context, In) (chan Out, chan error, error)) {
+	var in In
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, fmt.Sprintf("could not decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	values, errs, err := fn(ctx, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", daemon.ContentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	for {
+		var event daemon.StreamEvent[Out]
+
+		select {
+		case value, ok := <-values:
+			if !ok {
+				return
+			}
+
+			event.Value = value
+
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			} else if err == nil {
+				continue
+			}
+
+			event.Err = err.Error()
+
+		case <-ctx.Done():
+			return
+		}
+
+		if err := encoder.Encode(event); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
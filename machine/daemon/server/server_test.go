@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+type testConfig struct {
+	Name string
+}
+
+func TestRehydrate(t *testing.T) {
+	stored := machinev1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{UID: "a"},
+		Status:     machinev1alpha1.MachineStatus{PlatformConfig: testConfig{Name: "stored"}},
+	}
+
+	tests := []struct {
+		name string
+		in   interface{}
+		uid  string
+		want interface{}
+	}{
+		{
+			name: "decoded",
+			in:   map[string]interface{}{"Name": "decoded"},
+			uid:  "a",
+			want: testConfig{Name: "stored"},
+		},
+		{
+			name: "typed",
+			in:   testConfig{Name: "typed"},
+			uid:  "a",
+			want: testConfig{Name: "typed"},
+		},
+		{
+			name: "without uid",
+			in:   map[string]interface{}{"Name": "decoded"},
+			want: map[string]interface{}{"Name": "decoded"},
+		},
+		{
+			name: "unknown uid",
+			in:   map[string]interface{}{"Name": "decoded"},
+			uid:  "b",
+			want: map[string]interface{}{"Name": "decoded"},
+		},
+		{
+			name: "nil",
+			uid:  "a",
+		},
+	}
+
+	list := func(context.Context, *machinev1alpha1.MachineList) (*machinev1alpha1.MachineList, error) {
+		return &machinev1alpha1.MachineList{
+			Items: []machinev1alpha1.Machine{stored},
+		}, nil
+	}
+
+	before := rehydrate(list, func(status *machinev1alpha1.MachineStatus) *interface{} {
+		return &status.PlatformConfig
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := &machinev1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{UID: types.UID(tt.uid)},
+				Status:     machinev1alpha1.MachineStatus{PlatformConfig: tt.in},
+			}
+
+			got, err := before(context.Background(), machine)
+			if err != nil {
+				t.Fatalf("rehydrate() error = %v", err)
+			}
+
+			if config := got.(*machinev1alpha1.Machine).Status.PlatformConfig; !reflect.DeepEqual(config, tt.want) {
+				t.Errorf("rehydrate() = %v, want %v", config, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package daemon
+
+import (
+	"context"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// VolumeV1alpha1 returns a constructor of the volume service of the provided
+// driver which returns a client of the daemon whilst it is running and the
+// service returned by the provided direct constructor otherwise.
+func VolumeV1alpha1(driver string, direct func(context.Context, ...any) (volumev1alpha1.VolumeService, error)) func(context.Context, ...any) (volumev1alpha1.VolumeService, error) {
+	return func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+		if len(opts) == 0 && !IsDirect(ctx) && Running(ctx) {
+			return NewVolumeV1alpha1Client(ctx, driver)
+		}
+
+		return direct(ctx, opts...)
+	}
+}
+
+// volumeV1alpha1Client implements volumev1alpha1.VolumeService by forwarding
+// each method to the daemon.
+type volumeV1alpha1Client struct {
+	*client
+}
+
+// NewVolumeV1alpha1Client returns a client of the volume service of the
+// provided driver of the daemon, which is wrapped by the same Zip API service
+// handler as the services of the drivers which it is served by.
+func NewVolumeV1alpha1Client(ctx context.Context, driver string) (volumev1alpha1.VolumeService, error) {
+	return volumev1alpha1.NewVolumeServiceHandler(ctx, &volumeV1alpha1Client{newClient(ctx, ResourceVolumes, driver)})
+}
+
+// Create implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (c *volumeV1alpha1Client) Create(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[*volumev1alpha1.Volume, *volumev1alpha1.Volume](ctx, c.client, "create", volume)
+}
+
+// Delete implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (c *volumeV1alpha1Client) Delete(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[*volumev1alpha1.Volume, *volumev1alpha1.Volume](ctx, c.client, "delete", volume)
+}
+
+// Get implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (c *volumeV1alpha1Client) Get(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	return call[*volumev1alpha1.Volume, *volumev1alpha1.Volume](ctx, c.client, "get", volume)
+}
+
+// List implements kraftkit.sh/api/volume/v1alpha1.VolumeService
+func (c *volumeV1alpha1Client) List(ctx context.Context, volumes *volumev1alpha1.VolumeList) (*volumev1alpha1.VolumeList, error) {
+	return call[*volumev1alpha1.VolumeList, *volumev1alpha1.VolumeList](ctx, c.client, "list", volumes)
+}
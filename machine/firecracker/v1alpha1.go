@@ -6,10 +6,12 @@ package firecracker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	zip "api.zip"
@@ -17,6 +19,7 @@ import (
 	firecracker "github.com/firecracker-microvm/firecracker-go-sdk"
 	"github.com/firecracker-microvm/firecracker-go-sdk/client/models"
 	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
 	goprocess "github.com/shirou/gopsutil/v3/process"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -30,7 +33,10 @@ import (
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/run"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/cgroup"
+	"kraftkit.sh/machine/health"
 	"kraftkit.sh/machine/network/macaddr"
+	"kraftkit.sh/machine/network/portforward"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
 	"kraftkit.sh/unikraft/export/v0/vfscore"
@@ -40,6 +46,7 @@ const (
 	FirecrackerBin         = "firecracker"
 	DefaultClientTimout    = time.Second * 5
 	FirecrackerMemoryScale = 1024 * 1024
+	DefaultWatchInterval   = time.Second
 )
 
 // machineV1alpha1Service ...
@@ -73,8 +80,8 @@ func NewMachineV1alpha1Service(ctx context.Context, opts ...any) (machinev1alpha
 // Create implements kraftkit.sh/api/machine/v1alpha1.MachineService.Create
 func (service *machineV1alpha1Service) Create(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
 	// Start with fail-safe checks for unsupported specification declarations.
-	if len(machine.Spec.Ports) > 0 {
-		return machine, fmt.Errorf("kraftkit does not yet support port forwarding to firecracker (contributions welcome): please use a network instead")
+	if len(machine.Spec.Ports) > 0 && len(machine.Spec.Networks) == 0 {
+		return machine, fmt.Errorf("cannot publish ports of firecracker instance without a network")
 	}
 
 	if machine.Status.KernelPath == "" {
@@ -85,11 +92,22 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("cannot create firecracker instance with emulation")
 	}
 
+	if machine.Spec.Debug != nil {
+		return machine, fmt.Errorf("cannot create firecracker instance with a GDB server")
+	}
+
+	if machine.Spec.CrashDump {
+		return machine, fmt.Errorf("cannot create firecracker instance with crash dumps")
+	}
+
 	if machine.ObjectMeta.UID == "" {
 		machine.ObjectMeta.UID = uuid.NewUUID()
 	}
 
 	machine.Status.State = machinev1alpha1.MachineStateUnknown
+	machine.Status.StoppedExplicitly = false
+	machine.Status.ExitedAt = time.Time{}
+	machine.Status.ExitReason = ""
 
 	if len(machine.Status.StateDir) == 0 {
 		machine.Status.StateDir = filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, string(machine.ObjectMeta.UID))
@@ -156,6 +174,12 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		LogPath:    fcLogFile,
 	}
 
+	// Remove the API socket of a previous instance of the machine, e.g. when it
+	// is restarted, since Firecracker refuses to bind to an existing socket.
+	if err := os.Remove(fccfg.SocketPath); err != nil && !os.IsNotExist(err) {
+		return machine, err
+	}
+
 	defer func() {
 		if err != nil {
 			machine.Status.State = machinev1alpha1.MachineStateFailed
@@ -182,10 +206,23 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("could not prepare firecracker executable: %v", err)
 	}
 
-	process, err := exec.NewProcessFromExecutable(e,
+	eopts := []exec.ExecOption{
 		exec.WithStdout(logFile),
 		exec.WithDetach(true),
-	)
+	}
+
+	// Create the VMM within its own cgroup which constrains the host resources it
+	// consumes.  Without any limits, failing to do so, e.g. when running
+	// unprivileged or on a host without cgroup v2, is not fatal.
+	if path, err := cgroup.Create(machine); err == nil {
+		eopts = append(eopts, exec.WithCgroup(path))
+	} else if len(machine.Spec.Resources.Limits) > 0 {
+		return machine, fmt.Errorf("could not create cgroup: %w", err)
+	} else {
+		log.G(ctx).Debugf("not creating machine within cgroup: %v", err)
+	}
+
+	process, err := exec.NewProcessFromExecutable(e, eopts...)
 	if err != nil {
 		return machine, fmt.Errorf("could not prepare firecracker process: %v", err)
 	}
@@ -235,11 +272,53 @@ watch:
 
 	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
 
-	kernelArgs, err := ukargparse.Parse(machine.Spec.KernelArgs...)
+	// Set the logger information.
+	if service.debug {
+		if _, err := client.PutLogger(ctx, &models.Logger{
+			Level:         firecracker.String("Debug"),
+			LogPath:       firecracker.String(filepath.Join(machine.Status.StateDir, "firecracker.log")),
+			ShowLevel:     firecracker.Bool(true),
+			ShowLogOrigin: firecracker.Bool(true),
+		}); err != nil {
+			return machine, err
+		}
+	}
+
+	if len(machine.Status.SnapshotDir) > 0 {
+		err = service.restore(ctx, client, machine)
+	} else {
+		err = service.configure(ctx, client, machine, fstab)
+	}
 	if err != nil {
 		return machine, err
 	}
 
+	machine.Status.Pid = int32(pid)
+
+	// Publish the ports of the machine via a forwarder on the host which relays
+	// traffic to the IP address of the machine on its network.
+	if err := portforward.Start(ctx, machine); err != nil {
+		// Do not leave behind a VMM whose ports cannot be reached.
+		if err2 := process.Kill(); err2 != nil {
+			err = errors.Join(err, fmt.Errorf("could not kill firecracker process: %w", err2))
+		}
+
+		return machine, err
+	}
+
+	machine.Status.State = machinev1alpha1.MachineStateCreated
+
+	return machine, nil
+}
+
+// configure prepares the machine for booting via the API socket of its
+// Firecracker process.
+func (service *machineV1alpha1Service) configure(ctx context.Context, client *firecracker.Client, machine *machinev1alpha1.Machine, fstab []string) error {
+	kernelArgs, err := ukargparse.Parse(machine.Spec.KernelArgs...)
+	if err != nil {
+		return err
+	}
+
 	if len(fstab) > 0 {
 		kernelArgs = append(kernelArgs,
 			vfscore.ParamVfsFstab.WithValue(fstab),
@@ -253,37 +332,73 @@ watch:
 		// as the last byte.
 		startMac, err := macaddr.GenerateMacAddress(true)
 		if err != nil {
-			return machine, err
+			return err
 		}
 
 		i := 0 // host network ID.
 
+		// Each network device is configured statically via command-line
+		// arguments, unless the built-in arguments configure them already.
+		static := !kernelArgs.Contains(uknetdev.ParamIpv4Addr) && !kernelArgs.Contains(uknetdev.ParamIp)
+		var ips, ips6 []uknetdev.IpEntry
+
 		// Iterate over each interface of each network interface associated with
 		// this machine and attach it as a device.
-		for _, network := range machine.Spec.Networks {
-			for _, iface := range network.Interfaces {
+		for j, network := range machine.Spec.Networks {
+			for k, iface := range network.Interfaces {
 				mac := iface.Spec.MacAddress
 				if mac == "" {
 					// Increase the MAC address value by 1 such that we are able to
 					// identify interface IDs.
 					startMac = macaddr.IncrementMacAddress(startMac)
 					mac = startMac.String()
+					machine.Spec.Networks[j].Interfaces[k].Spec.MacAddress = mac
 				}
 
-				if _, err := client.PutGuestNetworkInterfaceByID(ctx, network.IfName, &models.NetworkInterface{
+				// The identifier must be unique amongst all interfaces of the machine,
+				// which may be attached to the same network more than once.
+				ifaceID := fmt.Sprintf("eth%d", i)
+
+				if _, err := client.PutGuestNetworkInterfaceByID(ctx, ifaceID, &models.NetworkInterface{
 					GuestMac:    mac,
 					HostDevName: &iface.Spec.IfName,
-					IfaceID:     &network.IfName,
+					IfaceID:     &ifaceID,
 				}); err != nil {
-					return machine, err
+					return err
+				}
+
+				// The nameservers of the network, e.g. its embedded DNS responder,
+				// are passed alongside the address of the same family.
+				var dns, dns6 []string
+				for _, nameserver := range network.Nameservers {
+					if strings.Contains(nameserver, ":") {
+						dns6 = append(dns6, nameserver)
+					} else {
+						dns = append(dns, nameserver)
+					}
+				}
+
+				gateway := iface.Spec.Gateway
+				if gateway == "" {
+					gateway = network.Gateway
+				}
+
+				gateway6 := iface.Spec.Gateway6
+				if gateway6 == "" {
+					gateway6 = network.Gateway6
 				}
 
-				// Assign the first interface statically via command-line arguments, also
-				// checking if the built-in arguments for
-				if !kernelArgs.Contains(uknetdev.ParamIpv4Addr) && i == 0 {
+				// The IPv4 and IPv6 configurations are passed separately, where the
+				// n-th entry applies to the n-th device.
+				ips = append(ips, uknetdev.NewIpEntry(iface.Spec.IP, network.Netmask, gateway, dns...))
+				ips6 = append(ips6, uknetdev.NewIpEntry(iface.Spec.IP6, network.Netmask6, gateway6, dns6...))
+
+				// Kernels which predate netdev.ip only accept the configuration of
+				// the first interface.
+				if static && i == 0 && iface.Spec.IP != "" {
 					kernelArgs = append(kernelArgs,
 						uknetdev.ParamIpv4Addr.WithValue(iface.Spec.IP),
-						uknetdev.ParamIpv4GwAddr.WithValue(network.Gateway),
+						uknetdev.ParamIpv4GwAddr.WithValue(gateway),
 						uknetdev.ParamIpv4SubnetMask.WithValue(network.Netmask),
 					)
 				}
@@ -292,6 +407,33 @@ watch:
 				i++
 			}
 		}
+
+		if static {
+			for _, param := range []struct {
+				param   ukargparse.Param
+				family  string
+				entries []uknetdev.IpEntry
+			}{
+				{uknetdev.ParamIp, "IPv4", ips},
+				{uknetdev.ParamIp6, "IPv6", ips6},
+			} {
+				if kernelArgs.Contains(param.param) {
+					continue
+				}
+
+				values, skipped := uknetdev.Positional(param.entries)
+				if len(skipped) > 0 {
+					log.G(ctx).
+						WithField("machine", machine.Name).
+						WithField("devices", skipped).
+						Warnf("%s addresses of network devices which follow a device without one are not configured", param.family)
+				}
+
+				if len(values) > 0 {
+					kernelArgs = append(kernelArgs, param.param.WithValue(values))
+				}
+			}
+		}
 	}
 
 	// TODO(nderjung): This is standard "Unikraft" positional argument syntax
@@ -310,7 +452,7 @@ watch:
 		VcpuCount:  firecracker.Int64(machine.Spec.Resources.Requests.Cpu().Value()),
 		MemSizeMib: firecracker.Int64(machine.Spec.Resources.Requests.Memory().Value() / FirecrackerMemoryScale),
 	}); err != nil {
-		return machine, err
+		return err
 	}
 
 	// Set the boot source configuration.
@@ -319,25 +461,17 @@ watch:
 		InitrdPath:      machine.Status.InitrdPath,
 		BootArgs:        run.BootArgsPrepare(args...),
 	}); err != nil {
-		return machine, err
-	}
-
-	// Set the logger information.
-	if service.debug {
-		if _, err := client.PutLogger(ctx, &models.Logger{
-			Level:         firecracker.String("Debug"),
-			LogPath:       firecracker.String(filepath.Join(machine.Status.StateDir, "firecracker.log")),
-			ShowLevel:     firecracker.Bool(true),
-			ShowLogOrigin: firecracker.Bool(true),
-		}); err != nil {
-			return machine, err
-		}
+		return err
 	}
 
-	machine.Status.Pid = int32(pid)
-	machine.Status.State = machinev1alpha1.MachineStateCreated
+	return nil
+}
 
-	return machine, nil
+// DecodePlatformConfig converts the platform configuration of a Firecracker
+// machine, as it is retrieved from the store or decoded from JSON, into
+// FirecrackerConfig.
+func DecodePlatformConfig(platformConfig interface{}) (*FirecrackerConfig, error) {
+	return getFirecrackerConfigFromPlatformConfig(platformConfig)
 }
 
 func getFirecrackerConfigFromPlatformConfig(platformConfig interface{}) (*FirecrackerConfig, error) {
@@ -351,6 +485,14 @@ func getFirecrackerConfigFromPlatformConfig(platformConfig interface{}) (*Firecr
 		return &fccfg, nil
 	}
 
+	// Attempt to decode a mapstructure version of the same configuration, e.g.
+	// when it has been decoded from JSON.
+	if _, ok := platformConfig.(map[string]interface{}); ok {
+		if err := mapstructure.Decode(platformConfig, &fccfg); err == nil {
+			return &fccfg, nil
+		}
+	}
+
 	return nil, fmt.Errorf("could not cast firecracker platform config from store")
 }
 
@@ -364,36 +506,54 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 	events := make(chan *machinev1alpha1.Machine)
 	errs := make(chan error)
 
-	go service.watch(ctx, machine, &events, &errs)
+	// The machine is polled into a copy, such that it is not shared with the
+	// caller.
+	go service.watch(ctx, machine.DeepCopyObject().(*machinev1alpha1.Machine), &events, &errs)
 
 	return events, errs, nil
 }
 
+// watch polls the state of the machine via its API socket, since Firecracker
+// does not emit events, and sends the machine through the channel whenever its
+// state changes, e.g. when it is paused or resumed.  Watching stops once the
+// machine has exited, the machine can no longer be polled or the context is
+// cancelled.
 func (service *machineV1alpha1Service) watch(ctx context.Context, machine *machinev1alpha1.Machine, events *chan *machinev1alpha1.Machine, errs *chan error) {
+	var state machinev1alpha1.MachineState
+
 	for {
-		select {
-		case <-ctx.Done():
-			log.G(ctx).Info("context cancelled (watch)")
-			*errs <- ctx.Err()
-			return
-		default:
-			process, err := os.FindProcess(int(machine.Status.Pid))
-			if err != nil {
-				return
+		machine, err := service.Get(ctx, machine)
+		if err != nil {
+			select {
+			case *errs <- err:
+			case <-ctx.Done():
 			}
 
-			state, err := process.Wait()
-			if err != nil {
-				*errs <- err
+			return
+		}
+
+		if machine.Status.State != state {
+			state = machine.Status.State
+
+			select {
+			case *events <- machine.DeepCopyObject().(*machinev1alpha1.Machine):
+			case <-ctx.Done():
 				return
 			}
 
-			if state.Exited() {
-				machine.Status.State = machinev1alpha1.MachineStateExited
-				machine.Status.ExitCode = state.ExitCode()
+			switch state {
+			case machinev1alpha1.MachineStateExited,
+				machinev1alpha1.MachineStateFailed,
+				machinev1alpha1.MachineStateErrored:
+				return
 			}
+		}
 
-			*events <- machine
+		select {
+		case <-ctx.Done():
+			log.G(ctx).Info("context cancelled (watch)")
+			return
+		case <-time.After(DefaultWatchInterval):
 		}
 	}
 }
@@ -406,24 +566,62 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 	}
 
 	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
+
+	info, err := client.GetInstanceInfo(ctx)
+	if err != nil {
+		return machine, fmt.Errorf("could not query machine status via API socket: %v", err)
+	}
+
+	// A machine which has previously been paused is resumed rather than booted.
+	if *info.Payload.State == models.InstanceInfoStatePaused {
+		if _, err := client.PatchVM(ctx, &models.VM{
+			State: firecracker.String(models.VMStateResumed),
+		}); err != nil {
+			return machine, fmt.Errorf("could not resume firecracker instance: %v", err)
+		}
+
+		machine.Status.State = machinev1alpha1.MachineStateRunning
+
+		return machine, nil
+	}
+
 	action := models.InstanceActionInfoActionTypeInstanceStart
-	info := models.InstanceActionInfo{
+	actionInfo := models.InstanceActionInfo{
 		ActionType: &action,
 	}
 
-	if _, err := client.CreateSyncAction(ctx, &info); err != nil {
+	if _, err := client.CreateSyncAction(ctx, &actionInfo); err != nil {
 		return machine, err
 	}
 
 	machine.Status.State = machinev1alpha1.MachineStateRunning
 	machine.Status.StartedAt = time.Now()
 
+	if err := health.Start(ctx, machine); err != nil {
+		return machine, err
+	}
+
 	return machine, nil
 }
 
 // Pause implements kraftkit.sh/api/machine/v1alpha1.MachineService
 func (service *machineV1alpha1Service) Pause(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
-	panic("not implemented: kraftkit.sh/machine/firecracker.machineV1alpha1Service.Pause")
+	fccfg, err := getFirecrackerConfigFromPlatformConfig(machine.Status.PlatformConfig)
+	if err != nil {
+		return machine, err
+	}
+
+	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
+
+	if _, err := client.PatchVM(ctx, &models.VM{
+		State: firecracker.String(models.VMStatePaused),
+	}); err != nil {
+		return machine, fmt.Errorf("could not pause firecracker instance: %v", err)
+	}
+
+	machine.Status.State = machinev1alpha1.MachineStatePaused
+
+	return machine, nil
 }
 
 // Logs implements kraftkit.sh/api/machine/v1alpha1.MachineService
@@ -476,6 +674,13 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		if state != savedState {
 			machine.Status.State = state
 		}
+
+		// Report the result of the most recent health check whilst the machine
+		// is running.
+		machine.Status.Health = nil
+		if machine.Status.State == machinev1alpha1.MachineStateRunning {
+			machine.Status.Health = health.Read(machine)
+		}
 	}()
 
 	if !activeProcess {
@@ -555,8 +760,18 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		return machine, err
 	}
 
+	if err := portforward.Stop(machine); err != nil {
+		return machine, err
+	}
+
+	if err := health.Stop(machine); err != nil {
+		return machine, err
+	}
+
 	machine.Status.State = machinev1alpha1.MachineStateExited
 	machine.Status.ExitedAt = time.Now()
+	machine.Status.ExitReason = machinev1alpha1.MachineExitReasonStopped
+	machine.Status.StoppedExplicitly = true
 
 	return machine, nil
 }
@@ -570,6 +785,9 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 
 	var errs merr.Errors
 
+	errs = append(errs, portforward.Stop(machine))
+	errs = append(errs, health.Stop(machine))
+	errs = append(errs, cgroup.Delete(machine))
 	errs = append(errs, os.Remove(machine.Status.LogFile))
 	errs = append(errs, os.Remove(fccfg.LogPath))
 	errs = append(errs, os.RemoveAll(machine.Status.StateDir))
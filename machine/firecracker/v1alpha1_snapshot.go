@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package firecracker
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	firecracker "github.com/firecracker-microvm/firecracker-go-sdk"
+	"github.com/firecracker-microvm/firecracker-go-sdk/client/models"
+	"github.com/sirupsen/logrus"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+const (
+	// SnapshotStateFile is the name of the file within the directory of a
+	// snapshot which contains the state of the microVM.
+	SnapshotStateFile = "firecracker.vmstate"
+
+	// SnapshotMemoryFile is the name of the file within the directory of a
+	// snapshot which contains the guest memory of the microVM.
+	SnapshotMemoryFile = "firecracker.mem"
+)
+
+// Snapshot implements kraftkit.sh/machine/platform.MachineSnapshotter
+func (service *machineV1alpha1Service) Snapshot(ctx context.Context, machine *machinev1alpha1.Machine, dir string) (err error) {
+	fccfg, err := getFirecrackerConfigFromPlatformConfig(machine.Status.PlatformConfig)
+	if err != nil {
+		return err
+	}
+
+	client := firecracker.NewClient(fccfg.SocketPath, logrus.NewEntry(log.G(ctx)), false)
+
+	info, err := client.GetInstanceInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("could not query machine status via API socket: %v", err)
+	}
+
+	// Snapshots can only be taken of paused microVMs.
+	switch *info.Payload.State {
+	case models.InstanceInfoStateRunning:
+		if _, err := service.Pause(ctx, machine); err != nil {
+			return err
+		}
+
+		defer func() {
+			if _, resumeErr := service.Start(ctx, machine); resumeErr != nil && err == nil {
+				err = resumeErr
+			}
+		}()
+
+	case models.InstanceInfoStatePaused:
+	default:
+		return fmt.Errorf("cannot snapshot firecracker instance which has not been started")
+	}
+
+	if _, err := client.CreateSnapshot(ctx, &models.SnapshotCreateParams{
+		MemFilePath:  firecracker.String(filepath.Join(dir, SnapshotMemoryFile)),
+		SnapshotPath: firecracker.String(filepath.Join(dir, SnapshotStateFile)),
+		SnapshotType: models.SnapshotCreateParamsSnapshotTypeFull,
+	}); err != nil {
+		return fmt.Errorf("could not snapshot firecracker instance: %v", err)
+	}
+
+	return nil
+}
+
+// restore loads the state of the machine from its snapshot via the API socket
+// of its Firecracker process.  The configuration of the microVM, including its
+// network interfaces, is part of the snapshot such that the tap devices of the
+// machine must have the same names as when the snapshot was taken.  The
+// microVM remains paused until the machine is started.
+func (service *machineV1alpha1Service) restore(ctx context.Context, client *firecracker.Client, machine *machinev1alpha1.Machine) error {
+	if _, err := client.LoadSnapshot(ctx, &models.SnapshotLoadParams{
+		MemFilePath:  firecracker.String(filepath.Join(machine.Status.SnapshotDir, SnapshotMemoryFile)),
+		SnapshotPath: firecracker.String(filepath.Join(machine.Status.SnapshotDir, SnapshotStateFile)),
+	}); err != nil {
+		return fmt.Errorf("could not restore firecracker instance from snapshot: %v", err)
+	}
+
+	return nil
+}
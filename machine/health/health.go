@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package health performs the health check of a machine.  The checks are
+// performed by a detached process which runs alongside the VMM, such that it
+// outlives the invoking program and exits together with the machine, and which
+// records the result of each check within the state directory of the machine
+// from where it is read by the platform driver.
+package health
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	goprocess "github.com/shirou/gopsutil/v3/process"
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+const (
+	// StatusFile is the name of the file within the state directory of the
+	// machine which contains the result of the most recent health check.
+	StatusFile = "health.json"
+
+	// PidFile is the name of the file within the state directory of the machine
+	// which contains the PID of its health checker.
+	PidFile = "health.pid"
+
+	// LogFile is the name of the file within the state directory of the machine
+	// which contains the output of its health checker.
+	LogFile = "health.log"
+
+	// reexecName is the name under which the health checker is registered such
+	// that the running binary can be re-executed as the health checker.
+	reexecName = "kraftkit-health"
+
+	pollInterval = time.Second
+	maxLogLine   = 1024 * 1024
+)
+
+func init() {
+	reexec.Register(reexecName, serve)
+}
+
+// checker is the configuration of the health checker process.
+type checker struct {
+	// Pid of the VMM of the machine.
+	Pid int32 `json:"pid"`
+
+	// Check is the health check of the machine.
+	Check machinev1alpha1.MachineHealthCheck `json:"check"`
+
+	// Address is the resolved host and port of TCP and HTTP checks.
+	Address string `json:"address,omitempty"`
+
+	// LogFile is the in-host path to the log file of the machine.
+	LogFile string `json:"logFile,omitempty"`
+
+	// StatusFile is the in-host path to the file which the result of each check
+	// is written to.
+	StatusFile string `json:"statusFile"`
+}
+
+// Read returns the result of the most recent health check of the machine.  A
+// nil value is returned if the machine has no health check.
+func Read(machine *machinev1alpha1.Machine) *machinev1alpha1.MachineHealth {
+	if machine.Spec.HealthCheck == nil {
+		return nil
+	}
+
+	health := machinev1alpha1.MachineHealth{
+		Status: machinev1alpha1.MachineHealthStarting,
+	}
+
+	data, err := os.ReadFile(filepath.Join(machine.Status.StateDir, StatusFile))
+	if err != nil {
+		return &health
+	}
+
+	if err := json.Unmarshal(data, &health); err != nil {
+		return &machinev1alpha1.MachineHealth{
+			Status: machinev1alpha1.MachineHealthStarting,
+		}
+	}
+
+	return &health
+}
+
+// address returns the host and port at which the port of the machine can be
+// reached from the host, which is either the IP address of the machine on its
+// network or the port published on the host.
+func address(machine *machinev1alpha1.Machine, host string, port int32) (string, error) {
+	if host != "" {
+		return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+	}
+
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			if iface.Spec.IP != "" {
+				return net.JoinHostPort(iface.Spec.IP, strconv.Itoa(int(port))), nil
+			}
+		}
+	}
+
+	for _, published := range machine.Spec.Ports {
+		if published.MachinePort != port || (published.Protocol != "" && published.Protocol != corev1.ProtocolTCP) {
+			continue
+		}
+
+		host := published.HostIP
+		if host == "" || host == "0.0.0.0" {
+			host = "127.0.0.1"
+		}
+
+		return net.JoinHostPort(host, strconv.Itoa(int(published.HostPort))), nil
+	}
+
+	return "", fmt.Errorf("cannot check health: port %d of machine is neither reachable on a network nor published", port)
+}
+
+// serve is the entrypoint of the health checker process.  It is invoked with
+// its JSON-encoded configuration.
+func serve() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s CONFIG\n", reexecName)
+		os.Exit(1)
+	}
+
+	var c checker
+	if err := json.Unmarshal([]byte(os.Args[1]), &c); err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse config: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// Exit together with the machine.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+
+			if exists, err := goprocess.PidExistsWithContext(ctx, c.Pid); err == nil && !exists {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	c.run(ctx)
+	os.Exit(0)
+}
+
+// run periodically performs the health check until the context is cancelled.
+// Failures during the start period do not count towards the number of retries
+// unless the machine has been healthy before.
+func (c *checker) run(ctx context.Context) {
+	interval := c.Check.Interval.Duration
+	if interval <= 0 {
+		interval = machinev1alpha1.DefaultHealthCheckInterval
+	}
+
+	retries := c.Check.Retries
+	if retries < 1 {
+		retries = 1
+	}
+
+	started := time.Now()
+	health := machinev1alpha1.MachineHealth{
+		Status: machinev1alpha1.MachineHealthStarting,
+	}
+
+	for {
+		err := c.check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		health.CheckedAt = time.Now()
+
+		if err == nil {
+			health.Status = machinev1alpha1.MachineHealthHealthy
+			health.FailingStreak = 0
+			health.LastError = ""
+		} else {
+			health.LastError = err.Error()
+
+			if health.Status != machinev1alpha1.MachineHealthStarting || time.Since(started) >= c.Check.StartPeriod.Duration {
+				health.FailingStreak++
+				if health.FailingStreak >= retries {
+					health.Status = machinev1alpha1.MachineHealthUnhealthy
+				}
+			}
+		}
+
+		if err := write(c.StatusFile, health); err != nil {
+			fmt.Fprintf(os.Stderr, "could not record health: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// check performs a single health check.
+func (c *checker) check(ctx context.Context) error {
+	timeout := c.Check.Timeout.Duration
+	if timeout <= 0 {
+		timeout = machinev1alpha1.DefaultHealthCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch {
+	case c.Check.TCP != nil:
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.Address)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+
+	case c.Check.HTTP != nil:
+		scheme := c.Check.HTTP.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", scheme, c.Address, c.Check.HTTP.Path), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		return nil
+
+	case c.Check.Log != nil:
+		return matchLog(c.LogFile, c.Check.Log.Pattern)
+	}
+
+	return fmt.Errorf("health check has no test")
+}
+
+// matchLog checks whether any line of the provided log file matches the
+// provided regular expression.
+func matchLog(logFile, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLogLine)
+
+	for scanner.Scan() {
+		if re.Match(scanner.Bytes()) {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("no line of the log matches %q", pattern)
+}
+
+// write atomically records the result of a health check in the provided file.
+func write(statusFile string, health machinev1alpha1.MachineHealth) error {
+	data, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+
+	tmp := statusFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, statusFile)
+}
+
+// pidFile returns the path to the PID file of the health checker of the
+// machine.
+func pidFile(machine *machinev1alpha1.Machine) string {
+	return filepath.Join(machine.Status.StateDir, PidFile)
+}
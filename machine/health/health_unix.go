@@ -0,0 +1,139 @@
+//go:build !windows
+// +build !windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// Start spawns a detached health checker for the machine if it has a health
+// check and no checker is running yet, e.g. because the machine is resumed.
+// The checker exits once the process of the machine, as indicated by its PID,
+// has exited or when it is stopped via Stop.
+func Start(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	if machine.Spec.HealthCheck == nil {
+		return nil
+	}
+
+	if pid, err := readPid(machine); err == nil && syscall.Kill(pid, 0) == nil {
+		return nil
+	}
+
+	c := checker{
+		Pid:        machine.Status.Pid,
+		Check:      *machine.Spec.HealthCheck,
+		LogFile:    machine.Status.LogFile,
+		StatusFile: filepath.Join(machine.Status.StateDir, StatusFile),
+	}
+
+	var err error
+
+	switch {
+	case c.Check.TCP != nil:
+		c.Address, err = address(machine, c.Check.TCP.Host, c.Check.TCP.Port)
+	case c.Check.HTTP != nil:
+		c.Address, err = address(machine, c.Check.HTTP.Host, c.Check.HTTP.Port)
+	}
+	if err != nil {
+		return err
+	}
+
+	config, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	// Discard the result of the checks of a previous instance of the machine.
+	if err := os.Remove(c.StatusFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	logFile, err := os.Create(filepath.Join(machine.Status.StateDir, LogFile))
+	if err != nil {
+		return err
+	}
+
+	defer logFile.Close()
+
+	cmd := &exec.Cmd{
+		Path: reexec.Self(),
+		Args: []string{
+			reexecName,
+			string(config),
+		},
+		Stdout: logFile,
+		Stderr: logFile,
+		// the Setpgid flag is used to prevent the checker from exiting when the
+		// parent is killed
+		SysProcAttr: &syscall.SysProcAttr{
+			Setpgid: true,
+		},
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start health checker: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("pid", cmd.Process.Pid).
+		WithField("check", machine.Spec.HealthCheck.String()).
+		Debug("started health checker")
+
+	if err := os.WriteFile(pidFile(machine), []byte(strconv.Itoa(cmd.Process.Pid)), 0o644); err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("could not save pid of health checker: %w", err)
+	}
+
+	return cmd.Process.Release()
+}
+
+// Stop terminates the health checker of the machine, if any.
+func Stop(machine *machinev1alpha1.Machine) error {
+	pid, err := readPid(machine)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// The checker may have already exited together with the machine.
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("could not stop health checker: %w", err)
+	}
+
+	return os.Remove(pidFile(machine))
+}
+
+// readPid returns the PID of the health checker of the machine.
+func readPid(machine *machinev1alpha1.Machine) (int, error) {
+	data, err := os.ReadFile(pidFile(machine))
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("could not parse pid of health checker: %w", err)
+	}
+
+	return pid, nil
+}
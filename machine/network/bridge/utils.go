@@ -10,6 +10,8 @@ import (
 
 	"github.com/erikh/ping"
 	"github.com/vishvananda/netlink"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/internal/set"
 )
 
@@ -30,11 +32,21 @@ func BigIntToIP(v *big.Int) net.IP {
 	return net.IP(v.Bytes())
 }
 
-// Increases IP address numeric value by 1.
+// Increases IP address numeric value by 1.  The address retains its length,
+// such that nil is returned if the address overflows.
 func IncreaseIP(ip net.IP) net.IP {
+	size := net.IPv6len
+	if ip.To4() != nil {
+		size = net.IPv4len
+	}
+
 	rawip := IPToBigInt(ip)
 	rawip.Add(rawip, big.NewInt(1))
-	return BigIntToIP(rawip)
+	if rawip.BitLen() > size*8 {
+		return nil
+	}
+
+	return rawip.FillBytes(make(net.IP, size))
 }
 
 // IsUnicastIP returns true if the provided IP address and network mask is a
@@ -49,41 +61,50 @@ func IsUnicastIP(ip net.IP, mask net.IPMask) bool {
 	return ip.IsGlobalUnicast()
 }
 
-// BridgeIPs returns all the IPs attached to the provided bridge
-func BridgeIPs(bridge *netlink.Bridge) ([]string, error) {
+// BridgeIPs returns all the IPs of the provided family, i.e. netlink.FAMILY_V4
+// or netlink.FAMILY_V6, which are attached to the provided bridge.
+func BridgeIPs(bridge *netlink.Bridge, family int) ([]string, error) {
 	// get the neighbors
 	var (
 		list []netlink.Neigh
 		err  error
 	)
 
-	list, err = netlink.NeighList(bridge.Index, netlink.FAMILY_V4)
+	list, err = netlink.NeighList(bridge.Index, family)
 	if err != nil {
-		return nil, fmt.Errorf("cannot retrieve IPv4 neighbor information for interface %s: %v", bridge.Name, err)
+		return nil, fmt.Errorf("cannot retrieve neighbor information for interface %s: %v", bridge.Name, err)
 	}
 
 	ips := make([]string, len(list))
 	for i, entry := range list {
-		ips[i] = entry.String()
+		ips[i] = entry.IP.String()
 	}
 
 	return ips, nil
 }
 
-// For a given IP network, bridge (and its interface), allocate a free IP
-// address.
-func AllocateIP(ctx context.Context, ipnet *net.IPNet, iface *net.Interface, bridge *netlink.Bridge) (net.IP, error) {
+// For a given IPv4 or IPv6 network, bridge (and its interface), allocate a
+// free IP address which is neither attached to the bridge nor one of the
+// provided reserved addresses.
+func AllocateIP(ctx context.Context, ipnet *net.IPNet, iface *net.Interface, bridge *netlink.Bridge, reserved ...string) (net.IP, error) {
 	bridgeAddrs, err := iface.Addrs()
 	if err != nil {
 		return nil, err
 	}
 
-	allocatedIps, err := BridgeIPs(bridge)
+	v4 := ipnet.IP.To4() != nil
+
+	family := netlink.FAMILY_V6
+	if v4 {
+		family = netlink.FAMILY_V4
+	}
+
+	allocatedIps, err := BridgeIPs(bridge, family)
 	if err != nil {
 		return nil, err
 	}
 
-	allocatedSet := set.NewStringSet(allocatedIps...)
+	allocatedSet := set.NewStringSet(append(allocatedIps, reserved...)...)
 	ip := ipnet.IP
 
 search:
@@ -99,7 +120,7 @@ search:
 		switch {
 		// If the IP is not within the provided network, it is not possible to
 		// increment the IP so return with an error.
-		case !ipnet.Contains(ip):
+		case ip == nil || !ipnet.Contains(ip):
 			return nil, fmt.Errorf("could not allocate IP address in %v", ipnet.String())
 
 		// Skip the Bridge IP.
@@ -122,8 +143,9 @@ search:
 		case allocatedSet.Contains(ip.String()):
 			continue
 
-		// Use ICMP to check if the IP is in use as a final sanity check.
-		case ping.Ping(&net.IPAddr{IP: ip, Zone: ""}, 150*time.Millisecond):
+		// Use ICMP to check if the IP is in use as a final sanity check, which
+		// is only supported for IPv4.
+		case v4 && ping.Ping(&net.IPAddr{IP: ip, Zone: ""}, 150*time.Millisecond):
 			continue
 
 		default:
@@ -133,3 +155,65 @@ search:
 
 	return ip, nil
 }
+
+// bridgeSubnets returns the IPv4 and the global IPv6 address of the provided
+// bridge, either of which is nil if the bridge has none.
+func bridgeSubnets(bridge *netlink.Bridge) (*netlink.Addr, *netlink.Addr, error) {
+	var v4, v6 *netlink.Addr
+
+	addrs, err := netlink.AddrList(bridge, netlink.FAMILY_V4)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(addrs) > 0 {
+		v4 = &addrs[0]
+	}
+
+	addrs, err = netlink.AddrList(bridge, netlink.FAMILY_V6)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Skip the link-local address which the kernel assigns to every interface.
+	for i, addr := range addrs {
+		if addr.IP.IsGlobalUnicast() {
+			v6 = &addrs[i]
+			break
+		}
+	}
+
+	return v4, v6, nil
+}
+
+// setSubnets sets the gateways and netmasks of the provided network to the
+// provided addresses of its bridge.
+func setSubnets(spec *networkv1alpha1.NetworkSpec, v4, v6 *netlink.Addr) {
+	spec.Gateway, spec.Netmask = "", ""
+	if v4 != nil {
+		spec.Gateway = v4.IP.String()
+		spec.Netmask = net.IP(v4.Mask).String()
+	}
+
+	spec.Gateway6, spec.Netmask6 = "", ""
+	if v6 != nil {
+		spec.Gateway6 = v6.IP.String()
+		spec.Netmask6 = net.IP(v6.Mask).String()
+	}
+}
+
+// inUse returns the address of the interface which still responds to pings,
+// if any, and whether one does.
+func inUse(iface networkv1alpha1.NetworkInterfaceTemplateSpec) (string, bool) {
+	for _, addr := range []string{iface.Spec.IP, iface.Spec.IP6} {
+		if addr == "" {
+			continue
+		}
+
+		if ping.Ping(&net.IPAddr{IP: net.ParseIP(addr)}, 150*time.Millisecond) {
+			return addr, true
+		}
+	}
+
+	return "", false
+}
@@ -10,12 +10,9 @@ import (
 	"net"
 	"sort"
 	"strings"
-	"time"
 	"unicode"
 
-	"github.com/erikh/ping"
 	"github.com/vishvananda/netlink"
-	"github.com/vishvananda/netlink/nl"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
@@ -47,11 +44,9 @@ func (service *v1alpha1Network) Create(ctx context.Context, network *networkv1al
 	network.Status.State = networkv1alpha1.NetworkStateUnknown
 
 	// Validate the options.
-	if len(network.Spec.Gateway) == 0 {
-		return network, fmt.Errorf("gateway cannot be empty")
-	}
-	if len(network.Spec.Netmask) == 0 {
-		return network, fmt.Errorf("netmask cannot be empty")
+	ipnet, ipnet6 := network.Spec.IPNet(), network.Spec.IPNet6()
+	if ipnet == nil && ipnet6 == nil {
+		return network, fmt.Errorf("gateway and netmask of an IPv4 or IPv6 subnet are required")
 	}
 
 	bridge := &netlink.Bridge{
@@ -79,15 +74,18 @@ func (service *v1alpha1Network) Create(ctx context.Context, network *networkv1al
 
 	// br.Promisc = 1 // TODO(nderjung): Should the bridge be promiscuous?
 
-	// Setup IP address for bridge.
-	addr := &netlink.Addr{
-		IPNet: &net.IPNet{
-			IP:   net.ParseIP(network.Spec.Gateway),
-			Mask: net.ParseIP(network.Spec.Netmask).DefaultMask(),
-		},
-	}
-	if err := netlink.AddrAdd(br, addr); err != nil {
-		return network, fmt.Errorf("adding address %s to bridge %s failed: %v", addr.String(), network.Name, err)
+	// Setup IP addresses for bridge.
+	for _, subnet := range []*net.IPNet{ipnet, ipnet6} {
+		if subnet == nil {
+			continue
+		}
+
+		addr := &netlink.Addr{
+			IPNet: subnet,
+		}
+		if err := netlink.AddrAdd(br, addr); err != nil {
+			return network, fmt.Errorf("adding address %s to bridge %s failed: %v", addr.String(), network.Name, err)
+		}
 	}
 
 	// Bring the bridge up.
@@ -192,8 +190,8 @@ func (service *v1alpha1Network) Stop(ctx context.Context, network *networkv1alph
 			return network, fmt.Errorf("getting link %s failed: %v", iface.Spec.IfName, err)
 		}
 
-		if ping.Ping(&net.IPAddr{IP: net.ParseIP(iface.Spec.IP), Zone: ""}, 150*time.Millisecond) {
-			return network, fmt.Errorf("interface still in use: %s (%s, %s)", iface.Spec.IfName, iface.Spec.MacAddress, iface.Spec.IP)
+		if addr, ok := inUse(iface); ok {
+			return network, fmt.Errorf("interface still in use: %s (%s, %s)", iface.Spec.IfName, iface.Spec.MacAddress, addr)
 		}
 
 		if err := netlink.LinkSetDown(link); err != nil {
@@ -235,9 +233,13 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 		return nil, fmt.Errorf("could not get bridge interface: %v", err)
 	}
 
-	ipnet := &net.IPNet{
-		IP:   net.ParseIP(network.Spec.Gateway),
-		Mask: net.ParseIP(network.Spec.Netmask).DefaultMask(),
+	ipnet, ipnet6 := network.Spec.IPNet(), network.Spec.IPNet6()
+
+	// Reserve the addresses of all interfaces, including those of machines which
+	// are not running and hence not neighbors of the bridge.
+	var reserved []string
+	for _, iface := range network.Spec.Interfaces {
+		reserved = append(reserved, iface.Spec.IP, iface.Spec.IP6)
 	}
 
 	// Start MAC addresses iteratively.
@@ -279,13 +281,24 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 			iface.Spec.MacAddress = mac.String()
 		}
 
-		if iface.Spec.IP == "" {
-			ip, err := AllocateIP(ctx, ipnet, bridgeface, bridge)
+		if iface.Spec.IP == "" && ipnet != nil {
+			ip, err := AllocateIP(ctx, ipnet, bridgeface, bridge, reserved...)
 			if err != nil {
 				return network, fmt.Errorf("could not allocate interface IP for %s: %v", iface.Spec.IfName, err)
 			}
 
 			iface.Spec.IP = ip.String()
+			reserved = append(reserved, iface.Spec.IP)
+		}
+
+		if iface.Spec.IP6 == "" && ipnet6 != nil {
+			ip, err := AllocateIP(ctx, ipnet6, bridgeface, bridge, reserved...)
+			if err != nil {
+				return network, fmt.Errorf("could not allocate interface IPv6 for %s: %v", iface.Spec.IfName, err)
+			}
+
+			iface.Spec.IP6 = ip.String()
+			reserved = append(reserved, iface.Spec.IP6)
 		}
 
 		tap := &netlink.Tuntap{
@@ -338,6 +351,10 @@ func (service *v1alpha1Network) Update(ctx context.Context, network *networkv1al
 			continue // Skip non-tap interfaces
 		}
 
+		if tap.Attrs().MasterIndex != bridge.Attrs().Index {
+			continue // Skip interfaces attached to other networks
+		}
+
 		if _, ok := inuse[tap.Alias]; ok {
 			continue // Skip in-use interfaces
 		}
@@ -364,8 +381,8 @@ func (service *v1alpha1Network) Delete(ctx context.Context, network *networkv1al
 			return network, fmt.Errorf("could not get %s link: %v", iface.Spec.IfName, err)
 		}
 
-		if ping.Ping(&net.IPAddr{IP: net.ParseIP(iface.Spec.IP), Zone: ""}, 150*time.Millisecond) {
-			return network, fmt.Errorf("interface still in use: %s (%s, %s)", iface.Spec.IfName, iface.Spec.MacAddress, iface.Spec.IP)
+		if addr, ok := inUse(iface); ok {
+			return network, fmt.Errorf("interface still in use: %s (%s, %s)", iface.Spec.IfName, iface.Spec.MacAddress, addr)
 		}
 
 		// Bring down the bridge link
@@ -441,18 +458,18 @@ func (service *v1alpha1Network) Get(ctx context.Context, network *networkv1alpha
 		return network, fmt.Errorf("network link is not bridge")
 	}
 
-	addrs, err := netlink.AddrList(bridge, nl.FAMILY_V4)
+	v4, v6, err := bridgeSubnets(bridge)
 	if err != nil {
 		return network, err
 	}
 
-	if len(addrs) == 0 {
+	if v4 == nil && v6 == nil {
 		return network, fmt.Errorf("bridge %s has no ip address", network.Name)
 	}
 
 	network.Spec.Driver = "bridge"
-	network.Spec.Gateway = addrs[0].IP.String()
-	network.Spec.Netmask = net.IP(addrs[0].Mask).String()
+	network.Spec.IfName = bridge.Name
+	setSubnets(&network.Spec, v4, v6)
 
 	// Use the internal network bridge networking system to determine
 	// whether the identified network is online.
@@ -512,7 +529,7 @@ func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alp
 
 	// Discover new bridges.
 	for _, bridge := range bridges {
-		addrs, err := netlink.AddrList(bridge, nl.FAMILY_V4)
+		v4, v6, err := bridgeSubnets(bridge)
 		if err != nil {
 			continue // TODO(nderjung): error groups
 		}
@@ -522,18 +539,18 @@ func (service *v1alpha1Network) List(ctx context.Context, networks *networkv1alp
 				Name: bridge.Name,
 				UID:  uuid.NewUUID(),
 			},
+			Spec: networkv1alpha1.NetworkSpec{
+				IfName: bridge.Name,
+			},
 		}
 
-		if len(addrs) != 1 {
+		if v4 == nil && v6 == nil {
 			network.Status.State = networkv1alpha1.NetworkStateDown
 			networks.Items = append(networks.Items, network)
 			continue // TODO(nderjung): error groups
 		}
 
-		network.Spec = networkv1alpha1.NetworkSpec{
-			Gateway: addrs[0].IP.String(),
-			Netmask: net.IP(addrs[0].Mask).String(),
-		}
+		setSubnets(&network.Spec, v4, v6)
 
 		// Use the internal network bridge networking system to determine
 		// whether the identified network is online.
@@ -0,0 +1,587 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package dns provides an embedded DNS responder for a network, e.g. a bridge,
+// which resolves the names of the machines which are attached to the network to
+// the addresses of their interfaces on that network.  All other queries are
+// forwarded to the resolvers of the host.  The responder listens on the gateway
+// addresses of the network and runs as a detached process such that it
+// outlives the invoking program and exits once it is stopped or the network is
+// removed.
+package dns
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	"golang.org/x/net/dns/dnsmessage"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/machine/daemon"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+const (
+	// AliasesAnnotation is the annotation of a machine which holds a
+	// comma-separated list of additional names by which the machine is
+	// resolved, e.g. the name of its compose service.
+	AliasesAnnotation = "network.kraftkit.sh/aliases"
+
+	// Port is the port on which the responder listens.
+	Port = "53"
+
+	// reexecName is the name under which the responder is registered such that
+	// the running binary can be re-executed as the responder.
+	reexecName = "kraftkit-dns"
+
+	// resolvConf is the path to the configuration of the resolvers of the host.
+	resolvConf = "/etc/resolv.conf"
+
+	ttl             = 10
+	refreshInterval = 2 * time.Second
+	forwardTimeout  = 2 * time.Second
+	bindTimeout     = 3 * time.Second
+	pollInterval    = time.Second
+	maxDatagram     = 65535
+)
+
+func init() {
+	reexec.Register(reexecName, serve)
+}
+
+// responder is the configuration of the responder process.
+type responder struct {
+	// Network is the name of the network whose machines are resolved.
+	Network string `json:"network"`
+
+	// Addresses are the addresses the responder listens on, whose bound
+	// sockets are inherited in the same order.
+	Addresses []string `json:"addresses"`
+
+	// Upstreams are the addresses of the resolvers which queries for unknown
+	// names are forwarded to.
+	Upstreams []string `json:"upstreams,omitempty"`
+
+	// RuntimeDir is the runtime directory of KraftKit.
+	RuntimeDir string `json:"runtimeDir"`
+
+	// DaemonSocket is the path to the socket of the daemon through which the
+	// machines are listed.
+	DaemonSocket string `json:"daemonSocket"`
+
+	machines  []machinev1alpha1.MachineService
+	lock      sync.Mutex
+	records   map[string][]net.IP
+	refreshed time.Time
+}
+
+// dir returns the directory which holds the PID and log files of the
+// responders.
+func dir(ctx context.Context) string {
+	return filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "dns")
+}
+
+// pidFile returns the path to the PID file of the responder of the network.
+func pidFile(ctx context.Context, network string) string {
+	return filepath.Join(dir(ctx), network+".pid")
+}
+
+// logFile returns the path to the log file of the responder of the network.
+func logFile(ctx context.Context, network string) string {
+	return filepath.Join(dir(ctx), network+".log")
+}
+
+// addresses returns the gateway addresses of the network, which are the
+// addresses the responder listens on.
+func addresses(network *networkv1alpha1.Network) []string {
+	var ret []string
+
+	for _, gateway := range []string{network.Spec.Gateway, network.Spec.Gateway6} {
+		if gateway != "" {
+			ret = append(ret, gateway)
+		}
+	}
+
+	return ret
+}
+
+// listen binds the port of the responder on each of the provided addresses
+// and returns the underlying files such that they can be inherited by the
+// responder.  The addresses of a bridge which has just been brought up may
+// not yet be available, e.g. during IPv6 duplicate address detection, such
+// that binding is retried for a short while.
+func listen(addrs []string) ([]*os.File, error) {
+	files := make([]*os.File, 0, len(addrs))
+
+	closeAll := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	for _, addr := range addrs {
+		addr = net.JoinHostPort(addr, Port)
+
+		var pc net.PacketConn
+		var err error
+
+		for started := time.Now(); ; time.Sleep(100 * time.Millisecond) {
+			pc, err = net.ListenPacket("udp", addr)
+			if err == nil || time.Since(started) > bindTimeout {
+				break
+			}
+		}
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("could not listen on %s/udp: %w", addr, err)
+		}
+
+		file, err := pc.(*net.UDPConn).File()
+		pc.Close()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// parseResolvConf returns the addresses of the nameservers of the provided
+// resolv.conf(5)-formatted configuration, excluding the provided addresses.
+func parseResolvConf(r io.Reader, exclude ...string) []string {
+	var ret []string
+
+	scanner := bufio.NewScanner(r)
+
+nameservers:
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		// Zones of link-local addresses are not supported.
+		ip := net.ParseIP(fields[1])
+		if ip == nil {
+			continue
+		}
+
+		for _, addr := range exclude {
+			if ip.Equal(net.ParseIP(addr)) {
+				continue nameservers
+			}
+		}
+
+		ret = append(ret, ip.String())
+	}
+
+	return ret
+}
+
+// upstreams returns the resolvers of the host which are not the responder
+// itself.
+func upstreams(exclude ...string) []string {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return nil
+	}
+
+	defer f.Close()
+
+	return parseResolvConf(f, exclude...)
+}
+
+// machineRecords returns the addresses of each of the names of the provided
+// machines which are running and attached to the network.
+func machineRecords(network string, machines []machinev1alpha1.Machine) map[string][]net.IP {
+	records := map[string][]net.IP{}
+
+	for _, machine := range machines {
+		if machine.Status.State != machinev1alpha1.MachineStateRunning {
+			continue
+		}
+
+		var ips []net.IP
+		for _, spec := range machine.Spec.Networks {
+			if spec.IfName != network {
+				continue
+			}
+
+			for _, iface := range spec.Interfaces {
+				for _, addr := range []string{iface.Spec.IP, iface.Spec.IP6} {
+					if ip := net.ParseIP(addr); ip != nil {
+						ips = append(ips, ip)
+					}
+				}
+			}
+		}
+
+		if len(ips) == 0 {
+			continue
+		}
+
+		names := []string{machine.Name}
+		if aliases := machine.Annotations[AliasesAnnotation]; aliases != "" {
+			names = append(names, strings.Split(aliases, ",")...)
+		}
+
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+			if name != "" {
+				records[name] = append(records[name], ips...)
+			}
+		}
+	}
+
+	return records
+}
+
+// answer returns the response to the provided query if it asks for the
+// address of one of the names of the provided records.  Otherwise, false is
+// returned such that the query can be forwarded.
+func answer(query []byte, records map[string][]net.IP) ([]byte, bool, error) {
+	var p dnsmessage.Parser
+
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, false, err
+	}
+
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if header.Response || header.OpCode != 0 || len(questions) != 1 || questions[0].Class != dnsmessage.ClassINET {
+		return nil, false, nil
+	}
+
+	question := questions[0]
+
+	ips, ok := records[strings.ToLower(strings.TrimSuffix(question.Name.String(), "."))]
+	if !ok {
+		return nil, false, nil
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              dnsmessage.RCodeSuccess,
+	})
+	b.EnableCompression()
+
+	if err := b.StartQuestions(); err != nil {
+		return nil, false, err
+	}
+
+	if err := b.Question(question); err != nil {
+		return nil, false, err
+	}
+
+	if err := b.StartAnswers(); err != nil {
+		return nil, false, err
+	}
+
+	// Names which are known but have no address of the requested type are
+	// answered without any records.
+	rh := dnsmessage.ResourceHeader{
+		Name:  question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   ttl,
+	}
+
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil && (question.Type == dnsmessage.TypeA || question.Type == dnsmessage.TypeALL) {
+			var a dnsmessage.AResource
+			copy(a.A[:], ip4)
+			err = b.AResource(rh, a)
+		} else if ip4 == nil && (question.Type == dnsmessage.TypeAAAA || question.Type == dnsmessage.TypeALL) {
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], ip.To16())
+			err = b.AAAAResource(rh, aaaa)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	resp, err := b.Finish()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return resp, true, nil
+}
+
+// failure returns a response to the provided query which indicates that the
+// query could not be processed.
+func failure(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return nil, err
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		OpCode:             header.OpCode,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              dnsmessage.RCodeServerFailure,
+	})
+
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+
+	for _, question := range questions {
+		if err := b.Question(question); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Finish()
+}
+
+// serve is the entrypoint of the responder process.  It is invoked with its
+// JSON-encoded configuration and inherits the bound sockets of its addresses
+// starting at file descriptor 3.
+func serve() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s CONFIG\n", reexecName)
+		os.Exit(1)
+	}
+
+	var r responder
+	if err := json.Unmarshal([]byte(os.Args[1]), &r); err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse config: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.NewDefaultKraftKitConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not initialize config: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg.RuntimeDir = r.RuntimeDir
+	cfg.DaemonSocket = r.DaemonSocket
+
+	cfgm, err := config.NewConfigManager(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not initialize config: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx = config.WithConfigManager(ctx, cfgm)
+
+	// The machines are never listed directly, as the machine store would then
+	// be held open by a second long-running process next to the daemon.
+	for platform := range mplatform.Strategies() {
+		client, err := daemon.NewMachineV1alpha1Client(ctx, platform.String())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not access %s machines: %v\n", platform, err)
+			os.Exit(1)
+		}
+
+		r.machines = append(r.machines, client)
+	}
+
+	// Exit together with the network.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+
+			if _, err := net.InterfaceByName(r.Network); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for i, addr := range r.Addresses {
+		file := os.NewFile(uintptr(3+i), reexecName)
+
+		pc, err := net.FilePacketConn(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not inherit socket for %s: %v\n", addr, err)
+			os.Exit(1)
+		}
+
+		file.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.run(ctx, pc)
+		}()
+	}
+
+	wg.Wait()
+	os.Exit(0)
+}
+
+// run responds to the queries received on the provided connection until the
+// context is cancelled.
+func (r *responder) run(ctx context.Context, pc net.PacketConn) {
+	go func() {
+		<-ctx.Done()
+		pc.Close()
+	}()
+
+	buf := make([]byte, maxDatagram)
+
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		query := make([]byte, n)
+		copy(query, buf[:n])
+
+		go func() {
+			resp, err := r.respond(ctx, query)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "could not respond to %s: %v\n", addr, err)
+				return
+			}
+
+			if _, err := pc.WriteTo(resp, addr); err != nil {
+				fmt.Fprintf(os.Stderr, "could not respond to %s: %v\n", addr, err)
+			}
+		}()
+	}
+}
+
+// respond returns the response to the provided query, which is either answered
+// from the machines on the network or by the upstream resolvers.
+func (r *responder) respond(ctx context.Context, query []byte) ([]byte, error) {
+	resp, ok, err := answer(query, r.lookup(ctx))
+	if err != nil {
+		return nil, err
+	} else if ok {
+		return resp, nil
+	}
+
+	for _, upstream := range r.Upstreams {
+		resp, err := forward(query, upstream)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not forward query to %s: %v\n", upstream, err)
+			continue
+		}
+
+		return resp, nil
+	}
+
+	return failure(query)
+}
+
+// lookup returns the records of the machines on the network, which are
+// refreshed from the daemon at most once per refresh interval.
+func (r *responder) lookup(ctx context.Context) map[string][]net.IP {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.records != nil && time.Since(r.refreshed) < refreshInterval {
+		return r.records
+	}
+
+	var machines []machinev1alpha1.Machine
+	var errs []error
+
+	// Platforms which are not supported by the host are not served by the
+	// daemon and do not have any machines.
+	for _, service := range r.machines {
+		list, err := service.List(ctx, &machinev1alpha1.MachineList{})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		machines = append(machines, list.Items...)
+	}
+
+	if len(errs) == len(r.machines) {
+		fmt.Fprintf(os.Stderr, "could not list machines: %v\n", errors.Join(errs...))
+
+		// Serve the stale records rather than none at all.
+		if r.records != nil {
+			return r.records
+		}
+
+		return map[string][]net.IP{}
+	}
+
+	r.records = machineRecords(r.Network, machines)
+	r.refreshed = time.Now()
+
+	return r.records
+}
+
+// forward relays the provided query to the provided resolver and returns its
+// response.
+func forward(query []byte, upstream string) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(upstream, Port), forwardTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(forwardTimeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, maxDatagram)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dns
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+func TestParseResolvConf(t *testing.T) {
+	conf := strings.Join([]string{
+		"# Generated by NetworkManager",
+		"search example.com",
+		"nameserver 127.0.0.53",
+		"nameserver 172.18.0.1",
+		"nameserver fd00::1",
+		"nameserver invalid",
+		"options edns0",
+	}, "\n")
+
+	got := parseResolvConf(strings.NewReader(conf), "172.18.0.1")
+	want := []string{"127.0.0.53", "fd00::1"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseResolvConf() = %v, want %v", got, want)
+	}
+}
+
+func TestMachineRecords(t *testing.T) {
+	machine := func(name string, state machinev1alpha1.MachineState, aliases string, networks ...networkv1alpha1.NetworkSpec) machinev1alpha1.Machine {
+		m := machinev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       machinev1alpha1.MachineSpec{Networks: networks},
+			Status:     machinev1alpha1.MachineStatus{State: state},
+		}
+
+		if aliases != "" {
+			m.Annotations = map[string]string{AliasesAnnotation: aliases}
+		}
+
+		return m
+	}
+
+	network := func(name, ip, ip6 string) networkv1alpha1.NetworkSpec {
+		return networkv1alpha1.NetworkSpec{
+			IfName: name,
+			Interfaces: []networkv1alpha1.NetworkInterfaceTemplateSpec{{
+				Spec: networkv1alpha1.NetworkInterfaceSpec{IP: ip, IP6: ip6},
+			}},
+		}
+	}
+
+	got := machineRecords("kraft0", []machinev1alpha1.Machine{
+		machine("app-web", machinev1alpha1.MachineStateRunning, "web, Frontend", network("kraft0", "172.18.0.2", "fd00::2")),
+		machine("app-db", machinev1alpha1.MachineStateRunning, "", network("kraft1", "172.19.0.2", ""), network("kraft0", "172.18.0.3", "")),
+		machine("stopped", machinev1alpha1.MachineStateExited, "", network("kraft0", "172.18.0.4", "")),
+		machine("other", machinev1alpha1.MachineStateRunning, "", network("kraft1", "172.19.0.3", "")),
+	})
+
+	want := map[string][]net.IP{
+		"app-web":  {net.ParseIP("172.18.0.2"), net.ParseIP("fd00::2")},
+		"web":      {net.ParseIP("172.18.0.2"), net.ParseIP("fd00::2")},
+		"frontend": {net.ParseIP("172.18.0.2"), net.ParseIP("fd00::2")},
+		"app-db":   {net.ParseIP("172.18.0.3")},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("machineRecords() = %v, want %v", got, want)
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	records := map[string][]net.IP{
+		"web": {net.ParseIP("172.18.0.2"), net.ParseIP("fd00::2")},
+	}
+
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   dnsmessage.Type
+		ok      bool
+		answers []string
+	}{
+		{name: "A", qname: "web.", qtype: dnsmessage.TypeA, ok: true, answers: []string{"172.18.0.2"}},
+		{name: "AAAA", qname: "WEB.", qtype: dnsmessage.TypeAAAA, ok: true, answers: []string{"fd00::2"}},
+		{name: "ALL", qname: "web.", qtype: dnsmessage.TypeALL, ok: true, answers: []string{"172.18.0.2", "fd00::2"}},
+		{name: "no data", qname: "web.", qtype: dnsmessage.TypeMX, ok: true},
+		{name: "unknown", qname: "unikraft.org.", qtype: dnsmessage.TypeA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 42, RecursionDesired: true})
+			if err := b.StartQuestions(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := b.Question(dnsmessage.Question{
+				Name:  dnsmessage.MustNewName(tt.qname),
+				Type:  tt.qtype,
+				Class: dnsmessage.ClassINET,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			query, err := b.Finish()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, ok, err := answer(query, records)
+			if err != nil {
+				t.Fatalf("answer() error = %v", err)
+			} else if ok != tt.ok {
+				t.Fatalf("answer() ok = %v, want %v", ok, tt.ok)
+			} else if !ok {
+				return
+			}
+
+			var msg dnsmessage.Message
+			if err := msg.Unpack(resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if msg.ID != 42 || !msg.Response || !msg.Authoritative || msg.RCode != dnsmessage.RCodeSuccess {
+				t.Errorf("answer() header = %+v", msg.Header)
+			}
+
+			var answers []string
+			for _, rr := range msg.Answers {
+				switch body := rr.Body.(type) {
+				case *dnsmessage.AResource:
+					answers = append(answers, net.IP(body.A[:]).String())
+				case *dnsmessage.AAAAResource:
+					answers = append(answers, net.IP(body.AAAA[:]).String())
+				}
+			}
+
+			if !reflect.DeepEqual(answers, tt.answers) {
+				t.Errorf("answer() answers = %v, want %v", answers, tt.answers)
+			}
+		})
+	}
+}
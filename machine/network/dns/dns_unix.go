@@ -0,0 +1,154 @@
+//go:build !windows
+// +build !windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/pidfile"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/daemon"
+)
+
+// Start spawns a detached responder for the network which listens on the
+// gateway addresses of the network.  Starting the responder of a network
+// whose responder is already running has no effect.  The responder lists the
+// machines through the daemon, which must be running.
+func Start(ctx context.Context, network *networkv1alpha1.Network) error {
+	if pid(ctx, network.Name) > 0 {
+		return nil
+	}
+
+	if !daemon.Running(ctx) {
+		return fmt.Errorf("cannot start DNS responder: the daemon is not running (start it with: kraft daemon)")
+	}
+
+	addrs := addresses(network)
+	if len(addrs) == 0 {
+		return fmt.Errorf("cannot start DNS responder: network %s has no gateway", network.Name)
+	}
+
+	cfg, err := json.Marshal(responder{
+		Network:      network.Name,
+		Addresses:    addrs,
+		Upstreams:    upstreams(addrs...),
+		RuntimeDir:   config.G[config.KraftKit](ctx).RuntimeDir,
+		DaemonSocket: config.G[config.KraftKit](ctx).DaemonSocket,
+	})
+	if err != nil {
+		return err
+	}
+
+	files, err := listen(addrs)
+	if err != nil {
+		return fmt.Errorf("could not start DNS responder: %w", err)
+	}
+
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	if err := os.MkdirAll(dir(ctx), 0o755); err != nil {
+		return err
+	}
+
+	logs, err := os.Create(logFile(ctx, network.Name))
+	if err != nil {
+		return err
+	}
+
+	defer logs.Close()
+
+	cmd := &exec.Cmd{
+		Path: reexec.Self(),
+		Args: []string{
+			reexecName,
+			string(cfg),
+		},
+		ExtraFiles: files,
+		Stdout:     logs,
+		Stderr:     logs,
+		// the Setpgid flag is used to prevent the responder from exiting when
+		// the parent is killed
+		SysProcAttr: &syscall.SysProcAttr{
+			Setpgid: true,
+		},
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start DNS responder: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("pid", cmd.Process.Pid).
+		WithField("network", network.Name).
+		WithField("addresses", strings.Join(addrs, ", ")).
+		Debug("started DNS responder")
+
+	if err := pidfile.Write(pidFile(ctx, network.Name), cmd.Process.Pid); err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("could not save pid of DNS responder: %w", err)
+	}
+
+	return cmd.Process.Release()
+}
+
+// Stop terminates the responder of the network, if any.
+func Stop(ctx context.Context, network string) error {
+	pid, err := pidfile.Lookup(pidFile(ctx, network), reexecName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not look up DNS responder: %w", err)
+	}
+
+	// The responder may have already exited together with the network, in
+	// which case its PID may since have been reused by an unrelated process.
+	if pid > 0 {
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("could not stop DNS responder: %w", err)
+		}
+	}
+
+	return os.Remove(pidFile(ctx, network))
+}
+
+// Nameservers returns the addresses of the responder of the network which
+// should be used by the machines on the network, or none if the responder of
+// the network is not running.
+func Nameservers(ctx context.Context, network *networkv1alpha1.Network) []string {
+	if pid(ctx, network.Name) == 0 {
+		return nil
+	}
+
+	return addresses(network)
+}
+
+// pid returns the PID of the responder of the network if it is running, or 0
+// otherwise.
+func pid(ctx context.Context, network string) int {
+	pid, err := pidfile.Lookup(pidFile(ctx, network), reexecName)
+	if err != nil {
+		return 0
+	}
+
+	return pid
+}
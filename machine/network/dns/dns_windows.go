@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package dns
+
+import (
+	"context"
+	"fmt"
+
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
+)
+
+// Start implements Start for unsupported hosts.
+func Start(ctx context.Context, network *networkv1alpha1.Network) error {
+	return fmt.Errorf("embedded DNS is not supported on this host")
+}
+
+// Stop implements Stop for unsupported hosts.
+func Stop(ctx context.Context, network string) error {
+	return nil
+}
+
+// Nameservers implements Nameservers for unsupported hosts.
+func Nameservers(ctx context.Context, network *networkv1alpha1.Network) []string {
+	return nil
+}
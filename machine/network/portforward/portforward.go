@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package portforward publishes the ports of a machine which is attached to a
+// network, e.g. a bridge, by relaying TCP and UDP traffic received on the host
+// to the IP address of the machine on that network.  The forwarder runs as a
+// detached process alongside the VMM such that it outlives the invoking
+// program and exits together with the machine.
+package portforward
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	goprocess "github.com/shirou/gopsutil/v3/process"
+	corev1 "k8s.io/api/core/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+const (
+	// PidFile is the name of the file within the state directory of the machine
+	// which contains the PID of its forwarder.
+	PidFile = "portforward.pid"
+
+	// LogFile is the name of the file within the state directory of the machine
+	// which contains the output of its forwarder.
+	LogFile = "portforward.log"
+
+	// reexecName is the name under which the forwarder is registered such that
+	// the running binary can be re-executed as the forwarder.
+	reexecName = "kraftkit-portforward"
+
+	dialTimeout    = 5 * time.Second
+	udpIdleTimeout = 60 * time.Second
+	pollInterval   = time.Second
+	maxDatagram    = 65535
+)
+
+func init() {
+	reexec.Register(reexecName, serve)
+}
+
+// machineIP returns the IP address of the first network interface of the
+// machine.
+func machineIP(machine *machinev1alpha1.Machine) (string, error) {
+	for _, network := range machine.Spec.Networks {
+		for _, iface := range network.Interfaces {
+			if iface.Spec.IP != "" {
+				return iface.Spec.IP, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("cannot publish ports: machine has no IP address on a network")
+}
+
+// listen binds each of the provided ports on the host and returns the
+// underlying files such that they can be inherited by the forwarder.  Binding
+// the ports before the forwarder is spawned allows for errors, such as ports
+// which are already in use, to be reported immediately.
+func listen(ports machinev1alpha1.MachinePorts) ([]*os.File, error) {
+	files := make([]*os.File, 0, len(ports))
+
+	closeAll := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	for _, port := range ports {
+		addr := net.JoinHostPort(port.HostIP, strconv.Itoa(int(port.HostPort)))
+
+		var file *os.File
+
+		switch protocol(port) {
+		case corev1.ProtocolTCP:
+			l, err := net.Listen("tcp", addr)
+			if err != nil {
+				closeAll()
+				return nil, fmt.Errorf("could not publish port %s/tcp: %w", addr, err)
+			}
+
+			file, err = l.(*net.TCPListener).File()
+			l.Close()
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+
+		case corev1.ProtocolUDP:
+			pc, err := net.ListenPacket("udp", addr)
+			if err != nil {
+				closeAll()
+				return nil, fmt.Errorf("could not publish port %s/udp: %w", addr, err)
+			}
+
+			file, err = pc.(*net.UDPConn).File()
+			pc.Close()
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+
+		default:
+			closeAll()
+			return nil, fmt.Errorf("unsupported port protocol: %s", port.Protocol)
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// protocol returns the normalized protocol of the port.
+func protocol(port machinev1alpha1.MachinePort) corev1.Protocol {
+	if port.Protocol == "" {
+		return machinev1alpha1.DefaultProtocol
+	}
+
+	return corev1.Protocol(strings.ToUpper(string(port.Protocol)))
+}
+
+// serve is the entrypoint of the forwarder process.  It is invoked with the
+// PID of the VMM, the IP address of the machine and the ports to publish,
+// whose bound sockets are inherited in the same order starting at file
+// descriptor 3.
+func serve() {
+	if len(os.Args) != 4 {
+		fmt.Fprintf(os.Stderr, "usage: %s PID IP PORTS\n", reexecName)
+		os.Exit(1)
+	}
+
+	pid, err := strconv.ParseInt(os.Args[1], 10, 32)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse pid: %v\n", err)
+		os.Exit(1)
+	}
+
+	var ports machinev1alpha1.MachinePorts
+	if err := json.Unmarshal([]byte(os.Args[3]), &ports); err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse ports: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// Exit together with the machine.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+
+			if exists, err := goprocess.PidExistsWithContext(ctx, int32(pid)); err == nil && !exists {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for i, port := range ports {
+		file := os.NewFile(uintptr(3+i), reexecName)
+		target := net.JoinHostPort(os.Args[2], strconv.Itoa(int(port.MachinePort)))
+
+		switch protocol(port) {
+		case corev1.ProtocolTCP:
+			l, err := net.FileListener(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "could not inherit listener for %s: %v\n", target, err)
+				os.Exit(1)
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				forwardTCP(ctx, l, target)
+			}()
+
+		case corev1.ProtocolUDP:
+			pc, err := net.FilePacketConn(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "could not inherit listener for %s: %v\n", target, err)
+				os.Exit(1)
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				forwardUDP(ctx, pc, target)
+			}()
+		}
+
+		file.Close()
+	}
+
+	wg.Wait()
+	os.Exit(0)
+}
+
+// forwardTCP accepts connections on the provided listener and relays each of
+// them to the target until the context is cancelled.
+func forwardTCP(ctx context.Context, l net.Listener, target string) {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			upstream, err := (&net.Dialer{Timeout: dialTimeout}).DialContext(ctx, "tcp", target)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "could not connect to %s: %v\n", target, err)
+				return
+			}
+
+			defer upstream.Close()
+
+			relay(conn, upstream)
+		}()
+	}
+}
+
+// relay copies data between both connections in each direction until both
+// directions have been closed.
+func relay(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	copyAndClose := func(dst, src net.Conn) {
+		defer wg.Done()
+
+		_, _ = io.Copy(dst, src)
+
+		// Propagate the end of the stream whilst allowing for the other
+		// direction to complete.
+		if conn, ok := dst.(*net.TCPConn); ok {
+			_ = conn.CloseWrite()
+		} else {
+			_ = dst.Close()
+		}
+	}
+
+	go copyAndClose(a, b)
+	go copyAndClose(b, a)
+
+	wg.Wait()
+}
+
+// forwardUDP relays datagrams received on the provided connection to the
+// target and the replies of the target back to the respective sender until
+// the context is cancelled.  Each sender is assigned its own upstream
+// connection which is released once it has been idle for some time.
+func forwardUDP(ctx context.Context, pc net.PacketConn, target string) {
+	go func() {
+		<-ctx.Done()
+		pc.Close()
+	}()
+
+	var lock sync.Mutex
+	sessions := make(map[string]net.Conn)
+
+	buf := make([]byte, maxDatagram)
+
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		lock.Lock()
+		upstream, ok := sessions[addr.String()]
+		if !ok {
+			upstream, err = net.DialTimeout("udp", target, dialTimeout)
+			if err != nil {
+				lock.Unlock()
+				fmt.Fprintf(os.Stderr, "could not connect to %s: %v\n", target, err)
+				continue
+			}
+
+			sessions[addr.String()] = upstream
+
+			go func(addr net.Addr, upstream net.Conn) {
+				defer func() {
+					lock.Lock()
+					delete(sessions, addr.String())
+					lock.Unlock()
+					upstream.Close()
+				}()
+
+				reply := make([]byte, maxDatagram)
+
+				for {
+					if err := upstream.SetReadDeadline(time.Now().Add(udpIdleTimeout)); err != nil {
+						return
+					}
+
+					n, err := upstream.Read(reply)
+					if err != nil {
+						return
+					}
+
+					if _, err := pc.WriteTo(reply[:n], addr); err != nil {
+						return
+					}
+				}
+			}(addr, upstream)
+		}
+		lock.Unlock()
+
+		if _, err := upstream.Write(buf[:n]); err != nil {
+			fmt.Fprintf(os.Stderr, "could not forward datagram to %s: %v\n", target, err)
+		}
+	}
+}
+
+// pidFile returns the path to the PID file of the forwarder of the machine.
+func pidFile(machine *machinev1alpha1.Machine) string {
+	return filepath.Join(machine.Status.StateDir, PidFile)
+}
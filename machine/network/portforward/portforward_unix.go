@@ -0,0 +1,118 @@
+//go:build !windows
+// +build !windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package portforward
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/internal/pidfile"
+	"kraftkit.sh/log"
+)
+
+// Start publishes the ports of the machine by spawning a detached forwarder
+// which relays traffic from the host to the IP address of the machine on its
+// first network.  The forwarder exits once the process of the machine, as
+// indicated by its PID, has exited or when it is stopped via Stop.
+func Start(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	if len(machine.Spec.Ports) == 0 {
+		return nil
+	}
+
+	ip, err := machineIP(machine)
+	if err != nil {
+		return err
+	}
+
+	ports, err := json.Marshal(machine.Spec.Ports)
+	if err != nil {
+		return err
+	}
+
+	files, err := listen(machine.Spec.Ports)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	logFile, err := os.Create(filepath.Join(machine.Status.StateDir, LogFile))
+	if err != nil {
+		return err
+	}
+
+	defer logFile.Close()
+
+	cmd := &exec.Cmd{
+		Path: reexec.Self(),
+		Args: []string{
+			reexecName,
+			strconv.Itoa(int(machine.Status.Pid)),
+			ip,
+			string(ports),
+		},
+		ExtraFiles: files,
+		Stdout:     logFile,
+		Stderr:     logFile,
+		// the Setpgid flag is used to prevent the forwarder from exiting when
+		// the parent is killed
+		SysProcAttr: &syscall.SysProcAttr{
+			Setpgid: true,
+		},
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start port forwarder: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("pid", cmd.Process.Pid).
+		WithField("ports", machine.Spec.Ports.String()).
+		Debug("started port forwarder")
+
+	if err := pidfile.Write(pidFile(machine), cmd.Process.Pid); err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("could not save pid of port forwarder: %w", err)
+	}
+
+	return cmd.Process.Release()
+}
+
+// Stop terminates the forwarder of the machine, if any.
+func Stop(machine *machinev1alpha1.Machine) error {
+	pid, err := pidfile.Lookup(pidFile(machine), reexecName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read pid of port forwarder: %w", err)
+	}
+
+	// The forwarder may have already exited together with the machine, in which
+	// case its PID may have been reused by an unrelated process.
+	if pid > 0 {
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("could not stop port forwarder: %w", err)
+		}
+	}
+
+	return os.Remove(pidFile(machine))
+}
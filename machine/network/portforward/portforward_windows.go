@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package portforward
+
+import (
+	"context"
+	"fmt"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// Start implements Start for unsupported hosts.
+func Start(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	if len(machine.Spec.Ports) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("publishing ports of networked machines is not supported on this host")
+}
+
+// Stop implements Stop for unsupported hosts.
+func Stop(machine *machinev1alpha1.Machine) error {
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/config"
+	"kraftkit.sh/machine/daemon"
 	"kraftkit.sh/machine/network/bridge"
 	"kraftkit.sh/machine/store"
 )
@@ -21,7 +22,7 @@ import (
 func hostSupportedStrategies() map[string]*Strategy {
 	return map[string]*Strategy{
 		"bridge": {
-			NewNetworkV1alpha1: func(ctx context.Context, opts ...any) (networkv1alpha1.NetworkService, error) {
+			NewNetworkV1alpha1: daemon.NetworkV1alpha1("bridge", func(ctx context.Context, opts ...any) (networkv1alpha1.NetworkService, error) {
 				service, err := bridge.NewNetworkServiceV1alpha1(ctx, opts...)
 				if err != nil {
 					return nil, err
@@ -42,7 +43,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 					service,
 					zip.WithStore[networkv1alpha1.NetworkSpec, networkv1alpha1.NetworkStatus](embeddedStore, zip.StoreRehydrationSpecNil),
 				)
-			},
+			}),
 		},
 	}
 }
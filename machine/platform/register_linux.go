@@ -6,14 +6,13 @@ package platform
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/internal/set"
+	"kraftkit.sh/machine/daemon"
 	"kraftkit.sh/machine/firecracker"
-	"kraftkit.sh/machine/store"
 )
 
 var firecrackerV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
@@ -25,12 +24,7 @@ var firecrackerV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev
 		return nil, err
 	}
 
-	embeddedStore, err := store.NewEmbeddedStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
-		filepath.Join(
-			config.G[config.KraftKit](ctx).RuntimeDir,
-			"machinev1alpha1",
-		),
-	)
+	embeddedStore, err := NewMachineV1alpha1Store(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -43,13 +37,25 @@ var firecrackerV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev
 	)
 }
 
+var firecrackerV1alpha1Snapshotter = func(ctx context.Context, opts ...any) (MachineSnapshotter, error) {
+	if set.NewStringSet("debug", "trace").Contains(config.G[config.KraftKit](ctx).Log.Level) {
+		opts = append(opts, firecracker.WithDebug(true))
+	}
+
+	return snapshotter(firecracker.NewMachineV1alpha1Service(ctx, opts...))
+}
+
 func unixVariantStrategies() map[Platform]*Strategy {
 	// TODO(jake-ciolek): The firecracker driver has a dependency on github.com/containernetworking/plugins/pkg/ns via
 	// github.com/firecracker-microvm/firecracker-go-sdk
 	// Unfortunately, it doesn't support darwin.
 	return map[Platform]*Strategy{
 		PlatformFirecracker: {
-			NewMachineV1alpha1: firecrackerV1alpha1Driver,
+			NewMachineV1alpha1:            daemon.MachineV1alpha1(PlatformFirecracker.String(), firecrackerV1alpha1Driver),
+			NewMachineSnapshotterV1alpha1: firecrackerV1alpha1Snapshotter,
+			DecodePlatformConfig: func(platformConfig interface{}) (interface{}, error) {
+				return firecracker.DecodePlatformConfig(platformConfig)
+			},
 		},
 	}
 }
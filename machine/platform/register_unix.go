@@ -9,14 +9,12 @@ package platform
 
 import (
 	"context"
-	"path/filepath"
 
 	zip "api.zip"
 
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
-	"kraftkit.sh/config"
+	"kraftkit.sh/machine/daemon"
 	"kraftkit.sh/machine/qemu"
-	"kraftkit.sh/machine/store"
 )
 
 var qemuV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev1alpha1.MachineService, error) {
@@ -25,12 +23,7 @@ var qemuV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev1alpha1
 		return nil, err
 	}
 
-	embeddedStore, err := store.NewEmbeddedStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
-		filepath.Join(
-			config.G[config.KraftKit](ctx).RuntimeDir,
-			"machinev1alpha1",
-		),
-	)
+	embeddedStore, err := NewMachineV1alpha1Store(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -43,12 +36,20 @@ var qemuV1alpha1Driver = func(ctx context.Context, opts ...any) (machinev1alpha1
 	)
 }
 
+var qemuV1alpha1Snapshotter = func(ctx context.Context, opts ...any) (MachineSnapshotter, error) {
+	return snapshotter(qemu.NewMachineV1alpha1Service(ctx, opts...))
+}
+
 // hostSupportedStrategies returns the map of known supported drivers for the
 // given host.
 func hostSupportedStrategies() map[Platform]*Strategy {
 	s := map[Platform]*Strategy{
 		PlatformQEMU: {
-			NewMachineV1alpha1: qemuV1alpha1Driver,
+			NewMachineV1alpha1:            daemon.MachineV1alpha1(PlatformQEMU.String(), qemuV1alpha1Driver),
+			NewMachineSnapshotterV1alpha1: qemuV1alpha1Snapshotter,
+			DecodePlatformConfig: func(platformConfig interface{}) (interface{}, error) {
+				return qemu.DecodePlatformConfig(platformConfig)
+			},
 		},
 	}
 
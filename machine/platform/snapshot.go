@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package platform
+
+import (
+	"context"
+	"fmt"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// MachineSnapshotter is implemented by the platform drivers which are able to
+// save the complete state of a machine to a directory on the host.  A machine
+// is restored from this state by creating it with the directory set as the
+// SnapshotDir of its status.
+type MachineSnapshotter interface {
+	// Snapshot saves the state of the provided machine to the provided
+	// directory.  A running machine is paused for the duration of the snapshot
+	// and is resumed afterwards.
+	Snapshot(context.Context, *machinev1alpha1.Machine, string) error
+}
+
+// NewMachineSnapshotterV1alpha1 returns the snapshotter of the provided
+// platform.
+func NewMachineSnapshotterV1alpha1(ctx context.Context, platform Platform) (MachineSnapshotter, error) {
+	strategy, ok := Strategies()[platform]
+	if !ok {
+		return nil, fmt.Errorf("unsupported platform driver: %s (contributions welcome!)", platform.String())
+	} else if strategy.NewMachineSnapshotterV1alpha1 == nil {
+		return nil, fmt.Errorf("platform driver does not support snapshots: %s", platform.String())
+	}
+
+	return strategy.NewMachineSnapshotterV1alpha1(ctx)
+}
+
+// snapshotter returns the snapshotter of the provided machine service as
+// returned by the constructor of a platform driver.
+func snapshotter(service machinev1alpha1.MachineService, err error) (MachineSnapshotter, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotter, ok := service.(MachineSnapshotter)
+	if !ok {
+		return nil, fmt.Errorf("machine service does not support snapshots")
+	}
+
+	return snapshotter, nil
+}
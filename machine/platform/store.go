@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package platform
+
+import (
+	"context"
+	"path/filepath"
+
+	zip "api.zip"
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/machine/store"
+)
+
+// NewMachineV1alpha1Store returns the store of machines which is shared
+// between all platforms.  Since stores are shared within the process, watching
+// it reports the changes which are made through any platform driver.
+func NewMachineV1alpha1Store(ctx context.Context) (zip.Store, error) {
+	return store.NewEmbeddedStore[machinev1alpha1.MachineSpec, machinev1alpha1.MachineStatus](
+		filepath.Join(
+			config.G[config.KraftKit](ctx).RuntimeDir,
+			"machinev1alpha1",
+		),
+	)
+}
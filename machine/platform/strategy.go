@@ -23,9 +23,11 @@ type NewStrategyConstructor[T any] func(context.Context, ...any) (T, error)
 // Strategy represents canonical reference of a machine driver and their
 // platform.
 type Strategy struct {
-	Name               string
-	Platform           Platform
-	NewMachineV1alpha1 NewStrategyConstructor[machinev1alpha1.MachineService]
+	Name                          string
+	Platform                      Platform
+	NewMachineV1alpha1            NewStrategyConstructor[machinev1alpha1.MachineService]
+	NewMachineSnapshotterV1alpha1 NewStrategyConstructor[MachineSnapshotter]
+	DecodePlatformConfig          func(interface{}) (interface{}, error)
 }
 
 // Strategies returns the list of registered platform implementations.
@@ -51,3 +53,21 @@ func DriverNames() []string {
 
 	return ret
 }
+
+// DecodePlatformConfig returns the platform configuration of the provided
+// machine decoded into the configuration type of its platform driver, e.g.
+// QemuConfig, regardless of whether it has been retrieved from the store or
+// decoded from JSON.  The configuration is returned unchanged if the platform
+// driver does not provide a decoder.
+func DecodePlatformConfig(machine *machinev1alpha1.Machine) (interface{}, error) {
+	if machine.Status.PlatformConfig == nil {
+		return nil, nil
+	}
+
+	strategy, ok := Strategies()[PlatformByName(machine.Spec.Platform)]
+	if !ok || strategy.DecodePlatformConfig == nil {
+		return machine.Status.PlatformConfig, nil
+	}
+
+	return strategy.DecodePlatformConfig(machine.Status.PlatformConfig)
+}
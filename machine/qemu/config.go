@@ -9,6 +9,7 @@ import "kraftkit.sh/internal/run"
 type QemuConfig struct {
 	// Command-line arguments for qemu-system-*
 	Accel      QemuMachineAccelerator `flag:"-accel"       json:"accel,omitempty"`
+	Action     string                 `flag:"-action"      json:"action,omitempty"`
 	Append     string                 `flag:"-append"      json:"append,omitempty"`
 	CharDevs   []QemuCharDev          `flag:"-chardev"     json:"chardev,omitempty"`
 	CPU        QemuCPU                `flag:"-cpu"         json:"cpu,omitempty"`
@@ -17,6 +18,8 @@ type QemuConfig struct {
 	Display    QemuDisplay            `flag:"-display"     json:"display,omitempty"`
 	EnableKVM  bool                   `flag:"-enable-kvm"  json:"enable_kvm,omitempty"`
 	FsDevs     []QemuFsDev            `flag:"-fsdev"       json:"fsdev,omitempty"`
+	GDB        string                 `flag:"-gdb"         json:"gdb,omitempty"`
+	Incoming   string                 `flag:"-incoming"    json:"incoming,omitempty"`
 	InitRd     string                 `flag:"-initrd"      json:"initrd,omitempty"`
 	Kernel     string                 `flag:"-kernel"      json:"kernel,omitempty"`
 	Machine    QemuMachine            `flag:"-machine"     json:"machine,omitempty"`
@@ -69,6 +72,13 @@ func WithAccel(accel QemuMachineAccelerator) QemuOption {
 	}
 }
 
+func WithAction(action string) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.Action = action
+		return nil
+	}
+}
+
 func WithAppend(append ...string) QemuOption {
 	return func(qc *QemuConfig) error {
 		qc.Append = run.BootArgsPrepare(append...)
@@ -140,6 +150,20 @@ func WithFsDevice(fsdev QemuFsDev) QemuOption {
 	}
 }
 
+func WithGDB(dev string) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.GDB = dev
+		return nil
+	}
+}
+
+func WithIncoming(incoming string) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.Incoming = incoming
+		return nil
+	}
+}
+
 func WithInitRd(initrd string) QemuOption {
 	return func(qc *QemuConfig) error {
 		qc.InitRd = initrd
@@ -19,7 +19,7 @@ func init() {
 	// Character devices
 	// gob.Register(QemuCharDevNull{})
 	// gob.Register(QemuCharDevSocketTCP{})
-	// gob.Register(QemuCharDevSocketUnix{})
+	gob.Register(QemuCharDevSocketUnix{})
 	// gob.Register(QemuCharDevUdp{})
 	// gob.Register(QemuCharDevVirtualConsole{})
 	// gob.Register(QemuCharDevRingBuf{})
@@ -39,7 +39,7 @@ func init() {
 	// gob.Register(QemuHostCharDevPty{})
 	gob.Register(QemuHostCharDevNone{})
 	// gob.Register(QemuHostCharDevNull{})
-	// gob.Register(QemuHostCharDevNamed{})
+	gob.Register(QemuHostCharDevNamed{})
 	// gob.Register(QemuHostCharDevTty{})
 	gob.Register(QemuHostCharDevFile{})
 	// gob.Register(QemuHostCharDevStdio{})
@@ -280,7 +280,7 @@ func init() {
 	// gob.Register(QemuDeviceVhostVsockPci{})
 	// gob.Register(QemuDeviceVhostVsockPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioBalloonDevice{})
-	// gob.Register(QemuDeviceVirtioBalloonPci{})
+	gob.Register(QemuDeviceVirtioBalloonPci{})
 	// gob.Register(QemuDeviceVirtioBalloonPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioBalloonPciTransitional{})
 	// gob.Register(QemuDeviceVirtioCryptoDevice{})
@@ -6,10 +6,15 @@ package qemu
 
 import (
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
 )
 
 type QemuHostCharDev interface {
@@ -51,10 +56,14 @@ func (cd QemuHostCharDevVirtualConsole) Resource() string {
 }
 
 func (cd QemuHostCharDevVirtualConsole) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevVirtualConsole.Connection")
+	return nil, fmt.Errorf("virtual console is only accessible through the display of QEMU")
 }
 
-type QemuHostCharDevPty struct{}
+type QemuHostCharDevPty struct {
+	// Path of the pseudo-terminal which is allocated by QEMU once it has started,
+	// e.g. /dev/pts/3.
+	Path string `json:"path,omitempty"`
+}
 
 func (cd QemuHostCharDevPty) String() string {
 	return string(QemuCharDevTypePty)
@@ -65,7 +74,11 @@ func (cd QemuHostCharDevPty) Resource() string {
 }
 
 func (cd QemuHostCharDevPty) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevPty.Connection")
+	if len(cd.Path) == 0 {
+		return nil, fmt.Errorf("path of pseudo-terminal allocated by QEMU is unknown")
+	}
+
+	return openCharDevFile(cd.Path, cd.Path)
 }
 
 type QemuHostCharDevNone struct{}
@@ -79,7 +92,7 @@ func (cd QemuHostCharDevNone) Resource() string {
 }
 
 func (cd QemuHostCharDevNone) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevNone.Connection")
+	return nil, fmt.Errorf("no character device is attached")
 }
 
 type QemuHostCharDevNull struct{}
@@ -94,7 +107,11 @@ func (cd QemuHostCharDevNull) Resource() string {
 }
 
 func (cd QemuHostCharDevNull) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevNull.Connection")
+	return &hostCharDevConn{
+		Reader: strings.NewReader(""),
+		Writer: io.Discard,
+		addr:   hostCharDevAddr(cd.String()),
+	}, nil
 }
 
 type QemuHostCharDevNamed struct {
@@ -125,7 +142,7 @@ func (cd QemuHostCharDevNamed) Resource() string {
 }
 
 func (cd QemuHostCharDevNamed) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevNamed.Connection")
+	return nil, fmt.Errorf("cannot connect to named character device '%s' without its backend", cd.Id)
 }
 
 type QemuHostCharDevTty struct {
@@ -158,7 +175,7 @@ func (cd QemuHostCharDevTty) Resource() string {
 }
 
 func (cd QemuHostCharDevTty) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevTty.Connection")
+	return openCharDevFile(cd.Path, cd.Path)
 }
 
 type QemuHostCharDevFile struct {
@@ -189,7 +206,18 @@ func (cd QemuHostCharDevFile) Resource() string {
 }
 
 func (cd QemuHostCharDevFile) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevFile.Connection")
+	// QEMU only writes to the file, such that the connection is read-only.
+	f, err := os.Open(cd.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hostCharDevConn{
+		Reader:  f,
+		Writer:  readOnlyWriter{},
+		closers: []io.Closer{f},
+		addr:    hostCharDevAddr(cd.Filename),
+	}, nil
 }
 
 type QemuHostCharDevStdio struct {
@@ -213,7 +241,7 @@ func (cd QemuHostCharDevStdio) Resource() string {
 }
 
 func (cd QemuHostCharDevStdio) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevStdio.Connection")
+	return nil, fmt.Errorf("standard input and output of QEMU are only accessible to its parent process")
 }
 
 type QemuHostCharDevPipe struct {
@@ -244,7 +272,14 @@ func (cd QemuHostCharDevPipe) Resource() string {
 }
 
 func (cd QemuHostCharDevPipe) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevPipe.Connection")
+	// QEMU reads from FILENAME.in and writes to FILENAME.out if both exist, and
+	// otherwise uses FILENAME for both.
+	in, out := cd.Filename+".in", cd.Filename+".out"
+	if _, err := os.Stat(in); err != nil {
+		in, out = cd.Filename, cd.Filename
+	}
+
+	return openCharDevFile(out, in)
 }
 
 type QemuHostCharDevUDP struct {
@@ -296,7 +331,22 @@ func (cd QemuHostCharDevUDP) Resource() string {
 }
 
 func (cd QemuHostCharDevUDP) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevUDP.Connection")
+	if cd.SourcePort <= 0 {
+		return nil, fmt.Errorf("cannot connect to UDP character device without source port")
+	}
+
+	// QEMU sends to the remote address and receives on the source address.
+	laddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(cd.RemoteHost, strconv.Itoa(cd.RemotePort)))
+	if err != nil {
+		return nil, err
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(dialableHost(cd.SourceHost), strconv.Itoa(cd.SourcePort)))
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialUDP("udp", laddr, raddr)
 }
 
 const (
@@ -359,7 +409,15 @@ func (cd QemuHostCharDevTCP) Resource() string {
 }
 
 func (cd QemuHostCharDevTCP) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevTCP.Connection")
+	if !cd.Server {
+		return nil, fmt.Errorf("cannot connect to TCP character device which is not a server")
+	}
+
+	if cd.Port <= 0 {
+		cd.Port = QemuHostCharDevTCPDefaultPort
+	}
+
+	return net.Dial("tcp", net.JoinHostPort(dialableHost(cd.Host), strconv.Itoa(cd.Port)))
 }
 
 type QemuHostCharDevTelnet struct {
@@ -412,7 +470,11 @@ func (cd QemuHostCharDevTelnet) Resource() string {
 }
 
 func (cd QemuHostCharDevTelnet) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevTelnet.Connection")
+	if !cd.Server {
+		return nil, fmt.Errorf("cannot connect to telnet character device which is not a server")
+	}
+
+	return net.Dial("tcp", net.JoinHostPort(dialableHost(cd.Host), strconv.Itoa(cd.Port)))
 }
 
 type QemuHostCharDevWebsocket struct {
@@ -464,7 +526,24 @@ func (cd QemuHostCharDevWebsocket) Resource() string {
 }
 
 func (cd QemuHostCharDevWebsocket) Connection() (net.Conn, error) {
-	return nil, fmt.Errorf("not implemented: machine.qemu.QemuHostCharDevWebsocket.Connection")
+	address := net.JoinHostPort(dialableHost(cd.Host), strconv.Itoa(cd.Port))
+
+	config, err := websocket.NewConfig("ws://"+address+"/", "http://"+address+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	// QEMU only accepts connections which use the binary sub-protocol.
+	config.Protocol = []string{"binary"}
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.PayloadType = websocket.BinaryFrame
+
+	return conn, nil
 }
 
 type QemuHostCharDevUnix struct {
@@ -519,3 +598,127 @@ func (cd QemuHostCharDevUnix) Resource() string {
 func (cd QemuHostCharDevUnix) Connection() (net.Conn, error) {
 	return net.Dial("unix", cd.Resource())
 }
+
+// dialableHost returns the host at which a character device which listens on
+// the provided host can be reached.
+func dialableHost(host string) string {
+	if len(host) == 0 || host == "0.0.0.0" {
+		return "127.0.0.1"
+	}
+
+	return host
+}
+
+// openCharDevFile returns a connection which reads from the file that QEMU
+// writes to and which writes to the file that QEMU reads from.  Both are the
+// same file for, e.g., a TTY.
+func openCharDevFile(read, write string) (net.Conn, error) {
+	r, err := os.OpenFile(read, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if read == write {
+		return &hostCharDevConn{
+			Reader:  r,
+			Writer:  r,
+			closers: []io.Closer{r},
+			addr:    hostCharDevAddr(read),
+		}, nil
+	}
+
+	w, err := os.OpenFile(write, os.O_WRONLY, 0)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return &hostCharDevConn{
+		Reader:  r,
+		Writer:  w,
+		closers: []io.Closer{r, w},
+		addr:    hostCharDevAddr(read),
+	}, nil
+}
+
+// hostCharDevAddr is the address of a character device which is not backed by
+// a socket, i.e. its path.
+type hostCharDevAddr string
+
+// Network implements net.Addr
+func (addr hostCharDevAddr) Network() string {
+	return "chardev"
+}
+
+// String implements net.Addr
+func (addr hostCharDevAddr) String() string {
+	return string(addr)
+}
+
+// hostCharDevConn implements net.Conn for character devices which are not
+// backed by a socket.
+type hostCharDevConn struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+	addr    hostCharDevAddr
+}
+
+// Close implements net.Conn
+func (conn *hostCharDevConn) Close() error {
+	var err error
+
+	for _, closer := range conn.closers {
+		if cerr := closer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// LocalAddr implements net.Conn
+func (conn *hostCharDevConn) LocalAddr() net.Addr {
+	return conn.addr
+}
+
+// RemoteAddr implements net.Conn
+func (conn *hostCharDevConn) RemoteAddr() net.Addr {
+	return conn.addr
+}
+
+// SetDeadline implements net.Conn
+func (conn *hostCharDevConn) SetDeadline(t time.Time) error {
+	if err := conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+
+	return conn.SetWriteDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn
+func (conn *hostCharDevConn) SetReadDeadline(t time.Time) error {
+	if f, ok := conn.Reader.(*os.File); ok {
+		return f.SetReadDeadline(t)
+	}
+
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn
+func (conn *hostCharDevConn) SetWriteDeadline(t time.Time) error {
+	if f, ok := conn.Writer.(*os.File); ok {
+		return f.SetWriteDeadline(t)
+	}
+
+	return nil
+}
+
+// readOnlyWriter rejects all writes to a character device which can only be
+// read from.
+type readOnlyWriter struct{}
+
+// Write implements io.Writer
+func (readOnlyWriter) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("character device is read-only")
+}
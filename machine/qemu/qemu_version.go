@@ -19,6 +19,7 @@ import (
 var (
 	QemuVersion4_2_0 = semver.New(4, 2, 0, "", "")
 	QemuVersion5_2_0 = semver.New(5, 2, 0, "", "")
+	QemuVersion6_0_0 = semver.New(6, 0, 0, "", "")
 	QemuVersion6_2_0 = semver.New(6, 2, 0, "", "")
 	QemuVersion7_2_0 = semver.New(7, 2, 0, "", "")
 	QemuVersion7_2_4 = semver.New(7, 2, 4, "", "")
@@ -57,14 +57,20 @@ type QMPEvent[T utils.ComparableStringer] struct {
 }
 
 type QMPEventMonitor[T utils.ComparableStringer] struct {
-	client *bufio.Reader
-	types  []T
+	client  *bufio.Reader
+	types   []T
+	typeMap map[T]reflect.Type
 }
 
+// NewQMPEventMonitor returns a monitor which accepts the provided types of
+// events from the QMP service.  The data of events whose type is in the
+// provided type map is decoded into a new value of the mapped type, otherwise
+// it is decoded as a generic map.
 func NewQMPEventMonitor[T utils.ComparableStringer](client io.ReadWriteCloser, types []T, typeMap map[T]reflect.Type) (*QMPEventMonitor[T], error) {
 	monitor := QMPEventMonitor[T]{
-		client: bufio.NewReader(client),
-		types:  types,
+		client:  bufio.NewReader(client),
+		types:   types,
+		typeMap: typeMap,
 	}
 
 	return &monitor, nil
@@ -78,22 +84,25 @@ func (em *QMPEventMonitor[T]) Accept() (*QMPEvent[T], error) {
 		return nil, err
 	}
 
-	// Use a generic to serialize as a map (which is the very least we can expect)
-	// from the Unmarshal result.  We can then peak at it before applying a type.
-	var raw map[string]any
+	// Decode only the envelope of the message such that we can peak at its type
+	// before decoding its data.
+	var raw struct {
+		Event     *string         `json:"event"`
+		Data      json.RawMessage `json:"data"`
+		Timestamp Timestamp       `json:"timestamp"`
+	}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, err
 	}
 
-	typ, ok := raw["event"]
-	if !ok {
+	if raw.Event == nil {
 		return nil, ErrAcceptedNonEvent
 	}
 
 	var t T
 	found := false
 	for _, needle := range em.types {
-		if needle.String() == typ {
+		if needle.String() == *raw.Event {
 			t = needle
 			found = true
 			break
@@ -101,10 +110,36 @@ func (em *QMPEventMonitor[T]) Accept() (*QMPEvent[T], error) {
 	}
 
 	if !found {
-		return nil, fmt.Errorf("unknown QMP event type: %s", typ)
+		return nil, fmt.Errorf("unknown QMP event type: %s", *raw.Event)
 	}
 
-	return &QMPEvent[T]{
+	event := QMPEvent[T]{
 		Event: t,
-	}, nil
+		Timestamp: time.Unix(
+			int64(raw.Timestamp.Seconds),
+			int64(raw.Timestamp.Microseconds)*int64(time.Microsecond),
+		),
+	}
+
+	if len(raw.Data) == 0 {
+		return &event, nil
+	}
+
+	if typ := em.typeMap[t]; typ != nil {
+		value := reflect.New(typ)
+		if err := json.Unmarshal(raw.Data, value.Interface()); err != nil {
+			return nil, fmt.Errorf("could not decode data of QMP event %s: %w", t, err)
+		}
+
+		event.Data = value.Elem().Interface()
+	} else {
+		var data map[string]any
+		if err := json.Unmarshal(raw.Data, &data); err != nil {
+			return nil, fmt.Errorf("could not decode data of QMP event %s: %w", t, err)
+		}
+
+		event.Data = data
+	}
+
+	return &event, nil
 }
@@ -27,3 +27,30 @@ type SystemPowerdownRequest struct {
 type SystemWakeupRequest struct {
 	Execute string `json:"execute" default:"system_Wakeup"`
 }
+
+type BalloonRequest struct {
+	Execute string `json:"execute" default:"balloon"`
+
+	Arguments BalloonRequestArguments `json:"arguments,omitempty"`
+}
+
+type BalloonRequestArguments struct {
+	Value int64 `json:"value"`
+}
+
+type QueryBalloonRequest struct {
+	Execute string `json:"execute" default:"query-balloon"`
+}
+
+// Information about the guest balloon device.
+//
+// Since: 0.14
+type BalloonInfo struct {
+	// the logical size of the VM in bytes Formula used:
+	// logical_vm_size = vm_ram_size - balloon_size
+	Actual int64 `json:"actual"`
+}
+
+type QueryBalloonResponse struct {
+	Return BalloonInfo `json:"return"`
+}
@@ -10,13 +10,15 @@ import (
 	"io"
 	"reflect"
 	"sync"
+	"time"
 )
 
 type QEMUMachineProtocolClient struct {
-	conn io.ReadWriteCloser
-	lock sync.RWMutex
-	recv *bufio.Reader
-	send *bufio.Writer
+	conn   io.ReadWriteCloser
+	lock   sync.RWMutex
+	recv   *bufio.Reader
+	send   *bufio.Writer
+	events [][]byte
 }
 
 func NewQEMUMachineProtocolClient(conn io.ReadWriteCloser) *QEMUMachineProtocolClient {
@@ -31,6 +33,75 @@ func (c *QEMUMachineProtocolClient) Close() error {
 	return c.conn.Close()
 }
 
+// recvResponse returns the next line received on the connection which is not
+// an asynchronous event, i.e. the response to the request which has just been
+// sent.  Events which are received in the meantime are retained such that
+// they can be awaited.
+func (c *QEMUMachineProtocolClient) recvResponse() ([]byte, error) {
+	for {
+		b, err := c.recv.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		var msg struct {
+			Event *string `json:"event"`
+		}
+		if err := json.Unmarshal(b, &msg); err != nil || msg.Event == nil {
+			return b, nil
+		}
+
+		c.events = append(c.events, b)
+	}
+}
+
+// AwaitEvent blocks until an asynchronous event for which the provided
+// function returns true has been received, or until the timeout has elapsed
+// if the connection supports read deadlines.  Events which have been received
+// whilst awaiting responses are considered first.
+func (c *QEMUMachineProtocolClient) AwaitEvent(timeout time.Duration, match func(event string, data map[string]any) bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	matches := func(b []byte) bool {
+		var msg struct {
+			Event *string        `json:"event"`
+			Data  map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal(b, &msg); err != nil || msg.Event == nil {
+			return false
+		}
+
+		return match(*msg.Event, msg.Data)
+	}
+
+	for i, b := range c.events {
+		if matches(b) {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return nil
+		}
+	}
+
+	if conn, ok := c.conn.(interface{ SetReadDeadline(time.Time) error }); ok {
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+
+		defer conn.SetReadDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	for {
+		b, err := c.recv.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+
+		if matches(b) {
+			return nil
+		}
+	}
+}
+
 func (c *QEMUMachineProtocolClient) setRpcRequestSetDefaults(face any) error {
 	v := reflect.ValueOf(face)
 
@@ -73,7 +144,7 @@ func (c *QEMUMachineProtocolClient) Greeting() (*GreetingResponse, error) {
 	defer c.lock.Unlock()
 
 	var res GreetingResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +178,7 @@ func (c *QEMUMachineProtocolClient) Quit(req QuitRequest) (*QuitResponse, error)
 	}
 
 	var res QuitResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +212,7 @@ func (c *QEMUMachineProtocolClient) Stop(req StopRequest) (*any, error) {
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +246,7 @@ func (c *QEMUMachineProtocolClient) Cont(req ContRequest) (*any, error) {
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +280,7 @@ func (c *QEMUMachineProtocolClient) SystemReset(req SystemResetRequest) (*any, e
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +314,7 @@ func (c *QEMUMachineProtocolClient) SystemPowerdown(req SystemPowerdownRequest)
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +348,7 @@ func (c *QEMUMachineProtocolClient) SystemWakeup(req SystemWakeupRequest) (*any,
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +382,7 @@ func (c *QEMUMachineProtocolClient) Capabilities(req CapabilitiesRequest) (*any,
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -345,7 +416,7 @@ func (c *QEMUMachineProtocolClient) QueryKvm(req QueryKvmRequest) (*QueryKvmResp
 	}
 
 	var res QueryKvmResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -379,7 +450,7 @@ func (c *QEMUMachineProtocolClient) QueryStatus(req QueryStatusRequest) (*QueryS
 	}
 
 	var res QueryStatusResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -413,7 +484,7 @@ func (c *QEMUMachineProtocolClient) SetLink(req SetLinkRequest) (*any, error) {
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -447,7 +518,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddLegacyNic(req NetdevAddLegacyNicReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -481,7 +552,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevUser(req NetdevAddDevUserRequest
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -515,7 +586,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevTap(req NetdevAddDevTapRequest)
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -549,7 +620,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevL2TPv3(req NetdevAddDevL2TPv3Req
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -583,7 +654,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevSocket(req NetdevAddDevSocketReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -617,7 +688,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevStream(req NetdevAddDevStreamReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -651,7 +722,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevDgram(req NetdevAddDevDgramReque
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -685,7 +756,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVde(req NetdevAddDevVdeRequest)
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -719,7 +790,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevBridge(req NetdevAddDevBridgeReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -753,7 +824,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevHubPort(req NetdevAddDevHubPortR
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -787,7 +858,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevNetmap(req NetdevAddDevNetmapReq
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -821,7 +892,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVhostUser(req NetdevAddDevVhostU
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -855,7 +926,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVhostVDPA(req NetdevAddDevVhostV
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -889,7 +960,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetHost(req NetdevAddDevVmnetH
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -923,7 +994,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetShared(req NetdevAddDevVmne
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -957,7 +1028,7 @@ func (c *QEMUMachineProtocolClient) NetdevAddDevVmnetBridged(req NetdevAddDevVmn
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -991,7 +1062,7 @@ func (c *QEMUMachineProtocolClient) NetdevDel(req NetdevDelRequest) (*any, error
 	}
 
 	var res any
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -1025,7 +1096,245 @@ func (c *QEMUMachineProtocolClient) QueryRxFilter(req QueryRxFilterRequest) (*Qu
 	}
 
 	var res QueryRxFilterResponse
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) DeviceAdd(req DeviceAddRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) DeviceDel(req DeviceDelRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) Balloon(req BalloonRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryBalloon(req QueryBalloonRequest) (*QueryBalloonResponse, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res QueryBalloonResponse
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) Migrate(req MigrateRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) QueryMigrate(req QueryMigrateRequest) (*QueryMigrateResponse, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res QueryMigrateResponse
+	b, err = c.recvResponse()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *QEMUMachineProtocolClient) DumpGuestMemory(req DumpGuestMemoryRequest) (*any, error) {
+	var b []byte
+	var err error
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.setRpcRequestSetDefaults(&req); err != nil {
+		return nil, err
+	}
+
+	b, err = json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.send.Write(append(b, '\x0a')); err != nil {
+		return nil, err
+	}
+	if err := c.send.Flush(); err != nil {
+		return nil, err
+	}
+
+	var res any
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qmpv7alpha2
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientInterleavedEvents(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	qmp := NewQEMUMachineProtocolClient(client)
+	defer qmp.Close()
+
+	go func() {
+		r := bufio.NewReader(server)
+
+		for _, lines := range [][]string{
+			// The event of the unplugged device is emitted before the response to
+			// the subsequent request.
+			{`{"return": {}}`},
+			{`{"event": "DEVICE_DELETED", "data": {"device": "nic-hostnet1"}}`, `{"error": {"class": "GenericError", "desc": "failed"}}`},
+		} {
+			if _, err := r.ReadBytes('\n'); err != nil {
+				return
+			}
+
+			for _, line := range lines {
+				if _, err := server.Write([]byte(line + "\n")); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	if _, err := qmp.DeviceDel(DeviceDelRequest{Arguments: DeviceDelRequestArguments{Id: "nic-hostnet1"}}); err != nil {
+		t.Fatalf("DeviceDel() error = %v", err)
+	}
+
+	res, err := qmp.NetdevDel(NetdevDelRequest{Arguments: NetdevDelRequestArguments{Id: "hostnet1"}})
+	if err != nil {
+		t.Fatalf("NetdevDel() error = %v", err)
+	}
+
+	if body, ok := (*res).(map[string]any); !ok || body["error"] == nil {
+		t.Errorf("NetdevDel() = %v, want error response", *res)
+	}
+
+	if err := qmp.AwaitEvent(time.Second, func(event string, data map[string]any) bool {
+		return event == "DEVICE_DELETED" && data["device"] == "nic-hostnet1"
+	}); err != nil {
+		t.Errorf("AwaitEvent() error = %v", err)
+	}
+
+	// No further events are received such that awaiting times out.
+	if err := qmp.AwaitEvent(10*time.Millisecond, func(string, map[string]any) bool {
+		return true
+	}); err == nil {
+		t.Error("AwaitEvent() error = nil, want timeout")
+	}
+}
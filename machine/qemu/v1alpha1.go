@@ -6,6 +6,7 @@ package qemu
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -28,18 +30,30 @@ import (
 	"k8s.io/apimachinery/pkg/util/uuid"
 
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	networkv1alpha1 "kraftkit.sh/api/network/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/exec"
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/retrytimeout"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/cgroup"
+	"kraftkit.sh/machine/health"
 	"kraftkit.sh/machine/network/macaddr"
-	"kraftkit.sh/machine/qemu/qmp"
+	"kraftkit.sh/machine/network/portforward"
 	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
 	"kraftkit.sh/unikraft/export/v0/vfscore"
 )
 
+// consoleCharDevId is the ID of the character device of the serial console of
+// the machine.
+const consoleCharDevId = "console"
+
+// deviceDeletedTimeout is how long the guest is given to release a device
+// which is unplugged.
+const deviceDeletedTimeout = 10 * time.Second
+
 // machineV1alpha1Service ...
 type machineV1alpha1Service struct {
 	eopts []exec.ExecOption
@@ -138,6 +152,9 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	}
 
 	machine.Status.State = machinev1alpha1.MachineStateUnknown
+	machine.Status.StoppedExplicitly = false
+	machine.Status.ExitedAt = time.Time{}
+	machine.Status.ExitReason = ""
 
 	if len(machine.Status.StateDir) == 0 {
 		machine.Status.StateDir = filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, string(machine.ObjectMeta.UID))
@@ -152,6 +169,8 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		machine.Status.LogFile = filepath.Join(machine.Status.StateDir, "machine.log")
 	}
 
+	machine.Status.ConsoleSocket = filepath.Join(machine.Status.StateDir, "console.sock")
+
 	if machine.Spec.Resources.Requests == nil {
 		machine.Spec.Resources.Requests = make(corev1.ResourceList, 2)
 	}
@@ -197,16 +216,25 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			NoWait:    true,
 			Server:    true,
 		}),
-		// Create a QMP connection solely for listening to events
+		// Create a QMP connection solely for listening to events, which are
+		// recorded by the lifecycle recorder of the machine
 		WithQMP(QemuHostCharDevUnix{
 			SocketDir: machine.Status.StateDir,
 			Name:      "qemu_events",
 			NoWait:    true,
 			Server:    true,
 		}),
-		WithSerial(QemuHostCharDevFile{
-			Monitor:  false,
-			Filename: machine.Status.LogFile,
+		// Expose the serial console through a socket such that it can be attached
+		// to whilst its output is also recorded in the log file.
+		WithCharDevice(QemuCharDevSocketUnix{
+			Id:      consoleCharDevId,
+			Path:    machine.Status.ConsoleSocket,
+			Server:  true,
+			NoWait:  true,
+			LogFile: machine.Status.LogFile,
+		}),
+		WithSerial(QemuHostCharDevNamed{
+			Id: consoleCharDevId,
 		}),
 		WithMonitor(QemuHostCharDevUnix{
 			SocketDir: machine.Status.StateDir,
@@ -219,6 +247,9 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			Threads: 1,
 			Sockets: 1,
 		}),
+		// Attach a balloon device such that the memory of the machine can be
+		// resized at runtime.
+		WithDevice(QemuDeviceVirtioBalloonPci{}),
 		WithVGA(QemuVGANone),
 		WithRTC(QemuRTC{
 			Base: QemuRTCBaseUtc,
@@ -227,6 +258,35 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		WithParallel(QemuHostCharDevNone{}),
 	}
 
+	if machine.Spec.Debug != nil {
+		qopts = append(qopts,
+			WithGDB("tcp:"+machine.Spec.Debug.Address()),
+		)
+	}
+
+	// The guest is paused instead of shutdown when it panics such that its
+	// memory can be dumped by the lifecycle recorder through the pvpanic device,
+	// which is only attached to x86 machines.
+	if machine.Spec.CrashDump {
+		if qemuVersion.LessThan(QemuVersion6_0_0) {
+			return machine, fmt.Errorf("crash dumps require QEMU %s or newer", QemuVersion6_0_0.String())
+		}
+
+		switch machine.Spec.Architecture {
+		case "x86_64", "amd64":
+		default:
+			return machine, fmt.Errorf("crash dumps are not supported on %s", machine.Spec.Architecture)
+		}
+
+		if err := os.MkdirAll(filepath.Join(machine.Status.StateDir, machinev1alpha1.CrashDumpDir), 0o775); err != nil {
+			return machine, err
+		}
+
+		qopts = append(qopts,
+			WithAction("panic=pause"),
+		)
+	}
+
 	// TODO: Parse Rootfs types
 	if len(machine.Status.InitrdPath) > 0 {
 		qopts = append(qopts,
@@ -234,7 +294,19 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		)
 	}
 
-	if len(machine.Spec.Ports) > 0 {
+	// Load the state of the machine from its snapshot instead of booting it.
+	// The remaining configuration must be identical to that of the machine the
+	// snapshot was taken from, which is guaranteed by its recorded specification.
+	if len(machine.Status.SnapshotDir) > 0 {
+		qopts = append(qopts,
+			WithIncoming("exec:cat "+shellQuote(filepath.Join(machine.Status.SnapshotDir, SnapshotStateFile))),
+		)
+	}
+
+	// Ports of machines which are attached to a network are published by a
+	// forwarder on the host once the machine has been created, otherwise they
+	// are published via user-mode networking.
+	if len(machine.Spec.Ports) > 0 && len(machine.Spec.Networks) == 0 {
 		// Start MAC addresses iteratively.
 		startMac, err := macaddr.GenerateMacAddress(true)
 		if err != nil {
@@ -246,6 +318,7 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 			if mac == "" {
 				startMac = macaddr.IncrementMacAddress(startMac)
 				mac = startMac.String()
+				machine.Spec.Ports[i].MacAddress = mac
 			}
 
 			hostnetid := fmt.Sprintf("hostnet%d", i)
@@ -279,16 +352,22 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 		i := 0 // host network ID.
 
+		// Each network device is configured statically via command-line
+		// arguments, unless the built-in arguments configure them already.
+		static := !kernelArgs.Contains(uknetdev.ParamIpv4Addr) && !kernelArgs.Contains(uknetdev.ParamIp)
+		var ips, ips6 []uknetdev.IpEntry
+
 		// Iterate over each interface of each network interface associated with
 		// this machine and attach it as a device.
-		for _, network := range machine.Spec.Networks {
-			for _, iface := range network.Interfaces {
+		for j, network := range machine.Spec.Networks {
+			for k, iface := range network.Interfaces {
 				mac := iface.Spec.MacAddress
 				if mac == "" {
 					// Increase the MAC address value by 1 such that we are able to
 					// identify interface IDs.
 					startMac = macaddr.IncrementMacAddress(startMac)
 					mac = startMac.String()
+					machine.Spec.Networks[j].Interfaces[k].Spec.MacAddress = mac
 				}
 
 				hostnetid := fmt.Sprintf("hostnet%d", i)
@@ -311,12 +390,38 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 					}),
 				)
 
-				// Assign the first interface statically via command-line arguments, also
-				// checking if the built-in arguments for
-				if !kernelArgs.Contains(uknetdev.ParamIpv4Addr) && i == 0 {
+				// The nameservers of the network, e.g. its embedded DNS responder,
+				// are passed alongside the address of the same family.
+				var dns, dns6 []string
+				for _, nameserver := range network.Nameservers {
+					if strings.Contains(nameserver, ":") {
+						dns6 = append(dns6, nameserver)
+					} else {
+						dns = append(dns, nameserver)
+					}
+				}
+
+				gateway := iface.Spec.Gateway
+				if gateway == "" {
+					gateway = network.Gateway
+				}
+
+				gateway6 := iface.Spec.Gateway6
+				if gateway6 == "" {
+					gateway6 = network.Gateway6
+				}
+
+				// The IPv4 and IPv6 configurations are passed separately, where the
+				// n-th entry applies to the n-th device.
+				ips = append(ips, uknetdev.NewIpEntry(iface.Spec.IP, network.Netmask, gateway, dns...))
+				ips6 = append(ips6, uknetdev.NewIpEntry(iface.Spec.IP6, network.Netmask6, gateway6, dns6...))
+
+				// Kernels which predate netdev.ip only accept the configuration of
+				// the first interface.
+				if static && i == 0 && iface.Spec.IP != "" {
 					kernelArgs = append(kernelArgs,
 						uknetdev.ParamIpv4Addr.WithValue(iface.Spec.IP),
-						uknetdev.ParamIpv4GwAddr.WithValue(network.Gateway),
+						uknetdev.ParamIpv4GwAddr.WithValue(gateway),
 						uknetdev.ParamIpv4SubnetMask.WithValue(network.Netmask),
 					)
 				}
@@ -325,6 +430,33 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 				i++
 			}
 		}
+
+		if static {
+			for _, param := range []struct {
+				param   ukargparse.Param
+				family  string
+				entries []uknetdev.IpEntry
+			}{
+				{uknetdev.ParamIp, "IPv4", ips},
+				{uknetdev.ParamIp6, "IPv6", ips6},
+			} {
+				if kernelArgs.Contains(param.param) {
+					continue
+				}
+
+				values, skipped := uknetdev.Positional(param.entries)
+				if len(skipped) > 0 {
+					log.G(ctx).
+						WithField("machine", machine.Name).
+						WithField("devices", skipped).
+						Warnf("%s addresses of network devices which follow a device without one are not configured", param.family)
+				}
+
+				if len(values) > 0 {
+					kernelArgs = append(kernelArgs, param.param.WithValue(values))
+				}
+			}
+		}
 	}
 
 	var fstab []string
@@ -455,16 +587,36 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 
 	defer fi.Close()
 
-	service.eopts = append(service.eopts,
+	// The options of the service are shared by every machine it creates, so the
+	// options of this machine are appended to a copy.
+	eopts := append(slices.Clone(service.eopts),
 		exec.WithStdout(fi),
 	)
 
+	// Create the VMM within its own cgroup which constrains the host resources it
+	// consumes.  Without any limits, failing to do so, e.g. when running
+	// unprivileged or on a host without cgroup v2, is not fatal.
+	if path, err := cgroup.Create(machine); err == nil {
+		eopts = append(eopts, exec.WithCgroup(path))
+	} else if len(machine.Spec.Resources.Limits) > 0 {
+		machine.Status.State = machinev1alpha1.MachineStateFailed
+		return machine, fmt.Errorf("could not create cgroup: %w", err)
+	} else {
+		log.G(ctx).Debugf("not creating machine within cgroup: %v", err)
+	}
+
 	qcfg, err := NewQemuConfig(qopts...)
 	if err != nil {
 		machine.Status.State = machinev1alpha1.MachineStateFailed
 		return machine, fmt.Errorf("could not generate QEMU config: %v", err)
 	}
 
+	// A machine which is restored from a snapshot does not print the preamble
+	// of the BIOS since it has already been booted.
+	if len(machine.Status.SnapshotDir) > 0 {
+		qcfg.ShowSGABiosPreamble = true
+	}
+
 	machine.Status.PlatformConfig = *qcfg
 
 	e, err := exec.NewExecutable(bin, *qcfg)
@@ -473,7 +625,7 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("could not prepare QEMU executable: %v", err)
 	}
 
-	process, err := exec.NewProcessFromExecutable(e, service.eopts...)
+	process, err := exec.NewProcessFromExecutable(e, eopts...)
 	if err != nil {
 		machine.Status.State = machinev1alpha1.MachineStateFailed
 		return machine, fmt.Errorf("could not prepare QEMU process: %v", err)
@@ -494,14 +646,285 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		return machine, fmt.Errorf("could not start and wait for QEMU process: %v", err)
 	}
 
+	if err := startLifecycleRecorder(ctx, machine, qcfg); err != nil {
+		machine.Status.State = machinev1alpha1.MachineStateFailed
+		return machine, err
+	}
+
+	if len(machine.Status.SnapshotDir) > 0 {
+		if err := service.waitForIncoming(ctx, machine); err != nil {
+			machine.Status.State = machinev1alpha1.MachineStateFailed
+
+			// Propagate the contents of the QEMU log file as an error
+			if errLog, err2 := os.ReadFile(qemuLogFile); err2 == nil {
+				err = errors.Join(fmt.Errorf(strings.TrimSpace(string(errLog))), err)
+			}
+
+			return machine, fmt.Errorf("could not restore QEMU instance from snapshot: %v", err)
+		}
+	}
+
+	if len(machine.Spec.Ports) > 0 && len(machine.Spec.Networks) > 0 {
+		process, err := processFromPidFile(qcfg.PidFile)
+		if err != nil {
+			machine.Status.State = machinev1alpha1.MachineStateFailed
+			return machine, err
+		}
+
+		machine.Status.Pid = process.Pid
+
+		if err := portforward.Start(ctx, machine); err != nil {
+			machine.Status.State = machinev1alpha1.MachineStateFailed
+
+			// Do not leave behind a VMM whose ports cannot be reached.  QEMU removes
+			// its PID file itself once it has exited, after which its cgroup is
+			// empty and can be removed.
+			if err2 := process.Terminate(); err2 != nil {
+				err = errors.Join(err, fmt.Errorf("could not terminate QEMU process: %w", err2))
+			} else if err2 := retrytimeout.RetryTimeout(5*time.Second, func() error {
+				if _, err := os.Stat(qcfg.PidFile); !os.IsNotExist(err) {
+					return fmt.Errorf("process still active")
+				}
+
+				return nil
+			}); err2 != nil {
+				err = errors.Join(err, err2)
+			} else if err2 := cgroup.Delete(machine); err2 != nil {
+				err = errors.Join(err, err2)
+			}
+
+			return machine, err
+		}
+	}
+
 	machine.Status.State = machinev1alpha1.MachineStateCreated
 
 	return machine, nil
 }
 
 // Update implements kraftkit.sh/api/machine/v1alpha1.MachineService
+//
+// Changes to the specification of the machine are applied to the running QEMU
+// process via QMP.  Network interfaces which are new to the specification are
+// hot-plugged, interfaces which have been removed from it are unplugged and the
+// link of each interface is set according to its status.  Finally, the memory
+// of the machine is resized via its balloon device which, as it can only
+// reclaim memory from the guest, cannot grow beyond the boot-time memory.
 func (service *machineV1alpha1Service) Update(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
-	panic("not implemented: kraftkit.sh/machine/qemu.machineV1alpha1Service.Update")
+	qcfg, ok := machine.Status.PlatformConfig.(QemuConfig)
+	if !ok {
+		return machine, fmt.Errorf("cannot read QEMU platform configuration from machine status")
+	}
+
+	qmpClient, err := service.QMPClient(ctx, machine)
+	if err != nil {
+		return machine, fmt.Errorf("could not update qemu instance: %v", err)
+	}
+
+	defer qmpClient.Close()
+
+	// Record the network backends which have been attached or detached thus far,
+	// even if a subsequent change cannot be applied.
+	defer func() {
+		machine.Status.PlatformConfig = qcfg
+	}()
+
+	balloon := false
+	nics := make(map[string]struct{})
+	for _, device := range qcfg.Devices {
+		switch d := device.(type) {
+		case QemuDeviceVirtioBalloonPci:
+			balloon = true
+		case QemuDeviceVirtioNetPci:
+			nics[d.Netdev] = struct{}{}
+		}
+	}
+
+	// Index the tap network backends of the machine by the name of their host
+	// interface and determine the next free host network ID.
+	attached := make(map[string]QemuNetDevTap)
+	next := 0
+	for _, netdev := range qcfg.NetDevs {
+		var id string
+		switch nd := netdev.(type) {
+		case QemuNetDevTap:
+			if nd.Ifname != "" {
+				attached[nd.Ifname] = nd
+			}
+			id = nd.Id
+		case QemuNetDevUser:
+			id = nd.Id
+		}
+
+		if n, err := strconv.Atoi(strings.TrimPrefix(id, "hostnet")); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	desired := make(map[string]struct{})
+
+	for i, network := range machine.Spec.Networks {
+		for j, iface := range network.Interfaces {
+			// Interfaces without a name cannot be matched against the tap backends
+			// of the machine.
+			if iface.Spec.IfName == "" {
+				continue
+			}
+
+			desired[iface.Spec.IfName] = struct{}{}
+
+			tap, ok := attached[iface.Spec.IfName]
+			if !ok {
+				mac := iface.Spec.MacAddress
+				if mac == "" {
+					hwaddr, err := macaddr.GenerateMacAddress(true)
+					if err != nil {
+						return machine, err
+					}
+
+					mac = hwaddr.String()
+					machine.Spec.Networks[i].Interfaces[j].Spec.MacAddress = mac
+				}
+
+				tap = QemuNetDevTap{
+					Id:         fmt.Sprintf("hostnet%d", next),
+					Ifname:     iface.Spec.IfName,
+					Br:         network.IfName,
+					Script:     "no", // Disable execution
+					Downscript: "no", // Disable execution
+				}
+				next++
+
+				if err := qmpResult(qmpClient.NetdevAddDevTap(qmpapi.NetdevAddDevTapRequest{
+					Arguments: qmpapi.NetdevTapOptions{
+						Id:         tap.Id,
+						Type:       qmpapi.NET_CLIENT_DRIVER_TAP,
+						Ifname:     tap.Ifname,
+						Br:         tap.Br,
+						Script:     tap.Script,
+						Downscript: tap.Downscript,
+					},
+				})); err != nil {
+					return machine, fmt.Errorf("could not add network backend for interface %s: %w", tap.Ifname, err)
+				}
+
+				qcfg.NetDevs = append(qcfg.NetDevs, tap)
+
+				// Hot-plugged devices are not recorded alongside the devices which were
+				// attached at boot and are identified by the ID of their backend.
+				if err := qmpResult(qmpClient.DeviceAdd(qmpapi.DeviceAddRequest{
+					Arguments: qmpapi.DeviceAddRequestArguments{
+						Driver: "virtio-net-pci",
+						Id:     "nic-" + tap.Id,
+						Netdev: tap.Id,
+						Mac:    mac,
+					},
+				})); err != nil {
+					return machine, fmt.Errorf("could not add network device for interface %s: %w", tap.Ifname, err)
+				}
+			}
+
+			switch iface.Status.State {
+			case networkv1alpha1.NetworkInterfaceStateConnected,
+				networkv1alpha1.NetworkInterfaceStateDisconnected:
+				if err := qmpResult(qmpClient.SetLink(qmpapi.SetLinkRequest{
+					Arguments: qmpapi.SetLinkRequestArguments{
+						Name: tap.Id,
+						Up:   iface.Status.State == networkv1alpha1.NetworkInterfaceStateConnected,
+					},
+				})); err != nil {
+					return machine, fmt.Errorf("could not set link of interface %s: %w", tap.Ifname, err)
+				}
+			}
+		}
+	}
+
+	netdevs := make([]QemuNetDev, 0, len(qcfg.NetDevs))
+	for i, netdev := range qcfg.NetDevs {
+		tap, ok := netdev.(QemuNetDevTap)
+		if _, keep := desired[tap.Ifname]; !ok || tap.Ifname == "" || keep {
+			netdevs = append(netdevs, netdev)
+			continue
+		}
+
+		// Hot-plugged devices are unplugged before their backend is removed, which
+		// QEMU only completes once the guest has released the device.  Devices
+		// which were attached at boot have no ID and remain in place, such that
+		// removing their backend merely brings their link down.
+		if _, ok := nics[tap.Id]; !ok {
+			device := "nic-" + tap.Id
+
+			if err := qmpResult(qmpClient.DeviceDel(qmpapi.DeviceDelRequest{
+				Arguments: qmpapi.DeviceDelRequestArguments{
+					Id: device,
+				},
+			})); err != nil {
+				qcfg.NetDevs = append(netdevs, qcfg.NetDevs[i:]...)
+				return machine, fmt.Errorf("could not remove network device for interface %s: %w", tap.Ifname, err)
+			}
+
+			if err := qmpClient.AwaitEvent(deviceDeletedTimeout, func(event string, data map[string]any) bool {
+				return event == "DEVICE_DELETED" && data["device"] == device
+			}); err != nil {
+				qcfg.NetDevs = append(netdevs, qcfg.NetDevs[i:]...)
+				return machine, fmt.Errorf("could not await removal of network device for interface %s: %w", tap.Ifname, err)
+			}
+		}
+
+		if err := qmpResult(qmpClient.NetdevDel(qmpapi.NetdevDelRequest{
+			Arguments: qmpapi.NetdevDelRequestArguments{
+				Id: tap.Id,
+			},
+		})); err != nil {
+			qcfg.NetDevs = append(netdevs, qcfg.NetDevs[i:]...)
+			return machine, fmt.Errorf("could not remove network backend for interface %s: %w", tap.Ifname, err)
+		}
+	}
+
+	qcfg.NetDevs = netdevs
+
+	if memory := machine.Spec.Resources.Requests.Memory().Value(); memory > 0 {
+		boot := int64(qcfg.Memory.Size) * QemuMemoryScale
+		if qcfg.Memory.Unit == QemuMemoryUnitGB {
+			boot *= 1024
+		}
+
+		if memory > boot {
+			return machine, fmt.Errorf("cannot grow memory beyond the boot-time memory of %d bytes", boot)
+		}
+
+		// Machines which were created without a balloon device cannot be resized.
+		if !balloon {
+			if memory != boot {
+				return machine, fmt.Errorf("cannot resize memory without a balloon device")
+			}
+
+			return machine, nil
+		}
+
+		info, err := qmpClient.QueryBalloon(qmpapi.QueryBalloonRequest{})
+		if err != nil {
+			return machine, fmt.Errorf("could not query balloon device: %w", err)
+		}
+
+		if info.Return.Actual != memory {
+			if err := qmpResult(qmpClient.Balloon(qmpapi.BalloonRequest{
+				Arguments: qmpapi.BalloonRequestArguments{
+					Value: memory,
+				},
+			})); err != nil {
+				return machine, fmt.Errorf("could not resize memory: %w", err)
+			}
+		}
+	}
+
+	return machine, nil
+}
+
+// DecodePlatformConfig converts the platform configuration of a QEMU machine,
+// as it is retrieved from the store or decoded from JSON, into QemuConfig.
+func DecodePlatformConfig(platformConfig interface{}) (*QemuConfig, error) {
+	return getQEMUConfigFromPlatformConfig(platformConfig)
 }
 
 // getQEMUConfigFromPlatformConfig converts the provided platformConfig
@@ -512,6 +935,10 @@ func getQEMUConfigFromPlatformConfig(platformConfig interface{}) (*QemuConfig, e
 		return qcfgptr, nil
 	}
 
+	if qcfg, ok := platformConfig.(QemuConfig); ok {
+		return &qcfg, nil
+	}
+
 	// If we cannot directly cast it to the structure, attempt to decode a
 	// mapstructure version of the same configuration.
 	var qcfg QemuConfig
@@ -566,6 +993,28 @@ func qmpClientHandshake(conn *net.Conn) (*qmpapi.QEMUMachineProtocolClient, erro
 	return qmpClient, nil
 }
 
+// qmpResult returns the error which is embedded in the provided QMP response,
+// if any, or the error which occurred whilst performing the request.
+func qmpResult(res *any, err error) error {
+	if err != nil {
+		return err
+	} else if res == nil {
+		return nil
+	}
+
+	body, ok := (*res).(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	qmpErr, ok := body["error"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	return fmt.Errorf("%v: %v", qmpErr["class"], qmpErr["desc"])
+}
+
 func (service *machineV1alpha1Service) QMPClient(ctx context.Context, machine *machinev1alpha1.Machine) (*qmpapi.QEMUMachineProtocolClient, error) {
 	qcfg, err := getQEMUConfigFromPlatformConfig(machine.Status.PlatformConfig)
 	if err != nil {
@@ -601,6 +1050,11 @@ func processFromPidFile(pidFile string) (*goprocess.Process, error) {
 }
 
 // Watch implements kraftkit.sh/api/machine/v1alpha1.MachineService
+//
+// The QMP events which relate to the lifecycle of the machine are received
+// from its lifecycle recorder, such that any number of watchers can co-exist,
+// and translated into its state.  Since QEMU may exit without emitting any
+// event, e.g. when it is killed, its process is additionally polled.
 func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machinev1alpha1.Machine) (chan *machinev1alpha1.Machine, chan error, error) {
 	events := make(chan *machinev1alpha1.Machine)
 	errs := make(chan error)
@@ -610,90 +1064,138 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 		return nil, nil, fmt.Errorf("cannot cast QEMU platform configuration from machine status")
 	}
 
-	// Always use index 1 for monitoring events
-	conn, err := qcfg.QMP[1].Connection()
+	// Only the events which are recorded after the machine is watched are
+	// followed, since the earlier events are reflected by its current state.
+	// The lifecycle file is therefore opened before its current state is read.
+	lines, tailErrs, err := logtail.NewLogTailFromEnd(ctx, filepath.Join(machine.Status.StateDir, lifecycleFile))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("could not follow lifecycle events: %w", err)
 	}
 
-	// Perform the handshake
-	_, err = qmpClientHandshake(&conn)
-	if err != nil {
-		return nil, nil, err
-	}
+	// The machine is updated as events are received, such that it must not be
+	// shared with the caller, nor with the watcher once it has been sent.
+	machine = machine.DeepCopyObject().(*machinev1alpha1.Machine)
 
-	monitor, err := qmp.NewQMPEventMonitor(conn,
-		qmpapi.EventTypes(),
-		nil,
-	)
-	if err != nil {
-		return nil, nil, err
+	// send and fail report to the watcher unless it has stopped watching, in
+	// which case they return false.  Each sent machine is a copy of its current
+	// state.
+	send := func(machine *machinev1alpha1.Machine) bool {
+		select {
+		case events <- machine.DeepCopyObject().(*machinev1alpha1.Machine):
+			return true
+		case <-ctx.Done():
+			return false
+		}
 	}
 
-	// firstCall is used to initialize the channel with the current state of the
-	// machine, so that it can be immediately acted upon.
-	firstCall := true
+	fail := func(err error) bool {
+		select {
+		case errs <- err:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 
 	go func() {
-	accept:
+		// Initialize the channel with the current state of the machine, so that
+		// it can be immediately acted upon.
+		machine, err := service.Get(ctx, machine)
+		if err != nil {
+			if !fail(err) {
+				return
+			}
+		} else if !send(machine) {
+			return
+		}
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
 		for {
-			// First check if the context has been cancelled
 			select {
 			case <-ctx.Done():
-				break accept
-			default:
-			}
+				return
 
-			// Check the current state
-			machine, err := service.Get(ctx, machine)
-			if err != nil {
-				errs <- err
-				continue
-			}
+			case err := <-tailErrs:
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil && !fail(err) {
+					return
+				}
 
-			// Initialize with the current state
-			if firstCall {
-				events <- machine
-				firstCall = false
-			}
+			case <-ticker.C:
+				if process, err := processFromPidFile(qcfg.PidFile); err == nil {
+					if running, err := process.IsRunning(); err == nil && running {
+						continue
+					}
+				}
 
-			// Listen for changes in state
-			event, err := monitor.Accept()
-			if err != nil {
-				errs <- err
-				continue
-			}
+				machine, err := service.Get(ctx, machine)
+				if err != nil {
+					if !fail(err) {
+						return
+					}
+
+					continue
+				}
+
+				send(machine)
+				return
+
+			case line := <-lines:
+				var event lifecycleEvent
+				if err := json.Unmarshal([]byte(line), &event); err != nil {
+					if !fail(fmt.Errorf("could not parse lifecycle event: %w", err)) {
+						return
+					}
+
+					continue
+				}
 
-			// Send the event through the channel
-			switch event.Event {
-			case qmpapi.EVENT_STOP, qmpapi.EVENT_SUSPEND, qmpapi.EVENT_POWERDOWN:
-				machine.Status.State = machinev1alpha1.MachineStatePaused
-				events <- machine
+				switch event.Event {
+				case qmpapi.EVENT_STOP, qmpapi.EVENT_SUSPEND, qmpapi.EVENT_POWERDOWN:
+					machine.Status.State = machinev1alpha1.MachineStatePaused
 
-			case qmpapi.EVENT_RESUME:
-				machine.Status.State = machinev1alpha1.MachineStateRunning
-				events <- machine
+				case qmpapi.EVENT_RESUME:
+					machine.Status.State = machinev1alpha1.MachineStateRunning
+					machine.Status.ExitReason = ""
 
-			case qmpapi.EVENT_RESET, qmpapi.EVENT_WAKEUP:
-				machine.Status.State = machinev1alpha1.MachineStateRestarting
-				events <- machine
+				case qmpapi.EVENT_RESET, qmpapi.EVENT_WAKEUP:
+					machine.Status.State = machinev1alpha1.MachineStateRestarting
 
-			case qmpapi.EVENT_SHUTDOWN:
-				machine.Status.State = machinev1alpha1.MachineStateExited
-				events <- machine
+				// The watchdog of the guest only affects the state of the machine when
+				// it pauses it, otherwise it results in a subsequent RESET or
+				// SHUTDOWN.
+				case qmpapi.EVENT_WATCHDOG:
+					if event.Action != "pause" {
+						continue
+					}
 
-				if !qcfg.NoShutdown {
-					break accept
+					machine.Status.State = machinev1alpha1.MachineStatePaused
+
+				case qmpapi.EVENT_SHUTDOWN:
+					machine.Status.State = machinev1alpha1.MachineStateExited
+					machine.Status.ExitReason = readExitReason(machine)
+
+				case qmpapi.EVENT_GUEST_PANICKED:
+					machine.Status.State = machinev1alpha1.MachineStateErrored
+					machine.Status.ExitReason = machinev1alpha1.MachineExitReasonPanic
+
+				default:
+					continue
+				}
+
+				if !send(machine) {
+					return
 				}
-			case qmpapi.EVENT_GUEST_PANICKED:
-				machine.Status.State = machinev1alpha1.MachineStateErrored
-				events <- machine
 
-				if !qcfg.NoShutdown {
-					break accept
+				// QEMU exits after the guest has shut down or panicked, unless it is
+				// instructed otherwise.
+				switch event.Event {
+				case qmpapi.EVENT_SHUTDOWN, qmpapi.EVENT_GUEST_PANICKED:
+					if !qcfg.NoShutdown {
+						return
+					}
 				}
-			default:
-				errs <- fmt.Errorf("unsupported event: %s", event.Event)
 			}
 		}
 	}()
@@ -709,9 +1211,16 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 	}
 
 	defer qmpClient.Close()
-	_, err = qmpClient.Cont(qmpapi.ContRequest{})
-	if err != nil {
-		return machine, err
+
+	// A machine which waits for its debugger is continued by the debugger
+	// instead, until then it remains paused.
+	waitForDebugger := machine.Spec.Debug != nil && machine.Spec.Debug.Wait
+
+	if !waitForDebugger {
+		_, err = qmpClient.Cont(qmpapi.ContRequest{})
+		if err != nil {
+			return machine, err
+		}
 	}
 
 	qcfg, ok := machine.Status.PlatformConfig.(QemuConfig)
@@ -728,6 +1237,14 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 	machine.Status.State = machinev1alpha1.MachineStateRunning
 	machine.Status.StartedAt = time.Now()
 
+	if waitForDebugger {
+		machine.Status.State = machinev1alpha1.MachineStatePaused
+	}
+
+	if err := health.Start(ctx, machine); err != nil {
+		return machine, err
+	}
+
 	return machine, nil
 }
 
@@ -808,6 +1325,7 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 
 	exitedAt := machine.Status.ExitedAt
 	exitCode := machine.Status.ExitCode
+	exitReason := machine.Status.ExitReason
 
 	defer func() {
 		if exitCode >= 0 && machine.Status.ExitedAt.IsZero() {
@@ -821,6 +1339,8 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 			machine.Status.ExitCode = exitCode
 		}
 
+		machine.Status.ExitReason = exitReason
+
 		// Set the start time to now if it was not previously set
 		if machine.Status.StartedAt.IsZero() && state == machinev1alpha1.MachineStateRunning {
 			machine.Status.StartedAt = time.Now()
@@ -831,12 +1351,32 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 		if state != savedState {
 			machine.Status.State = state
 		}
+
+		// Report the result of the most recent health check whilst the machine
+		// is running.
+		machine.Status.Health = nil
+		if machine.Status.State == machinev1alpha1.MachineStateRunning {
+			machine.Status.Health = health.Read(machine)
+		}
 	}()
 
 	if !activeProcess {
 		state = machinev1alpha1.MachineStateExited
+
+		// A machine which was alive but whose exit has not been recorded has been
+		// killed before QEMU could emit any event.
+		if exitReason == "" {
+			exitReason = readExitReason(machine)
+		}
+		if exitReason == "" && (savedState == machinev1alpha1.MachineStateRunning || savedState == machinev1alpha1.MachineStatePaused) {
+			exitReason = machinev1alpha1.MachineExitReasonKilled
+		}
+
 		if savedState == machinev1alpha1.MachineStateRunning {
 			exitCode = 1
+			if exitReason == machinev1alpha1.MachineExitReasonShutdown {
+				exitCode = 0
+			}
 		}
 		return machine, nil
 	}
@@ -866,22 +1406,40 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 	case qmpapi.RUN_STATE_GUEST_PANICKED:
 		state = machinev1alpha1.MachineStateErrored
 		exitCode = 1
+		exitReason = machinev1alpha1.MachineExitReasonPanic
 
 	case qmpapi.RUN_STATE_INTERNAL_ERROR, qmpapi.RUN_STATE_IO_ERROR:
 		state = machinev1alpha1.MachineStateFailed
 		exitCode = 1
 
-	case qmpapi.RUN_STATE_PAUSED:
+	case qmpapi.RUN_STATE_PAUSED, qmpapi.RUN_STATE_POSTMIGRATE:
 		state = machinev1alpha1.MachineStatePaused
 		exitCode = -1
 
+	// The machine is halted by its debugger, e.g. at a breakpoint.
+	case qmpapi.RUN_STATE_DEBUG:
+		state = machinev1alpha1.MachineStatePaused
+		exitCode = -1
+
+	// The machine is waiting for its debugger, either before or after it has
+	// been started.
+	case qmpapi.RUN_STATE_PRELAUNCH:
+		state = machinev1alpha1.MachineStateUnknown
+		if machine.Spec.Debug != nil && machine.Spec.Debug.Wait {
+			state = machinev1alpha1.MachineStatePaused
+		}
+
+		exitCode = -1
+
 	case qmpapi.RUN_STATE_RUNNING:
 		state = machinev1alpha1.MachineStateRunning
 		exitCode = -1
+		exitReason = ""
 
 	case qmpapi.RUN_STATE_SHUTDOWN:
 		state = machinev1alpha1.MachineStateExited
 		exitCode = 0
+		exitReason = readExitReason(machine)
 
 	case qmpapi.RUN_STATE_SUSPENDED:
 		state = machinev1alpha1.MachineStateSuspended
@@ -889,7 +1447,6 @@ func (service *machineV1alpha1Service) Get(ctx context.Context, machine *machine
 
 	default:
 		// qmpapi.RUN_STATE_SAVE_VM,
-		// qmpapi.RUN_STATE_PRELAUNCH,
 		// qmpapi.RUN_STATE_RESTORE_VM,
 		// qmpapi.RUN_STATE_WATCHDOG,
 		state = machinev1alpha1.MachineStateUnknown
@@ -937,6 +1494,8 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 	}
 
 	machine.Status.State = machinev1alpha1.MachineStateExited
+	machine.Status.ExitReason = machinev1alpha1.MachineExitReasonStopped
+	machine.Status.StoppedExplicitly = true
 
 	if err := retrytimeout.RetryTimeout(5*time.Second, func() error {
 		if _, err := os.ReadFile(qcfg.PidFile); !os.IsNotExist(err) {
@@ -948,6 +1507,14 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		return machine, err
 	}
 
+	if err := portforward.Stop(machine); err != nil {
+		return machine, err
+	}
+
+	if err := health.Stop(machine); err != nil {
+		return machine, err
+	}
+
 	return machine, nil
 }
 
@@ -960,6 +1527,18 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 
 	var errs merr.Errors
 
+	if err := portforward.Stop(machine); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := health.Stop(machine); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := cgroup.Delete(machine); err != nil {
+		errs = append(errs, err)
+	}
+
 	err := os.RemoveAll(machine.Status.StateDir)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("error deleting QEMU's state directory %s: %w", machine.Status.StateDir, err))
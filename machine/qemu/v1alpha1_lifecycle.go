@@ -0,0 +1,351 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/machine/qemu/qmp"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+const (
+	// lifecycleFile is the name of the file within the state directory of the
+	// machine which contains the lifecycle events emitted by QEMU, one JSON
+	// object per line.
+	lifecycleFile = "lifecycle.jsonl"
+
+	// lifecycleLogFile is the name of the file within the state directory of the
+	// machine which contains the output of its lifecycle recorder.
+	lifecycleLogFile = "lifecycle.log"
+
+	// lifecycleReexecName is the name under which the lifecycle recorder is
+	// registered such that the running binary can be re-executed as it.
+	lifecycleReexecName = "kraftkit-qemu-lifecycle"
+)
+
+func init() {
+	reexec.Register(lifecycleReexecName, recordLifecycle)
+}
+
+// lifecycleEventData is the data of the QMP events which relate to the
+// lifecycle of the machine.
+type lifecycleEventData struct {
+	// Guest indicates whether a SHUTDOWN or RESET was requested by the guest.
+	Guest bool `json:"guest,omitempty"`
+
+	// Reason is the cause of a SHUTDOWN or RESET.
+	Reason qmpapi.ShutdownCause `json:"reason,omitempty"`
+
+	// Action is the action taken by QEMU upon a WATCHDOG or GUEST_PANICKED.
+	Action string `json:"action,omitempty"`
+}
+
+// dumpCompletedEventData is the data of the DUMP_COMPLETED QMP event.
+type dumpCompletedEventData struct {
+	// Error is the reason the dump has failed, if it has.
+	Error string `json:"error,omitempty"`
+}
+
+// lifecycleEventTypes maps the QMP events which relate to the lifecycle of the
+// machine to the type of their data.  Events without data are mapped to nil.
+var lifecycleEventTypes = map[qmpapi.EventType]reflect.Type{
+	qmpapi.EVENT_STOP:           nil,
+	qmpapi.EVENT_RESUME:         nil,
+	qmpapi.EVENT_SUSPEND:        nil,
+	qmpapi.EVENT_WAKEUP:         nil,
+	qmpapi.EVENT_POWERDOWN:      nil,
+	qmpapi.EVENT_RESET:          reflect.TypeOf(lifecycleEventData{}),
+	qmpapi.EVENT_SHUTDOWN:       reflect.TypeOf(lifecycleEventData{}),
+	qmpapi.EVENT_WATCHDOG:       reflect.TypeOf(lifecycleEventData{}),
+	qmpapi.EVENT_GUEST_PANICKED: reflect.TypeOf(lifecycleEventData{}),
+}
+
+// lifecycleEvent is a QMP event which relates to the lifecycle of the machine
+// as it is recorded in its lifecycle file.
+type lifecycleEvent struct {
+	lifecycleEventData
+
+	// Event is the type of the QMP event.
+	Event qmpapi.EventType `json:"event"`
+
+	// Timestamp represents when QEMU emitted the event.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// lifecycleRecorder is the configuration of the lifecycle recorder process.
+type lifecycleRecorder struct {
+	// EventsSocket is the path to the QMP socket which events are received from.
+	EventsSocket string `json:"eventsSocket"`
+
+	// ControlSocket is the path to the QMP socket through which commands are
+	// sent.
+	ControlSocket string `json:"controlSocket"`
+
+	// LifecycleFile is the path to the file which events are appended to.
+	LifecycleFile string `json:"lifecycleFile"`
+
+	// CrashDumpDir is the path to the directory which the memory of the guest is
+	// dumped into when it panics, if any.
+	CrashDumpDir string `json:"crashDumpDir,omitempty"`
+}
+
+// recordLifecycle is the entrypoint of the lifecycle recorder process.  It is
+// invoked with its JSON-encoded configuration.  Since QMP events are not queued
+// by QEMU, recording them alongside the machine is the only way to later
+// determine why it has exited.  The recorder exits together with QEMU.
+func recordLifecycle() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s CONFIG\n", lifecycleReexecName)
+		os.Exit(1)
+	}
+
+	var r lifecycleRecorder
+	if err := json.Unmarshal([]byte(os.Args[1]), &r); err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := r.run(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// run records the lifecycle events of the machine until QEMU exits.  When the
+// guest panics and crash dumps are enabled, its memory is dumped before the
+// panic is recorded, such that the dump is complete once the machine is
+// observed to have errored.
+func (r *lifecycleRecorder) run() error {
+	conn, err := net.Dial("unix", r.EventsSocket)
+	if err != nil {
+		return fmt.Errorf("could not connect to QMP socket: %w", err)
+	}
+
+	if _, err := qmpClientHandshake(&conn); err != nil {
+		return fmt.Errorf("could not perform QMP handshake: %w", err)
+	}
+
+	dataTypes := map[qmpapi.EventType]reflect.Type{
+		qmpapi.EVENT_DUMP_COMPLETED: reflect.TypeOf(dumpCompletedEventData{}),
+	}
+	for event, typ := range lifecycleEventTypes {
+		dataTypes[event] = typ
+	}
+
+	monitor, err := qmp.NewQMPEventMonitor(conn,
+		qmpapi.EventTypes(),
+		dataTypes,
+	)
+	if err != nil {
+		return fmt.Errorf("could not monitor QMP events: %w", err)
+	}
+
+	f, err := os.OpenFile(r.LifecycleFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open lifecycle file: %w", err)
+	}
+
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+
+	// The panic which is recorded once its crash dump has completed.
+	var panicked *lifecycleEvent
+
+	for {
+		event, err := monitor.Accept()
+		if errors.Is(err, qmp.ErrAcceptedNonEvent) {
+			continue
+		} else if err != nil {
+			// The socket is closed once QEMU has exited.
+			var opErr *net.OpError
+			if errors.Is(err, io.EOF) || errors.As(err, &opErr) {
+				if panicked != nil {
+					return encoder.Encode(panicked)
+				}
+
+				return nil
+			}
+
+			fmt.Fprintf(os.Stderr, "could not accept QMP event: %v\n", err)
+			continue
+		}
+
+		if event.Event == qmpapi.EVENT_DUMP_COMPLETED {
+			if data, ok := event.Data.(dumpCompletedEventData); ok && data.Error != "" {
+				fmt.Fprintf(os.Stderr, "could not dump guest memory: %s\n", data.Error)
+			}
+
+			if panicked != nil {
+				if err := encoder.Encode(panicked); err != nil {
+					fmt.Fprintf(os.Stderr, "could not record QMP event: %v\n", err)
+				}
+
+				panicked = nil
+			}
+
+			continue
+		}
+
+		if _, ok := lifecycleEventTypes[event.Event]; !ok {
+			continue
+		}
+
+		record := lifecycleEvent{
+			Event:     event.Event,
+			Timestamp: event.Timestamp,
+		}
+
+		if data, ok := event.Data.(lifecycleEventData); ok {
+			record.lifecycleEventData = data
+		}
+
+		// The memory of the guest can only be dumped whilst it is retained.
+		if record.Event == qmpapi.EVENT_GUEST_PANICKED && record.Action == "pause" && r.CrashDumpDir != "" && panicked == nil {
+			if err := r.dump(record.Timestamp); err != nil {
+				fmt.Fprintf(os.Stderr, "could not dump guest memory: %v\n", err)
+			} else {
+				panicked = &record
+				continue
+			}
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			fmt.Fprintf(os.Stderr, "could not record QMP event: %v\n", err)
+		}
+	}
+}
+
+// dump requests QEMU to dump the memory of the guest as an ELF core into the
+// crash dump directory.  The dump is performed in the background and its
+// completion is signalled by the DUMP_COMPLETED event.
+func (r *lifecycleRecorder) dump(panickedAt time.Time) error {
+	conn, err := net.Dial("unix", r.ControlSocket)
+	if err != nil {
+		return fmt.Errorf("could not connect to QMP socket: %w", err)
+	}
+
+	client, err := qmpClientHandshake(&conn)
+	if err != nil {
+		return fmt.Errorf("could not perform QMP handshake: %w", err)
+	}
+
+	defer client.Close()
+
+	core := filepath.Join(r.CrashDumpDir, panickedAt.UTC().Format("20060102150405")+machinev1alpha1.CrashDumpExt)
+
+	return qmpResult(client.DumpGuestMemory(qmpapi.DumpGuestMemoryRequest{
+		Arguments: qmpapi.DumpGuestMemoryRequestArguments{
+			Paging:   false,
+			Protocol: "file:" + core,
+			Detach:   true,
+			Format:   qmpapi.DUMP_GUEST_MEMORY_FORMAT_ELF,
+		},
+	}))
+}
+
+// readLifecycle returns the lifecycle events of the machine which have been
+// recorded since it was created.
+func readLifecycle(machine *machinev1alpha1.Machine) ([]lifecycleEvent, error) {
+	f, err := os.Open(filepath.Join(machine.Status.StateDir, lifecycleFile))
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var events []lifecycleEvent
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event lifecycleEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return events, fmt.Errorf("could not parse lifecycle event: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
+
+// readExitReason returns why the machine has exited according to its recorded
+// lifecycle events.  An empty reason is returned if it is not known.
+func readExitReason(machine *machinev1alpha1.Machine) machinev1alpha1.MachineExitReason {
+	events, _ := readLifecycle(machine)
+
+	return exitReason(events)
+}
+
+// exitReason determines why a machine has exited from its lifecycle events.
+// An empty reason is returned if the events do not indicate that the machine
+// has exited, e.g. because QEMU has been killed before it could emit them.
+func exitReason(events []lifecycleEvent) machinev1alpha1.MachineExitReason {
+	var reason machinev1alpha1.MachineExitReason
+	watchdog := false
+
+	for _, event := range events {
+		switch event.Event {
+		case qmpapi.EVENT_RESUME:
+			// The machine has been continued after it has previously been halted,
+			// e.g. if it is retained after it has shutdown.
+			reason = ""
+			watchdog = false
+
+		case qmpapi.EVENT_WATCHDOG:
+			switch event.Action {
+			case "reset", "shutdown", "poweroff":
+				watchdog = true
+			}
+
+		case qmpapi.EVENT_GUEST_PANICKED:
+			reason = machinev1alpha1.MachineExitReasonPanic
+
+		case qmpapi.EVENT_SHUTDOWN:
+			// The shutdown which results from a panic or an expired watchdog is
+			// attributed to the former.
+			if reason == machinev1alpha1.MachineExitReasonPanic {
+				break
+			} else if watchdog {
+				reason = machinev1alpha1.MachineExitReasonWatchdog
+				break
+			}
+
+			switch event.Reason {
+			case qmpapi.SHUTDOWN_GUEST_SHUTDOWN:
+				reason = machinev1alpha1.MachineExitReasonShutdown
+			case qmpapi.SHUTDOWN_GUEST_RESET,
+				qmpapi.SHUTDOWN_HOST_QMP_SYSTEM_RESET,
+				qmpapi.SHUTDOWN_SUBSYSTEM_RESET:
+				reason = machinev1alpha1.MachineExitReasonReset
+			case qmpapi.SHUTDOWN_GUEST_PANIC:
+				reason = machinev1alpha1.MachineExitReasonPanic
+			case qmpapi.SHUTDOWN_HOST_QMP_QUIT, qmpapi.SHUTDOWN_HOST_UI:
+				reason = machinev1alpha1.MachineExitReasonStopped
+			case qmpapi.SHUTDOWN_HOST_SIGNAL:
+				reason = machinev1alpha1.MachineExitReasonKilled
+			case qmpapi.SHUTDOWN_HOST_ERROR:
+				reason = machinev1alpha1.MachineExitReasonError
+			}
+		}
+	}
+
+	return reason
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"testing"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+func TestExitReason(t *testing.T) {
+	shutdown := func(reason qmpapi.ShutdownCause) lifecycleEvent {
+		return lifecycleEvent{
+			Event:              qmpapi.EVENT_SHUTDOWN,
+			lifecycleEventData: lifecycleEventData{Reason: reason},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		events []lifecycleEvent
+		want   machinev1alpha1.MachineExitReason
+	}{
+		{
+			name: "no events",
+			want: "",
+		},
+		{
+			name:   "running",
+			events: []lifecycleEvent{{Event: qmpapi.EVENT_RESUME}},
+			want:   "",
+		},
+		{
+			name:   "guest shutdown",
+			events: []lifecycleEvent{{Event: qmpapi.EVENT_RESUME}, shutdown(qmpapi.SHUTDOWN_GUEST_SHUTDOWN)},
+			want:   machinev1alpha1.MachineExitReasonShutdown,
+		},
+		{
+			name:   "guest reset",
+			events: []lifecycleEvent{shutdown(qmpapi.SHUTDOWN_GUEST_RESET)},
+			want:   machinev1alpha1.MachineExitReasonReset,
+		},
+		{
+			name:   "quit",
+			events: []lifecycleEvent{shutdown(qmpapi.SHUTDOWN_HOST_QMP_QUIT)},
+			want:   machinev1alpha1.MachineExitReasonStopped,
+		},
+		{
+			name:   "signal",
+			events: []lifecycleEvent{shutdown(qmpapi.SHUTDOWN_HOST_SIGNAL)},
+			want:   machinev1alpha1.MachineExitReasonKilled,
+		},
+		{
+			name: "panic",
+			events: []lifecycleEvent{
+				{Event: qmpapi.EVENT_GUEST_PANICKED, lifecycleEventData: lifecycleEventData{Action: "pause"}},
+				shutdown(qmpapi.SHUTDOWN_HOST_QMP_QUIT),
+			},
+			want: machinev1alpha1.MachineExitReasonPanic,
+		},
+		{
+			name: "watchdog",
+			events: []lifecycleEvent{
+				{Event: qmpapi.EVENT_WATCHDOG, lifecycleEventData: lifecycleEventData{Action: "reset"}},
+				{Event: qmpapi.EVENT_RESET},
+				shutdown(qmpapi.SHUTDOWN_GUEST_RESET),
+			},
+			want: machinev1alpha1.MachineExitReasonWatchdog,
+		},
+		{
+			name: "watchdog without effect",
+			events: []lifecycleEvent{
+				{Event: qmpapi.EVENT_WATCHDOG, lifecycleEventData: lifecycleEventData{Action: "none"}},
+				shutdown(qmpapi.SHUTDOWN_GUEST_SHUTDOWN),
+			},
+			want: machinev1alpha1.MachineExitReasonShutdown,
+		},
+		{
+			name: "resumed after shutdown",
+			events: []lifecycleEvent{
+				shutdown(qmpapi.SHUTDOWN_GUEST_SHUTDOWN),
+				{Event: qmpapi.EVENT_RESUME},
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitReason(tt.events); got != tt.want {
+				t.Errorf("exitReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
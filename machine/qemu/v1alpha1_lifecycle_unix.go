@@ -0,0 +1,79 @@
+//go:build !windows
+// +build !windows
+
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/log"
+)
+
+// startLifecycleRecorder spawns a detached lifecycle recorder for the machine
+// which receives events from the QMP sockets of the provided configuration.
+// The events of a previous instance of the machine are discarded.
+func startLifecycleRecorder(ctx context.Context, machine *machinev1alpha1.Machine, qcfg *QemuConfig) error {
+	r := lifecycleRecorder{
+		EventsSocket:  qcfg.QMP[1].Resource(),
+		ControlSocket: qcfg.QMP[0].Resource(),
+		LifecycleFile: filepath.Join(machine.Status.StateDir, lifecycleFile),
+	}
+
+	if machine.Spec.CrashDump {
+		r.CrashDumpDir = filepath.Join(machine.Status.StateDir, machinev1alpha1.CrashDumpDir)
+	}
+
+	config, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(r.LifecycleFile, nil, 0o644); err != nil {
+		return err
+	}
+
+	logFile, err := os.Create(filepath.Join(machine.Status.StateDir, lifecycleLogFile))
+	if err != nil {
+		return err
+	}
+
+	defer logFile.Close()
+
+	cmd := &exec.Cmd{
+		Path: reexec.Self(),
+		Args: []string{
+			lifecycleReexecName,
+			string(config),
+		},
+		Stdout: logFile,
+		Stderr: logFile,
+		// the Setpgid flag is used to prevent the recorder from exiting when the
+		// parent is killed
+		SysProcAttr: &syscall.SysProcAttr{
+			Setpgid: true,
+		},
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start lifecycle recorder: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("pid", cmd.Process.Pid).
+		Debug("started lifecycle recorder")
+
+	return cmd.Process.Release()
+}
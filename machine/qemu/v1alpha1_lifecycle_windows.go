@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+)
+
+// startLifecycleRecorder implements startLifecycleRecorder for unsupported
+// hosts, where the lifecycle of the machine is not recorded and hence neither
+// why it has exited is known nor is its memory dumped when it panics.
+func startLifecycleRecorder(ctx context.Context, machine *machinev1alpha1.Machine, qcfg *QemuConfig) error {
+	if machine.Spec.CrashDump {
+		return fmt.Errorf("crash dumps are not supported on this host")
+	}
+
+	return os.WriteFile(filepath.Join(machine.Status.StateDir, lifecycleFile), nil, 0o644)
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+)
+
+const (
+	// SnapshotStateFile is the name of the file within the directory of a
+	// snapshot which contains the migration stream of the machine.
+	SnapshotStateFile = "qemu.state"
+
+	// snapshotPollInterval is the interval at which the progress of a migration
+	// is queried.
+	snapshotPollInterval = 100 * time.Millisecond
+)
+
+// shellQuote quotes the provided path such that it can be used within the
+// shell command of a migration URI.  Migration streams are written to and read
+// from files via the "exec:" protocol since, unlike the "file:" protocol, it is
+// supported by all versions of QEMU.
+func shellQuote(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
+
+// Snapshot implements kraftkit.sh/machine/platform.MachineSnapshotter
+//
+// The state of the machine is written to the snapshot via a QMP migration
+// whilst the machine is paused.  Each request is performed on its own QMP
+// connection since pausing and resuming the machine emits events which would
+// otherwise interleave with the responses of subsequent requests.
+func (service *machineV1alpha1Service) Snapshot(ctx context.Context, machine *machinev1alpha1.Machine, dir string) (err error) {
+	machine, err = service.Get(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	switch machine.Status.State {
+	case machinev1alpha1.MachineStateRunning:
+		if _, err := service.Pause(ctx, machine); err != nil {
+			return fmt.Errorf("could not pause qemu instance: %v", err)
+		}
+
+		defer func() {
+			if _, resumeErr := service.Start(ctx, machine); resumeErr != nil && err == nil {
+				err = fmt.Errorf("could not resume qemu instance: %v", resumeErr)
+			}
+		}()
+
+	case machinev1alpha1.MachineStatePaused:
+	default:
+		return fmt.Errorf("cannot snapshot qemu instance which is %s", machine.Status.State)
+	}
+
+	qmpClient, err := service.QMPClient(ctx, machine)
+	if err != nil {
+		return fmt.Errorf("could not snapshot qemu instance: %v", err)
+	}
+
+	defer qmpClient.Close()
+
+	if err := qmpResult(qmpClient.Migrate(qmpapi.MigrateRequest{
+		Arguments: qmpapi.MigrateRequestArguments{
+			Uri: "exec:cat > " + shellQuote(filepath.Join(dir, SnapshotStateFile)),
+		},
+	})); err != nil {
+		return fmt.Errorf("could not save state of qemu instance: %w", err)
+	}
+
+	for {
+		info, err := qmpClient.QueryMigrate(qmpapi.QueryMigrateRequest{})
+		if err != nil {
+			return fmt.Errorf("could not query progress of snapshot: %w", err)
+		}
+
+		switch info.Return.Status {
+		case qmpapi.MIGRATION_STATUS_COMPLETED:
+			return nil
+
+		case qmpapi.MIGRATION_STATUS_FAILED, qmpapi.MIGRATION_STATUS_CANCELLED:
+			return fmt.Errorf("could not save state of qemu instance: %s", info.Return.ErrorDesc)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(snapshotPollInterval):
+		}
+	}
+}
+
+// waitForIncoming blocks until the state of a machine which is being restored
+// from a snapshot has been loaded.  QEMU exits if the state cannot be loaded
+// such that its QMP socket becomes unavailable.
+func (service *machineV1alpha1Service) waitForIncoming(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	for {
+		qmpClient, err := service.QMPClient(ctx, machine)
+		if err != nil {
+			return err
+		}
+
+		status, err := qmpClient.QueryStatus(qmpapi.QueryStatusRequest{})
+		qmpClient.Close()
+		if err != nil {
+			return err
+		}
+
+		if status.Return.Status != qmpapi.RUN_STATE_INMIGRATE {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(snapshotPollInterval):
+		}
+	}
+}
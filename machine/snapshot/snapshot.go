@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package snapshot manages the snapshots of machines on the host.  Each
+// snapshot is a directory which is situated next to the state directories of
+// the machines and contains the state saved by the platform driver of the
+// machine alongside the machine's specification and its kernel, such that the
+// machine can later be recreated with identical networking and volumes.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
+	"kraftkit.sh/config"
+)
+
+const (
+	// MetadataFile is the name of the file within the directory of a snapshot
+	// which records the snapshot and the machine it was taken from.
+	MetadataFile = "snapshot.json"
+
+	// KernelFile is the name of the copy of the kernel of the machine within the
+	// directory of a snapshot.
+	KernelFile = "kernel"
+
+	// InitrdFile is the name of the copy of the initramfs of the machine within
+	// the directory of a snapshot.
+	InitrdFile = "initrd"
+)
+
+// Snapshot is the saved state of a machine.
+type Snapshot struct {
+	// Name of the snapshot.
+	Name string `json:"name"`
+
+	// CreatedAt represents when the snapshot was taken.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Machine is the machine the snapshot was taken from, whose kernel and
+	// initramfs refer to the copies within the directory of the snapshot.
+	Machine machinev1alpha1.Machine `json:"machine"`
+
+	dir string
+}
+
+// RootDir returns the directory which contains all snapshots.
+func RootDir(ctx context.Context) string {
+	return filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "snapshots")
+}
+
+// NewName returns a name for a new snapshot of the provided machine.
+func NewName(machine *machinev1alpha1.Machine) string {
+	return fmt.Sprintf("%s-%s", machine.Name, time.Now().Format("20060102150405"))
+}
+
+// validName checks whether the provided name can be used as the name of the
+// directory of a snapshot.
+func validName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name: %q", name)
+	}
+
+	return nil
+}
+
+// New prepares the directory of a new snapshot of the provided machine,
+// recording its specification and copying its kernel and initramfs.  The
+// snapshot is only listed once it has been saved, i.e. after the platform
+// driver has written the state of the machine to the directory of the
+// snapshot.
+func New(ctx context.Context, name string, machine *machinev1alpha1.Machine) (*Snapshot, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(RootDir(ctx), name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("snapshot already exists: %s", name)
+	}
+
+	if err := os.MkdirAll(dir, fs.ModeSetgid|0o775); err != nil {
+		return nil, err
+	}
+
+	snapshot := Snapshot{
+		Name: name,
+		Machine: machinev1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   machine.Name,
+				Labels: machine.Labels,
+			},
+			Spec: machine.Spec,
+		},
+		dir: dir,
+	}
+
+	if machine.Status.KernelPath != "" {
+		snapshot.Machine.Status.KernelPath = filepath.Join(dir, KernelFile)
+		if err := copyFile(machine.Status.KernelPath, snapshot.Machine.Status.KernelPath); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("could not copy kernel: %w", err)
+		}
+	}
+
+	if machine.Status.InitrdPath != "" {
+		snapshot.Machine.Status.InitrdPath = filepath.Join(dir, InitrdFile)
+		if err := copyFile(machine.Status.InitrdPath, snapshot.Machine.Status.InitrdPath); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("could not copy initramfs: %w", err)
+		}
+	}
+
+	return &snapshot, nil
+}
+
+// Dir returns the in-host path to the directory of the snapshot.
+func (snapshot *Snapshot) Dir() string {
+	return snapshot.dir
+}
+
+// Save records the snapshot within its directory.
+func (snapshot *Snapshot) Save() error {
+	snapshot.CreatedAt = time.Now()
+
+	b, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(snapshot.dir, MetadataFile), b, 0o644)
+}
+
+// Size returns the total size of the files of the snapshot in bytes.
+func (snapshot *Snapshot) Size() (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(snapshot.dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if d.IsDir() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += fi.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
+// Get returns the snapshot with the provided name.
+func Get(ctx context.Context, name string) (*Snapshot, error) {
+	if err := validName(name); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(RootDir(ctx), name)
+
+	b, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return nil, fmt.Errorf("could not parse snapshot %s: %w", name, err)
+	}
+
+	snapshot.dir = dir
+
+	return &snapshot, nil
+}
+
+// List returns all saved snapshots ordered by the time they were taken.
+func List(ctx context.Context) ([]Snapshot, error) {
+	entries, err := os.ReadDir(RootDir(ctx))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// Skip snapshots which have not been saved, e.g. because they are still
+		// being taken or because taking them failed.
+		if _, err := os.Stat(filepath.Join(RootDir(ctx), entry.Name(), MetadataFile)); err != nil {
+			continue
+		}
+
+		snapshot, err := Get(ctx, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// Remove deletes the snapshot with the provided name.
+func Remove(ctx context.Context, name string) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(RootDir(ctx), name)
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
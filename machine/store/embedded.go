@@ -7,58 +7,142 @@ package store
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	zip "api.zip"
 	"github.com/dgraph-io/badger/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/storage"
 	"kraftkit.sh/internal/retrytimeout"
 )
 
+// versionKey is the key of the most recent resource version of the store.
+// Every write increments it within the same transaction such that concurrent
+// writes conflict and resource versions are unique and monotonic across all
+// objects of the store.
+var versionKey = []byte("\x00kraftkit.sh/resourceVersion")
+
+// embeddedStores are the stores which have been instantiated within this
+// process indexed by their path, such that all users of a store share its
+// handle and are published the changes of each other.
+var (
+	embeddedStoresMu sync.Mutex
+	embeddedStores   = map[string]any{}
+)
+
+// embeddedVersioner sets and parses the resource version of objects, which is
+// stored together with the object itself.
 type embeddedVersioner struct{}
 
 // UpdateObject implements storage.Versioner
 func (version *embeddedVersioner) UpdateObject(obj runtime.Object, resourceVersion uint64) error {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.UpdateObject")
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	versionString := ""
+	if resourceVersion != 0 {
+		versionString = strconv.FormatUint(resourceVersion, 10)
+	}
+
+	accessor.SetResourceVersion(versionString)
+
+	return nil
 }
 
 // UpdateList implements storage.Versioner
 func (version *embeddedVersioner) UpdateList(obj runtime.Object, resourceVersion uint64, continueValue string, remainingItemCount *int64) error {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.UpdateList")
+	if resourceVersion == 0 {
+		return fmt.Errorf("illegal resource version from storage: %d", resourceVersion)
+	}
+
+	accessor, err := meta.ListAccessor(obj)
+	if err != nil {
+		return err
+	}
+
+	accessor.SetResourceVersion(strconv.FormatUint(resourceVersion, 10))
+	accessor.SetContinue(continueValue)
+	accessor.SetRemainingItemCount(remainingItemCount)
+
+	return nil
 }
 
 // PrepareObjectForStorage implements storage.Versioner
 func (version *embeddedVersioner) PrepareObjectForStorage(obj runtime.Object) error {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.PrepareObjectForStorage")
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	accessor.SetResourceVersion("")
+	accessor.SetSelfLink("")
+
+	return nil
 }
 
 // ObjectResourceVersion implements storage.Versioner
 func (version *embeddedVersioner) ObjectResourceVersion(obj runtime.Object) (uint64, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.ObjectResourceVersion")
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return 0, err
+	}
+
+	return version.ParseResourceVersion(accessor.GetResourceVersion())
 }
 
 // ParseResourceVersion implements storage.Versioner
 func (version *embeddedVersioner) ParseResourceVersion(resourceVersion string) (uint64, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embeddedVersioner.ParseResourceVersion")
+	if resourceVersion == "" || resourceVersion == "0" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resource version %q: %v", resourceVersion, err)
+	}
+
+	return v, nil
 }
 
 // embedded is KraftKit's default internal storage mechanism which is based on
+// the embeddable key-value database Badger.
+//
+// The database can only be opened by a single process at a time and hence it
+// is only kept open whilst it is being accessed or watched.  Within a process,
+// the handle is shared by concurrent accesses such that Badger detects
+// conflicting transactions and changes are published to watchers.
 type embedded[Spec, Status any] struct {
 	path      string
 	versioner *embeddedVersioner
 	db        *badger.DB
 	bopts     badger.Options
 	timeout   time.Duration
+
+	// mu protects the handle to the database, the number of its users, the
+	// watchers of the store and their shared subscription to its changes.
+	mu       sync.Mutex
+	refs     int
+	watchers map[*embeddedWatcher[Spec, Status]]struct{}
+	feed     *embeddedFeed
 }
 
 // NewEmbeddedStore returns a api.zip.Store-compatible storage interface based
 // on the embeddable key-value database Badger.
+//
+// Stores of the same path are shared within the process, since the database
+// cannot be opened more than once at a time, e.g. whilst it is watched.
 func NewEmbeddedStore[Spec, Status any](path string) (zip.Store, error) {
 	var err error
 
@@ -69,11 +153,24 @@ func NewEmbeddedStore[Spec, Status any](path string) (zip.Store, error) {
 		}
 	}
 
+	embeddedStoresMu.Lock()
+	defer embeddedStoresMu.Unlock()
+
+	if existing, ok := embeddedStores[path]; ok {
+		storage, ok := existing.(*embedded[Spec, Status])
+		if !ok {
+			return nil, fmt.Errorf("store at %s holds objects of another type", path)
+		}
+
+		return storage, nil
+	}
+
 	storage := embedded[Spec, Status]{
 		bopts:     badger.DefaultOptions(path),
 		timeout:   5 * time.Second,
 		path:      path,
 		versioner: &embeddedVersioner{},
+		watchers:  map[*embeddedWatcher[Spec, Status]]struct{}{},
 	}
 
 	// TODO: Badger uses an internal `Infof` logger method entry which is too low
@@ -86,11 +183,22 @@ func NewEmbeddedStore[Spec, Status any](path string) (zip.Store, error) {
 	// inconsistent with enabling "debugging".
 	storage.bopts.Logger = nil
 
+	embeddedStores[path] = &storage
+
 	return &storage, nil
 }
 
-// open the embedded key-value store
+// open the embedded key-value store, or share the handle if it is already open
+// within this process
 func (store *embedded[_, _]) open() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.refs > 0 {
+		store.refs++
+		return nil
+	}
+
 	var db *badger.DB
 
 	db, err := badger.Open(store.bopts)
@@ -113,13 +221,128 @@ func (store *embedded[_, _]) open() error {
 	}
 
 	store.db = db
+	store.refs = 1
 
 	return nil
 }
 
-// close the embedded key-value store
+// close the embedded key-value store once it is no longer used within this
+// process
 func (store *embedded[_, _]) close() error {
-	return store.db.Close()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.refs--
+	if store.refs > 0 {
+		return nil
+	}
+
+	db := store.db
+	store.db = nil
+
+	return db.Close()
+}
+
+// update performs the provided read-write transaction, which is retried if it
+// conflicts with a concurrent transaction.
+func (store *embedded[_, _]) update(ctx context.Context, fn func(txn *badger.Txn) error) error {
+	for {
+		err := store.db.Update(fn)
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// currentVersion returns the most recent resource version of the store.
+func currentVersion(txn *badger.Txn) (uint64, error) {
+	item, err := txn.Get(versionKey)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var version uint64
+
+	if err := item.Value(func(val []byte) error {
+		if len(val) != 8 {
+			return fmt.Errorf("malformed resource version of store")
+		}
+
+		version = binary.BigEndian.Uint64(val)
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// nextVersion increments and returns the resource version of the store as part
+// of the provided transaction.
+func nextVersion(txn *badger.Txn) (uint64, error) {
+	version, err := currentVersion(txn)
+	if err != nil {
+		return 0, err
+	}
+
+	version++
+
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, version)
+
+	return version, txn.Set(versionKey, val)
+}
+
+// encode the provided object for storage.
+func encode(obj runtime.Object) ([]byte, error) {
+	b := bytes.Buffer{}
+	if err := gob.NewEncoder(&b).Encode(obj); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// decode the provided stored value into the provided object.
+func decode(val []byte, obj any) error {
+	return gob.NewDecoder(bytes.NewReader(val)).Decode(obj)
+}
+
+// write stores the provided object at the provided key with the next resource
+// version of the store as part of the provided transaction.  The resource
+// version of the object is set accordingly.
+func (store *embedded[_, _]) write(txn *badger.Txn, key string, obj runtime.Object, ttl uint64) error {
+	version, err := nextVersion(txn)
+	if err != nil {
+		return err
+	}
+
+	if err := store.versioner.PrepareObjectForStorage(obj); err != nil {
+		return err
+	}
+
+	if err := store.versioner.UpdateObject(obj, version); err != nil {
+		return err
+	}
+
+	val, err := encode(obj)
+	if err != nil {
+		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
+	}
+
+	entry := badger.NewEntry([]byte(key), val)
+	if ttl > 0 {
+		entry = entry.WithTTL(time.Duration(ttl) * time.Second)
+	}
+
+	return txn.SetEntry(entry)
 }
 
 // Versioner implements storage.Interface
@@ -128,48 +351,161 @@ func (store *embedded[_, _]) Versioner() storage.Versioner {
 }
 
 // Create implements storage.Interface
-func (store *embedded[_, _]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
+//
+// Unlike other implementations, an existing object is overwritten.  If the
+// provided object has a resource version, it is only overwritten if its stored
+// resource version matches.
+func (store *embedded[Spec, Status]) Create(ctx context.Context, key string, _, out runtime.Object, ttl uint64) error {
+	expected, err := store.versioner.ObjectResourceVersion(out)
+	if err != nil {
+		return err
+	}
+
 	if err := store.open(); err != nil {
 		return err
 	}
 
 	defer store.close()
 
-	b := bytes.Buffer{}
-	if err := gob.NewEncoder(&b).Encode(out); err != nil {
-		return fmt.Errorf("could not encode driver config for %s: %v", key, err)
-	}
+	if err := store.update(ctx, func(txn *badger.Txn) error {
+		if expected > 0 {
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return storage.NewKeyNotFoundError(key, int64(expected))
+			} else if err != nil {
+				return err
+			}
+
+			var existing zip.Object[Spec, Status]
+			if err := item.Value(func(val []byte) error {
+				return decode(val, &existing)
+			}); err != nil {
+				return fmt.Errorf("could not decode %s: %v", key, err)
+			}
+
+			if existing.ResourceVersion != strconv.FormatUint(expected, 10) {
+				return storage.NewResourceVersionConflictsError(key, int64(expected))
+			}
+		}
+
+		return store.write(txn, key, out, ttl)
+	}); err != nil {
+		var serr *storage.StorageError
+		if errors.As(err, &serr) {
+			return err
+		}
 
-	txn := store.db.NewTransaction(true)
-	if err := txn.SetEntry(badger.NewEntry([]byte(key), b.Bytes())); err != nil {
 		return fmt.Errorf("could not save machine driver to store for %s: %v", key, err)
 	}
 
-	return txn.Commit()
+	return nil
 }
 
 // Delete implements storage.Interface
-func (store *embedded[_, _]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, cachedExistingObject runtime.Object) error {
+func (store *embedded[Spec, Status]) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc, _ runtime.Object) error {
 	if err := store.open(); err != nil {
 		return err
 	}
 
 	defer store.close()
 
-	txn := store.db.NewTransaction(true)
+	return store.update(ctx, func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return storage.NewKeyNotFoundError(key, 0)
+		} else if err != nil {
+			return err
+		}
 
-	if err := txn.Delete([]byte(key)); err != nil {
-		return err
-	}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return fmt.Errorf("could not copy from store for %s: %v", key, err)
+		}
 
-	// TODO(nderjung): preconditions, validateDelete, cachedExistingObject
+		existing := &zip.Object[Spec, Status]{}
+		if err := decode(val, existing); err != nil {
+			return fmt.Errorf("could not decode %s: %v", key, err)
+		}
 
-	return txn.Commit()
+		if err := preconditions.Check(key, existing); err != nil {
+			return err
+		}
+
+		if validateDeletion != nil {
+			if err := validateDeletion(ctx, existing); err != nil {
+				return err
+			}
+		}
+
+		if _, err := nextVersion(txn); err != nil {
+			return err
+		}
+
+		if err := txn.Delete([]byte(key)); err != nil {
+			return err
+		}
+
+		if out != nil {
+			return decode(val, out)
+		}
+
+		return nil
+	})
 }
 
 // Watch implements storage.Interface
-func (store *embedded[_, _]) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.Watch")
+//
+// Changes are received through a single subscription to Badger's change feed
+// which is shared by all watchers of the store.  The database is kept open for
+// as long as it is watched, such that other processes, which cannot open it in
+// the meantime, must access the store through the process which watches it,
+// i.e. the daemon.
+func (store *embedded[Spec, Status]) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	since, err := store.versioner.ParseResourceVersion(opts.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.open(); err != nil {
+		return nil, err
+	}
+
+	watcher := newEmbeddedWatcher(ctx, store, key, since)
+
+	store.mu.Lock()
+	store.watchers[watcher] = struct{}{}
+	if store.feed == nil {
+		store.feed = store.follow()
+	}
+	feed := store.feed
+	store.mu.Unlock()
+
+	go watcher.run(feed)
+
+	return watcher, nil
+}
+
+// unwatch removes the provided watcher from the store and closes the database
+// once it is no longer watched, or otherwise used, within this process.
+func (store *embedded[Spec, Status]) unwatch(watcher *embeddedWatcher[Spec, Status]) {
+	store.mu.Lock()
+	delete(store.watchers, watcher)
+
+	// The subscription is ended outside of the lock, since the change feed may be
+	// waiting for it to deliver the last changes.
+	var feed *embeddedFeed
+	if len(store.watchers) == 0 {
+		feed = store.feed
+		store.feed = nil
+	}
+	store.mu.Unlock()
+
+	if feed != nil {
+		feed.cancel()
+		<-feed.done
+	}
+
+	_ = store.close()
 }
 
 // Get implements storage.Interface
@@ -182,7 +518,9 @@ func (store *embedded[_, _]) Get(ctx context.Context, key string, opts storage.G
 
 	if err := store.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
-		if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) && opts.IgnoreNotFound {
+			return runtime.SetZeroValue(objPtr)
+		} else if err != nil {
 			return fmt.Errorf("could not access store for %s: %v", key, err)
 		}
 
@@ -191,10 +529,7 @@ func (store *embedded[_, _]) Get(ctx context.Context, key string, opts storage.G
 			return fmt.Errorf("could not copy from store for %s: %v", key, err)
 		}
 
-		b := bytes.Buffer{}
-		b.Write(val)
-
-		return gob.NewDecoder(&b).Decode(objPtr)
+		return decode(val, objPtr)
 	}); err != nil {
 		return fmt.Errorf("could not read from store for %s: %v", key, err)
 	}
@@ -202,6 +537,63 @@ func (store *embedded[_, _]) Get(ctx context.Context, key string, opts storage.G
 	return nil
 }
 
+// list returns the objects whose key has the provided prefix, indexed by their
+// key, together with the resource version of the store.
+func (store *embedded[Spec, Status]) list(key string) (map[string]*zip.Object[Spec, Status], uint64, error) {
+	var objs map[string]*zip.Object[Spec, Status]
+	var version uint64
+
+	if err := store.db.View(func(txn *badger.Txn) error {
+		var err error
+		objs, version, err = listTxn[Spec, Status](txn, key)
+		return err
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	return objs, version, nil
+}
+
+// listTxn returns the objects whose key has the provided prefix as seen by the
+// provided transaction, indexed by their key, together with the resource
+// version of the store.
+func listTxn[Spec, Status any](txn *badger.Txn, key string) (map[string]*zip.Object[Spec, Status], uint64, error) {
+	objs := map[string]*zip.Object[Spec, Status]{}
+
+	version, err := currentVersion(txn)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	itr := txn.NewIterator(badger.IteratorOptions{
+		Prefix:       []byte(key),
+		PrefetchSize: 10, // TODO(nderjung): Arbitrarily picked
+	})
+
+	defer itr.Close()
+
+	for itr.Rewind(); itr.Valid(); itr.Next() {
+		if bytes.Equal(itr.Item().Key(), versionKey) {
+			continue
+		}
+
+		val, err := itr.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var obj zip.Object[Spec, Status]
+
+		if err := decode(val, &obj); err != nil {
+			return nil, 0, err
+		}
+
+		objs[string(itr.Item().KeyCopy(nil))] = &obj
+	}
+
+	return objs, version, nil
+}
+
 // GetList implements storage.Interface
 func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
 	if err := store.open(); err != nil {
@@ -216,46 +608,112 @@ func (store *embedded[Spec, Status]) GetList(ctx context.Context, key string, op
 	// Truncate the list of results as we are about to re-populate
 	list.Items = make([]zip.Object[Spec, Status], 0)
 
-	if err := store.db.View(func(txn *badger.Txn) error {
-		itr := txn.NewIterator(badger.IteratorOptions{
-			Prefix:       []byte(key),
-			PrefetchSize: 10, // TODO(nderjung): Arbitrarily picked
-		})
+	objs, version, err := store.list(key)
+	if err != nil {
+		return fmt.Errorf("could not list from store at %s: %v", key, err)
+	}
 
-		defer itr.Close()
+	for _, obj := range objs {
+		list.Items = append(list.Items, *obj)
+	}
 
-		for itr.Rewind(); itr.Valid(); itr.Next() {
-			val, err := itr.Item().ValueCopy(nil)
-			if err != nil {
-				return err
-			}
+	if version > 0 {
+		return store.versioner.UpdateList(list, version, "", nil)
+	}
 
-			b := bytes.Buffer{}
-			b.Write(val)
+	return nil
+}
 
-			var obj zip.Object[Spec, Status]
+// GuaranteedUpdate implements storage.Interface
+//
+// The object is read and written within the same transaction which is retried
+// with the most recent object if it conflicts with a concurrent write.
+func (store *embedded[Spec, Status]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, _ runtime.Object) error {
+	if err := store.open(); err != nil {
+		return err
+	}
+
+	defer store.close()
 
-			if err := gob.NewDecoder(&b).Decode(&obj); err != nil {
+	return store.update(ctx, func(txn *badger.Txn) error {
+		existing := &zip.Object[Spec, Status]{}
+
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			if !ignoreNotFound {
+				return storage.NewKeyNotFoundError(key, 0)
+			}
+		} else if err != nil {
+			return err
+		} else {
+			if err := item.Value(func(val []byte) error {
+				return decode(val, existing)
+			}); err != nil {
+				return fmt.Errorf("could not decode %s: %v", key, err)
+			}
+
+			if err := preconditions.Check(key, existing); err != nil {
 				return err
 			}
+		}
 
-			list.Items = append(list.Items, obj)
+		version, err := store.versioner.ObjectResourceVersion(existing)
+		if err != nil {
+			return err
 		}
 
-		return nil
-	}); err != nil {
-		return fmt.Errorf("could not list from store at %s: %v", key, err)
-	}
+		updated, ttl, err := tryUpdate(existing, storage.ResponseMeta{
+			ResourceVersion: version,
+		})
+		if err != nil {
+			return err
+		}
 
-	return nil
-}
+		var expiry uint64
+		if ttl != nil {
+			expiry = *ttl
+		}
 
-// GuaranteedUpdate implements storage.Interface
-func (store *embedded[_, _]) GuaranteedUpdate(ctx context.Context, key string, destination runtime.Object, ignoreNotFound bool, preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, cachedExistingObject runtime.Object) error {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.GuaranteedUpdate")
+		if err := store.write(txn, key, updated, expiry); err != nil {
+			return fmt.Errorf("could not save machine driver to store for %s: %v", key, err)
+		}
+
+		val, err := encode(updated)
+		if err != nil {
+			return err
+		}
+
+		return decode(val, destination)
+	})
 }
 
 // Count implements storage.Interface
 func (store *embedded[_, _]) Count(key string) (int64, error) {
-	panic("not implemented: kraftkit.sh/machine/store.embedded.Count")
+	if err := store.open(); err != nil {
+		return 0, err
+	}
+
+	defer store.close()
+
+	var count int64
+
+	if err := store.db.View(func(txn *badger.Txn) error {
+		itr := txn.NewIterator(badger.IteratorOptions{
+			Prefix: []byte(key),
+		})
+
+		defer itr.Close()
+
+		for itr.Rewind(); itr.Valid(); itr.Next() {
+			if !bytes.Equal(itr.Item().Key(), versionKey) {
+				count++
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("could not count in store at %s: %v", key, err)
+	}
+
+	return count, nil
 }
@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+)
+
+type testSpec struct {
+	Value string
+}
+
+type testStatus struct {
+	State string
+}
+
+type testObject = zip.Object[testSpec, testStatus]
+
+func newTestStore(t *testing.T) *embedded[testSpec, testStatus] {
+	t.Helper()
+
+	s, err := NewEmbeddedStore[testSpec, testStatus](t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s.(*embedded[testSpec, testStatus])
+}
+
+func newTestObject(name, value, resourceVersion string) *testObject {
+	return &testObject{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: resourceVersion,
+		},
+		Spec: testSpec{Value: value},
+	}
+}
+
+// create stores the provided object and returns its resource version.
+func create(t *testing.T, store *embedded[testSpec, testStatus], key string, obj *testObject) string {
+	t.Helper()
+
+	if err := store.Create(context.Background(), key, obj, obj, 0); err != nil {
+		t.Fatalf("Create(%s) error = %v", key, err)
+	}
+
+	return obj.ResourceVersion
+}
+
+func TestEmbeddedCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing is created before the object under test, unless empty.
+		existing string
+		// version returns the resource version of the object under test given
+		// that of the existing object.
+		version func(existing string) string
+		want    func(error) bool
+	}{
+		{
+			name:    "new without version",
+			version: func(string) string { return "" },
+		},
+		{
+			name:     "existing without version",
+			existing: "a",
+			version:  func(string) string { return "" },
+		},
+		{
+			name:     "existing with current version",
+			existing: "a",
+			version:  func(existing string) string { return existing },
+		},
+		{
+			name:     "existing with stale version",
+			existing: "a",
+			version:  func(string) string { return "42" },
+			want:     storage.IsConflict,
+		},
+		{
+			name:    "missing with version",
+			version: func(string) string { return "1" },
+			want:    storage.IsNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+
+			var existing string
+			if tt.existing != "" {
+				existing = create(t, store, "/objects/test", newTestObject("test", tt.existing, ""))
+			}
+
+			obj := newTestObject("test", "b", tt.version(existing))
+			err := store.Create(context.Background(), "/objects/test", obj, obj, 0)
+
+			if tt.want != nil {
+				if !tt.want(err) {
+					t.Fatalf("Create() error = %v", err)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			var got testObject
+			if err := store.Get(context.Background(), "/objects/test", storage.GetOptions{}, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Spec.Value != "b" || got.ResourceVersion != obj.ResourceVersion || got.ResourceVersion == existing {
+				t.Errorf("Get() = %q at version %q, want %q at version %q (previously %q)", got.Spec.Value, got.ResourceVersion, "b", obj.ResourceVersion, existing)
+			}
+		})
+	}
+}
+
+func TestEmbeddedGuaranteedUpdate(t *testing.T) {
+	tests := []struct {
+		name string
+		// concurrent is performed whilst the first attempt of the update is in
+		// progress, unless nil.
+		concurrent   func(*testing.T, *embedded[testSpec, testStatus])
+		wantAttempts int
+		wantValue    string
+		wantErr      func(error) bool
+	}{
+		{
+			name:         "without conflict",
+			wantAttempts: 1,
+			wantValue:    "a+",
+		},
+		{
+			name: "conflicting update",
+			concurrent: func(t *testing.T, store *embedded[testSpec, testStatus]) {
+				create(t, store, "/objects/test", newTestObject("test", "b", ""))
+			},
+			wantAttempts: 2,
+			wantValue:    "b+",
+		},
+		{
+			name: "conflicting delete",
+			concurrent: func(t *testing.T, store *embedded[testSpec, testStatus]) {
+				if err := store.Delete(context.Background(), "/objects/test", nil, nil, nil, nil); err != nil {
+					t.Error(err)
+				}
+			},
+			wantAttempts: 1,
+			wantErr:      storage.IsNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			create(t, store, "/objects/test", newTestObject("test", "a", ""))
+
+			attempts := 0
+			var got testObject
+
+			err := store.GuaranteedUpdate(context.Background(), "/objects/test", &got, false, nil, func(input runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+				attempts++
+
+				// Write concurrently from another goroutine, i.e. in another
+				// transaction, such that this transaction conflicts once committed.
+				if attempts == 1 && tt.concurrent != nil {
+					done := make(chan struct{})
+					go func() {
+						defer close(done)
+						tt.concurrent(t, store)
+					}()
+					<-done
+				}
+
+				obj := input.(*testObject).DeepCopyObject().(*testObject)
+				obj.Spec.Value += "+"
+
+				return obj, nil, nil
+			}, nil)
+
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("GuaranteedUpdate() error = %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("GuaranteedUpdate() error = %v", err)
+			} else if got.Spec.Value != tt.wantValue {
+				t.Errorf("GuaranteedUpdate() = %q, want %q", got.Spec.Value, tt.wantValue)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("GuaranteedUpdate() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestEmbeddedWatch(t *testing.T) {
+	type event struct {
+		Type  watch.EventType
+		Name  string
+		Value string
+	}
+
+	tests := []struct {
+		name string
+		// fromExisting watches from the resource version of the object which
+		// exists before the store is watched.
+		fromExisting bool
+		want         []event
+	}{
+		{
+			name: "from start",
+			want: []event{
+				{watch.Added, "a", "1"},
+				{watch.Added, "b", "1"},
+				{watch.Modified, "a", "2"},
+				{watch.Deleted, "b", "1"},
+			},
+		},
+		{
+			name:         "from version",
+			fromExisting: true,
+			want: []event{
+				{watch.Added, "b", "1"},
+				{watch.Modified, "a", "2"},
+				{watch.Deleted, "b", "1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+
+			// Objects of other prefixes are never reported.
+			create(t, store, "/others/a", newTestObject("a", "1", ""))
+			version := create(t, store, "/objects/a", newTestObject("a", "1", ""))
+
+			opts := storage.ListOptions{}
+			if tt.fromExisting {
+				opts.ResourceVersion = version
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			watcher, err := store.Watch(ctx, "/objects/", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer watcher.Stop()
+
+			var got []event
+
+			next := func() {
+				select {
+				case e, ok := <-watcher.ResultChan():
+					if !ok {
+						t.Fatal("watcher stopped unexpectedly")
+					}
+
+					obj := e.Object.(*testObject)
+					got = append(got, event{e.Type, obj.Name, obj.Spec.Value})
+				case <-ctx.Done():
+					t.Fatalf("timed out after events %v", got)
+				}
+			}
+
+			if !tt.fromExisting {
+				next()
+			}
+
+			create(t, store, "/others/b", newTestObject("b", "1", ""))
+			create(t, store, "/objects/b", newTestObject("b", "1", ""))
+			next()
+
+			create(t, store, "/objects/a", newTestObject("a", "2", ""))
+			next()
+
+			if err := store.Delete(ctx, "/objects/b", nil, nil, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			next()
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Watch() events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []string
+		prefix string
+		want   int64
+	}{
+		{
+			name:   "empty",
+			prefix: "/objects/",
+			want:   0,
+		},
+		{
+			name:   "prefix",
+			keys:   []string{"/objects/a", "/objects/b", "/others/c"},
+			prefix: "/objects/",
+			want:   2,
+		},
+		{
+			name:   "all",
+			keys:   []string{"/objects/a", "/objects/b", "/others/c"},
+			prefix: "",
+			want:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+
+			for _, key := range tt.keys {
+				create(t, store, key, newTestObject(key, "", ""))
+			}
+
+			got, err := store.Count(tt.prefix)
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEmbeddedStoreShared(t *testing.T) {
+	path := t.TempDir()
+
+	first, err := NewEmbeddedStore[testSpec, testStatus](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := NewEmbeddedStore[testSpec, testStatus](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first != second {
+		t.Errorf("NewEmbeddedStore() returned another store of the same path")
+	}
+
+	if _, err := NewEmbeddedStore[testStatus, testSpec](path); err == nil {
+		t.Errorf("NewEmbeddedStore() of another type did not fail")
+	}
+}
@@ -0,0 +1,305 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2023, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+
+	zip "api.zip"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/badger/v3/pb"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// feedProbeInterval is how often the store is touched until the subscription
+// of the change feed has been registered by Badger.
+const feedProbeInterval = 10 * time.Millisecond
+
+// embeddedFeed is the single subscription of the store to the change feed of
+// the open database, whose changes are fanned out to all of its watchers.
+type embeddedFeed struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// ready is closed once the first change has been received, i.e. once the
+	// subscription has been registered.
+	ready chan struct{}
+	once  sync.Once
+}
+
+// embeddedWatcher implements watch.Interface for the embedded store.  Changes
+// are derived by comparing the received objects with the most recently seen
+// objects such that each change is reported once, regardless of whether it was
+// received from the change feed or by the initial read of the store.
+type embeddedWatcher[Spec, Status any] struct {
+	store  *embedded[Spec, Status]
+	prefix string
+	ctx    context.Context
+	cancel context.CancelFunc
+	result chan watch.Event
+	stop   sync.Once
+
+	// since is the resource version after which changes are reported.  Objects
+	// which have not changed since are only reported if they change again.
+	since uint64
+
+	// objects are the most recently seen objects indexed by their key.
+	objects map[string]*zip.Object[Spec, Status]
+
+	// readTs is the timestamp at which the store was initially read, such that
+	// the changes which were committed beforehand are not reported again.
+	readTs uint64
+
+	// pending are the changes received from the change feed which have not yet
+	// been processed and notify signals that there are any.
+	mu      sync.Mutex
+	pending []*pb.KV
+	notify  chan struct{}
+}
+
+// newEmbeddedWatcher returns a watcher for the objects of the provided store
+// whose key has the provided prefix.
+func newEmbeddedWatcher[Spec, Status any](ctx context.Context, store *embedded[Spec, Status], prefix string, since uint64) *embeddedWatcher[Spec, Status] {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &embeddedWatcher[Spec, Status]{
+		store:   store,
+		prefix:  prefix,
+		ctx:     ctx,
+		cancel:  cancel,
+		result:  make(chan watch.Event),
+		since:   since,
+		objects: map[string]*zip.Object[Spec, Status]{},
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// follow subscribes the store to the change feed of the open database until
+// the returned feed is cancelled.  Must be called with the handle locked.
+func (store *embedded[_, _]) follow() *embeddedFeed {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	feed := &embeddedFeed{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		ready:  make(chan struct{}),
+	}
+
+	db := store.db
+
+	go func() {
+		defer close(feed.done)
+
+		// The subscription ends with an error once its context is cancelled.
+		_ = db.Subscribe(ctx, func(kvs *badger.KVList) error {
+			feed.once.Do(func() { close(feed.ready) })
+
+			store.mu.Lock()
+			defer store.mu.Unlock()
+
+			for watcher := range store.watchers {
+				watcher.receive(kvs)
+			}
+
+			return nil
+		}, []pb.Match{{Prefix: nil}})
+	}()
+
+	return feed
+}
+
+// await blocks until the subscription of the provided feed has been
+// registered, which Badger does not signal otherwise, by touching the resource
+// version of the store until the change is received.
+func (store *embedded[_, _]) await(ctx context.Context, feed *embeddedFeed) error {
+	for {
+		if err := store.update(ctx, func(txn *badger.Txn) error {
+			version, err := currentVersion(txn)
+			if err != nil {
+				return err
+			}
+
+			val := make([]byte, 8)
+			binary.BigEndian.PutUint64(val, version)
+
+			return txn.Set(versionKey, val)
+		}); err != nil {
+			return err
+		}
+
+		select {
+		case <-feed.ready:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(feedProbeInterval):
+		}
+	}
+}
+
+// receive queues the provided changes from the change feed.  It must not block
+// as the database cannot be closed until it returns.
+func (watcher *embeddedWatcher[_, _]) receive(kvs *badger.KVList) {
+	watcher.mu.Lock()
+	for _, kv := range kvs.GetKv() {
+		if strings.HasPrefix(string(kv.GetKey()), watcher.prefix) {
+			watcher.pending = append(watcher.pending, kv)
+		}
+	}
+	watcher.mu.Unlock()
+
+	select {
+	case watcher.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Stop implements watch.Interface
+func (watcher *embeddedWatcher[_, _]) Stop() {
+	watcher.stop.Do(func() {
+		watcher.cancel()
+		watcher.store.unwatch(watcher)
+	})
+}
+
+// ResultChan implements watch.Interface
+func (watcher *embeddedWatcher[_, _]) ResultChan() <-chan watch.Event {
+	return watcher.result
+}
+
+// run reports changes until the watcher is stopped, starting with the initial
+// state of the store.
+func (watcher *embeddedWatcher[Spec, Status]) run(feed *embeddedFeed) {
+	defer close(watcher.result)
+	defer watcher.Stop()
+
+	// The store is only read once its changes are received, such that no change
+	// is missed in between.  The changes which are received before the store
+	// has been read are skipped, since they are reflected by it.
+	if err := watcher.store.await(watcher.ctx, feed); err != nil {
+		return
+	}
+
+	var objs map[string]*zip.Object[Spec, Status]
+	if err := watcher.store.db.View(func(txn *badger.Txn) error {
+		var err error
+		objs, _, err = listTxn[Spec, Status](txn, watcher.prefix)
+		watcher.readTs = txn.ReadTs()
+		return err
+	}); err != nil {
+		return
+	}
+
+	for key, obj := range objs {
+		if !watcher.observe(key, obj) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-watcher.ctx.Done():
+			return
+
+		case <-watcher.notify:
+			watcher.mu.Lock()
+			pending := watcher.pending
+			watcher.pending = nil
+			watcher.mu.Unlock()
+
+			for _, kv := range pending {
+				if !watcher.apply(kv) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// apply reports the change of the provided key-value pair of the change feed.
+// It returns false if the watcher has been stopped.
+func (watcher *embeddedWatcher[Spec, Status]) apply(kv *pb.KV) bool {
+	if kv.GetVersion() <= watcher.readTs {
+		return true
+	}
+
+	key := string(kv.GetKey())
+
+	// Deleted keys are published without a value.
+	if len(kv.GetValue()) == 0 {
+		return watcher.observe(key, nil)
+	}
+
+	var obj zip.Object[Spec, Status]
+	if err := decode(kv.GetValue(), &obj); err != nil {
+		return true
+	}
+
+	return watcher.observe(key, &obj)
+}
+
+// observe reports the provided object as the most recent state of the provided
+// key, or its deletion if it is nil, unless it has already been seen.  It
+// returns false if the watcher has been stopped.
+func (watcher *embeddedWatcher[Spec, Status]) observe(key string, obj *zip.Object[Spec, Status]) bool {
+	if bytes.Equal([]byte(key), versionKey) {
+		return true
+	}
+
+	seen, ok := watcher.objects[key]
+
+	event := watch.Event{}
+
+	switch {
+	case obj == nil && !ok:
+		return true
+
+	case obj == nil:
+		event.Type = watch.Deleted
+		event.Object = seen
+
+	default:
+		version, err := watcher.store.versioner.ObjectResourceVersion(obj)
+		if err != nil {
+			return true
+		}
+
+		if ok {
+			if seenVersion, err := watcher.store.versioner.ObjectResourceVersion(seen); err == nil && version <= seenVersion {
+				return true
+			}
+
+			event.Type = watch.Modified
+		} else if version <= watcher.since && version > 0 {
+			// Objects are only reported once they change after the requested
+			// resource version.
+			watcher.objects[key] = obj
+			return true
+		} else {
+			event.Type = watch.Added
+		}
+
+		event.Object = obj
+	}
+
+	select {
+	case <-watcher.ctx.Done():
+		return false
+	case watcher.result <- event:
+	}
+
+	if obj == nil {
+		delete(watcher.objects, key)
+	} else {
+		watcher.objects[key] = obj
+	}
+
+	return true
+}
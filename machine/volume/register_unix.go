@@ -13,6 +13,7 @@ import (
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/kconfig"
+	"kraftkit.sh/machine/daemon"
 	"kraftkit.sh/machine/store"
 	ninepfs "kraftkit.sh/machine/volume/9pfs"
 )
@@ -30,7 +31,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 				// build configuration.
 				return true, nil
 			},
-			NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+			NewVolumeV1alpha1: daemon.VolumeV1alpha1("9pfs", func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
 				service, err := ninepfs.NewVolumeServiceV1alpha1(ctx, opts...)
 				if err != nil {
 					return nil, err
@@ -51,7 +52,7 @@ func hostSupportedStrategies() map[string]*Strategy {
 					service,
 					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](embeddedStore, zip.StoreRehydrationSpecNil),
 				)
-			},
+			}),
 		},
 	}
 }
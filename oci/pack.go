@@ -55,12 +55,13 @@ type ociPackage struct {
 	manifest *Manifest
 
 	// Embedded attributes which represent target.Target
-	arch    arch.Architecture
-	plat    plat.Platform
-	kconfig kconfig.KeyValueMap
-	kernel  string
-	initrd  initrd.Initrd
-	command []string
+	arch      arch.Architecture
+	plat      plat.Platform
+	kconfig   kconfig.KeyValueMap
+	kernel    string
+	kernelDbg string
+	initrd    initrd.Initrd
+	command   []string
 }
 
 var (
@@ -80,12 +81,13 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 
 	// Initialize the ociPackage by copying over target.Target attributes
 	ocipack := ociPackage{
-		arch:    targ.Architecture(),
-		plat:    targ.Platform(),
-		kconfig: targ.KConfig(),
-		kernel:  targ.Kernel(),
-		initrd:  targ.Initrd(),
-		command: popts.Args(),
+		arch:      targ.Architecture(),
+		plat:      targ.Platform(),
+		kconfig:   targ.KConfig(),
+		kernel:    targ.Kernel(),
+		kernelDbg: targ.KernelDbg(),
+		initrd:    targ.Initrd(),
+		command:   popts.Args(),
 	}
 
 	if popts.Name() == "" {
@@ -160,6 +162,28 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 		return nil, fmt.Errorf("could not add layer to manifest: %w", err)
 	}
 
+	if popts.PackKernelDbg() && ocipack.kernelDbg != "" && ocipack.kernelDbg != ocipack.Kernel() {
+		log.G(ctx).WithFields(logrus.Fields{
+			"src":  ocipack.kernelDbg,
+			"dest": WellKnownKernelDbgPath,
+		}).Debug("oci: including debuggable kernel")
+
+		layer, err := NewLayerFromFile(ctx,
+			ocispec.MediaTypeImageLayer,
+			ocipack.kernelDbg,
+			WellKnownKernelDbgPath,
+			WithLayerAnnotation(AnnotationKernelDbgPath, WellKnownKernelDbgPath),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not create new layer structure from file: %w", err)
+		}
+		defer os.Remove(layer.tmp)
+
+		if _, err := ocipack.manifest.AddLayer(ctx, layer); err != nil {
+			return nil, fmt.Errorf("could not add layer to manifest: %w", err)
+		}
+	}
+
 	if popts.Initrd() != "" {
 		log.G(ctx).
 			WithField("src", popts.Initrd()).
@@ -511,6 +535,12 @@ func (ocipack *ociPackage) Version() string {
 	return ocipack.ref.Identifier()
 }
 
+// ID returns the digest of the manifest of the package, which uniquely
+// identifies it.
+func (ocipack *ociPackage) ID() string {
+	return ocipack.manifest.desc.Digest.String()
+}
+
 // imageRef returns the OCI-standard image name in the format `name:tag`
 func (ocipack *ociPackage) imageRef() string {
 	if strings.HasPrefix(ocipack.Version(), "sha256:") {
@@ -588,6 +618,13 @@ func (ocipack *ociPackage) Pull(ctx context.Context, opts ...pack.PullOption) er
 		// Set the kernel, since it is a well-known within the destination path
 		ocipack.kernel = filepath.Join(popts.Workdir(), WellKnownKernelPath)
 
+		// Set the debuggable kernel if it has been packaged
+		ocipack.kernelDbg = ""
+		kernelDbg := filepath.Join(popts.Workdir(), WellKnownKernelDbgPath)
+		if f, err := os.Stat(kernelDbg); err == nil && f.Size() > 0 {
+			ocipack.kernelDbg = kernelDbg
+		}
+
 		// Set the command
 		ocipack.command = image.Config.Cmd
 
@@ -692,6 +729,10 @@ func (ocipack *ociPackage) Kernel() string {
 
 // KernelDbg implements unikraft.target.Target
 func (ocipack *ociPackage) KernelDbg() string {
+	if ocipack.kernelDbg != "" {
+		return ocipack.kernelDbg
+	}
+
 	return ocipack.kernel
 }
 
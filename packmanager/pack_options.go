@@ -11,6 +11,7 @@ type PackOptions struct {
 	args                             []string
 	initrd                           string
 	kconfig                          bool
+	kernelDbg                        bool
 	kernelLibraryIntermediateObjects bool
 	kernelLibraryObjects             bool
 	kernelSourceFiles                bool
@@ -49,6 +50,12 @@ func (popts *PackOptions) PackKConfig() bool {
 	return popts.kconfig
 }
 
+// PackKernelDbg returns whether the debuggable (symbolic) kernel image should
+// be packaged alongside the kernel image.
+func (popts *PackOptions) PackKernelDbg() bool {
+	return popts.kernelDbg
+}
+
 // PackKernelLibraryIntermediateObjects returns whether to package intermediate
 // kernel library object files.
 func (popts *PackOptions) PackKernelLibraryIntermediateObjects() bool {
@@ -116,6 +123,14 @@ func PackInitrd(initrd string) PackOption {
 	}
 }
 
+// PackKernelDbg marks to include the debuggable (symbolic) kernel image
+// alongside the kernel image.
+func PackKernelDbg(dbg bool) PackOption {
+	return func(popts *PackOptions) {
+		popts.kernelDbg = dbg
+	}
+}
+
 // PackKernelLibraryIntermediateObjects marks to include intermediate library
 // object files, e.g. libnolibc/errno.o
 func PackKernelLibraryIntermediateObjects(pack bool) PackOption {
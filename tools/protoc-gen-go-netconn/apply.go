@@ -186,6 +186,7 @@ import (
 	"reflect"
 {{ if .HasService -}}
 	"sync"
+	"time"
 {{ end }}
 )
 {{ end }}
@@ -194,10 +195,11 @@ import (
 	serviceTemplate = template.Must(template.New("service").Parse(ServiceTemplate))
 	ServiceTemplate = `
 type {{ .GoName }}Client struct {
-	conn io.ReadWriteCloser
-	lock sync.RWMutex
-	recv *bufio.Reader
-	send *bufio.Writer
+	conn   io.ReadWriteCloser
+	lock   sync.RWMutex
+	recv   *bufio.Reader
+	send   *bufio.Writer
+	events [][]byte
 }
 
 func New{{ .GoName }}Client(conn io.ReadWriteCloser) *{{ .GoName }}Client {
@@ -212,6 +214,75 @@ func (c *{{ .GoName }}Client) Close() error {
 	return c.conn.Close()
 }
 
+// recvResponse returns the next line received on the connection which is not
+// an asynchronous event, i.e. the response to the request which has just been
+// sent.  Events which are received in the meantime are retained such that
+// they can be awaited.
+func (c *{{ .GoName }}Client) recvResponse() ([]byte, error) {
+	for {
+		b, err := c.recv.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		var msg struct {
+			Event *string ` + "`" + `json:"event"` + "`" + `
+		}
+		if err := json.Unmarshal(b, &msg); err != nil || msg.Event == nil {
+			return b, nil
+		}
+
+		c.events = append(c.events, b)
+	}
+}
+
+// AwaitEvent blocks until an asynchronous event for which the provided
+// function returns true has been received, or until the timeout has elapsed
+// if the connection supports read deadlines.  Events which have been received
+// whilst awaiting responses are considered first.
+func (c *{{ .GoName }}Client) AwaitEvent(timeout time.Duration, match func(event string, data map[string]any) bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	matches := func(b []byte) bool {
+		var msg struct {
+			Event *string        ` + "`" + `json:"event"` + "`" + `
+			Data  map[string]any ` + "`" + `json:"data"` + "`" + `
+		}
+		if err := json.Unmarshal(b, &msg); err != nil || msg.Event == nil {
+			return false
+		}
+
+		return match(*msg.Event, msg.Data)
+	}
+
+	for i, b := range c.events {
+		if matches(b) {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return nil
+		}
+	}
+
+	if conn, ok := c.conn.(interface{ SetReadDeadline(time.Time) error }); ok {
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+
+		defer conn.SetReadDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	for {
+		b, err := c.recv.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+
+		if matches(b) {
+			return nil
+		}
+	}
+}
+
 func (c *{{ .GoName }}Client) setRpcRequestSetDefaults(face any) error {
 	v := reflect.ValueOf(face)
 
@@ -353,7 +424,7 @@ func (c *{{ .ServiceGoName }}Client) {{ .GoName }}(
 
 	{{ if $hasRes }}
 	var res {{ if $resAsAny }}any{{ else }}{{ .Output.GoIdent.GoName }}{{ end }}
-	b, err = c.recv.ReadBytes('\n')
+	b, err = c.recvResponse()
 	if err != nil {
 		return nil, err
 	}
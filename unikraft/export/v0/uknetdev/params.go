@@ -5,6 +5,10 @@
 package uknetdev
 
 import (
+	"net"
+	"strconv"
+	"strings"
+
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 )
 
@@ -12,11 +16,103 @@ var (
 	ParamIpv4Addr       = ukargparse.ParamStr("netdev", "ipv4_addr", nil)
 	ParamIpv4SubnetMask = ukargparse.ParamStr("netdev", "ipv4_subnet_mask", nil)
 	ParamIpv4GwAddr     = ukargparse.ParamStr("netdev", "ipv4_gw_addr", nil)
+
+	// ParamIp configures each network device of the unikernel, where the n-th
+	// value applies to the n-th device.
+	ParamIp = ukargparse.NewParamStrSlice("netdev", "ip", nil)
+
+	// ParamIp6 configures the IPv6 address of each network device of the
+	// unikernel, where the n-th value applies to the n-th device.
+	ParamIp6 = ukargparse.NewParamStrSlice("netdev", "ip6", nil)
 )
 
 // ExportedParams returns the parameters available by this exported library.
 func ExportedParams() []ukargparse.Param {
 	return []ukargparse.Param{
 		ParamIpv4Addr,
+		ParamIp,
+		ParamIp6,
+	}
+}
+
+// IpEntry is the configuration of a single network device.
+type IpEntry struct {
+	ip          string
+	netmask     string
+	gateway     string
+	nameservers []string
+}
+
+// NewIpEntry generates a structure that is representative of the
+// configuration of one of Unikraft's network devices.  The network mask is
+// provided in dotted-decimal notation, or in the notation of an IPv6 address
+// for IPv6 entries.  At most two nameservers are used.
+func NewIpEntry(ip, netmask, gateway string, nameservers ...string) IpEntry {
+	return IpEntry{
+		ip,
+		netmask,
+		gateway,
+		nameservers,
+	}
+}
+
+// String implements fmt.Stringer and returns a valid netdev.ip-formatted
+// entry, i.e. IP[/PREFIX][:GATEWAY][:DNS0][:DNS1].  IPv6 addresses are enclosed
+// in square brackets, e.g. [IP][/PREFIX][:[GATEWAY]], as used by netdev.ip6.
+func (entry IpEntry) String() string {
+	ip := bracket(entry.ip)
+	mask := net.ParseIP(entry.netmask).To4()
+
+	if strings.Contains(entry.ip, ":") {
+		mask = net.ParseIP(entry.netmask).To16()
+	}
+
+	if mask != nil && ip != "" {
+		ones, _ := net.IPMask(mask).Size()
+		ip += "/" + strconv.Itoa(ones)
 	}
+
+	fields := []string{ip, bracket(entry.gateway)}
+	for i, nameserver := range entry.nameservers {
+		if i == 2 {
+			break
+		}
+
+		fields = append(fields, bracket(nameserver))
+	}
+
+	return strings.TrimRight(strings.Join(fields, ":"), ":")
+}
+
+// bracket encloses the provided address in square brackets if it is an IPv6
+// address such that it can be separated from the other fields of an entry.
+func bracket(addr string) string {
+	if strings.Contains(addr, ":") {
+		return "[" + addr + "]"
+	}
+
+	return addr
+}
+
+// Positional returns the values of netdev.ip or netdev.ip6 for the provided
+// entries of consecutive network devices.  Since the n-th value applies to the
+// n-th device, values are only returned up to the first device without an
+// address.  The indices of the devices which follow it and have an address,
+// which therefore cannot be configured, are returned as well.
+func Positional(entries []IpEntry) ([]string, []int) {
+	var values []string
+	var skipped []int
+
+	for i, entry := range entries {
+		if entry.ip == "" {
+			continue
+		} else if len(values) < i {
+			skipped = append(skipped, i)
+			continue
+		}
+
+		values = append(values, entry.String())
+	}
+
+	return values, skipped
 }
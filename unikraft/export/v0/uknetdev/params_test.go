@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package uknetdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIpEntryString(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry IpEntry
+		want  string
+	}{
+		{
+			name:  "IPv4",
+			entry: NewIpEntry("172.18.0.2", "255.255.0.0", "172.18.0.1", "172.18.0.1"),
+			want:  "172.18.0.2/16:172.18.0.1:172.18.0.1",
+		},
+		{
+			name:  "IPv6",
+			entry: NewIpEntry("fd00::2", "ffff:ffff:ffff:ffff::", "fd00::1", "fd00::1"),
+			want:  "[fd00::2]/64:[fd00::1]:[fd00::1]",
+		},
+		{
+			name:  "without gateway",
+			entry: NewIpEntry("172.18.0.2", "255.255.0.0", ""),
+			want:  "172.18.0.2/16",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositional(t *testing.T) {
+	tests := []struct {
+		name        string
+		ips         []string
+		wantValues  []string
+		wantSkipped []int
+	}{
+		{
+			name:       "all",
+			ips:        []string{"172.18.0.2", "172.19.0.2"},
+			wantValues: []string{"172.18.0.2", "172.19.0.2"},
+		},
+		{
+			name:       "trailing without address",
+			ips:        []string{"172.18.0.2", ""},
+			wantValues: []string{"172.18.0.2"},
+		},
+		{
+			name:        "leading without address",
+			ips:         []string{"", "172.19.0.2", "", "172.20.0.2"},
+			wantSkipped: []int{1, 3},
+		},
+		{
+			name: "none",
+			ips:  []string{"", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []IpEntry
+			for _, ip := range tt.ips {
+				entries = append(entries, NewIpEntry(ip, "", ""))
+			}
+
+			values, skipped := Positional(entries)
+			if !reflect.DeepEqual(values, tt.wantValues) || !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("Positional() = (%v, %v), want (%v, %v)", values, skipped, tt.wantValues, tt.wantSkipped)
+			}
+		})
+	}
+}